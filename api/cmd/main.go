@@ -1,58 +1,179 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/bingxueshuang/devspaces/api/internal/space"
-	echojwt "github.com/labstack/echo-jwt/v4"
-
-	"github.com/bingxueshuang/devspaces/api/internal/auth"
+	"github.com/bingxueshuang/devspaces/api/internal/blob"
+	"github.com/bingxueshuang/devspaces/api/internal/config"
 	api "github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/bingxueshuang/devspaces/api/internal/server"
+	"github.com/bingxueshuang/devspaces/api/internal/space"
 	"github.com/bingxueshuang/devspaces/core"
-
-	"github.com/labstack/echo/v4"
+	"github.com/bingxueshuang/devspaces/db"
 )
 
-func PubkeyHandler(c echo.Context) error {
-	serverKey := c.Get("ServerKey").(api.KeyContext)
-	pk := serverKey.PKey.Bytes()
-	return api.SendOK(c, map[string]any{
-		"pubkey": hex.EncodeToString(pk),
-	})
+func main() {
+	if err := run(); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		log.Fatal(err)
+	}
 }
 
-func main() {
-	sk, pk, err := core.KeyGenServer()
+func run() error {
+	conf, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	e := echo.New()
-	e.HideBanner = true
-	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			kc := api.KeyContext{
-				Context: c,
-				SKey:    sk,
-				PKey:    pk,
-			}
-			c.Set("ServerKey", kc)
-			return next(c)
-		}
+	if err = conf.Validate(); err != nil {
+		return err
+	}
+	if conf.Print {
+		return conf.Write(os.Stdout)
+	}
+
+	sk, err := secretKey(conf.Keys.Server, "server", func() (*core.SKey, error) {
+		sk, _, err := core.KeyGenServer()
+		return sk, err
 	})
-	authGroup := e.Group("/auth")
-	auth.Setup(authGroup)
-	e.GET("/user/:uname", auth.UserHandler)
-	ptdGroup := e.Group("/space", echojwt.WithConfig(auth.Config))
-	space.Setup(ptdGroup)
-	e.GET("/dashboard", space.DashboardHandler, echojwt.WithConfig(auth.Config))
-	e.GET("/pubkey", PubkeyHandler)
-	e.GET("/", func(c echo.Context) error {
-		return api.SendOK(c, "hello world")
+	if err != nil {
+		return err
+	}
+	defer sk.Destroy()
+	pk := new(core.PKeyServer)
+	if err = pk.FromSKey(sk); err != nil {
+		return err
+	}
+	logKey, err := secretKey(conf.Keys.LogFile(), "log", func() (*core.SKey, error) {
+		sk, _, err := core.KeyGenCurve(pk.Curve)
+		return sk, err
 	})
+	if err != nil {
+		return err
+	}
+	defer logKey.Destroy()
+	if conf.JWT.Secret != "" {
+		api.TokenSecret = []byte(conf.JWT.Secret)
+	} else {
+		api.TokenSecret = make([]byte, 32)
+		if _, err = rand.Read(api.TokenSecret); err != nil {
+			return err
+		}
+	}
+	api.TokenTTL = time.Duration(conf.JWT.TTL)
+	blob.Default = &blob.Store{
+		Dir:     conf.Storage.BlobDir,
+		MaxSize: conf.Storage.BlobMaxSize,
+		Quota:   conf.Storage.BlobQuota,
+	}
+	space.RotationWindow = time.Duration(conf.Rotation.Window)
+	db.DefaultRetention = db.Retention{
+		MaxAge:   time.Duration(conf.Retention.MaxAge),
+		MaxCount: conf.Retention.MaxCount,
+	}
 
-	if err := e.Start(":5005"); err != http.ErrServerClosed {
-		log.Fatal(err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// streams end with the base context, as they never drain
+	base, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e := server.New(sk, pk, logKey)
+	server.Configure(e, conf.Options())
+	srv := &http.Server{
+		Addr:        conf.Listen.Addr,
+		Handler:     e,
+		BaseContext: func(net.Listener) context.Context { return base },
+	}
+	srv.RegisterOnShutdown(cancel)
+	if opts := conf.TLSOptions(); opts.Enabled() {
+		srv.TLSConfig, err = server.TLSConfig(opts)
+		if err != nil {
+			return err
+		}
+		for _, cert := range srv.TLSConfig.Certificates {
+			log.Printf("tls: self-signed certificate SHA256:%x", sha256.Sum256(cert.Certificate[0]))
+		}
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go space.Janitor(time.Duration(conf.Retention.Interval), done)
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Println("listening on", conf.Listen.Addr)
+		if srv.TLSConfig != nil {
+			errc <- srv.ListenAndServeTLS("", "")
+		} else {
+			errc <- srv.ListenAndServe()
+		}
+	}()
+	select {
+	case err = <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	log.Println("shutting down")
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(conf.Listen.ShutdownTimeout))
+	defer cancelShutdown()
+	if err = srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	if err = <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// secretKey reads the hex encoded secret key from the file, which is
+// written with a key of generate if it does not exist. An empty file
+// name generates a new key.
+func secretKey(file, name string, generate func() (*core.SKey, error)) (*core.SKey, error) {
+	if file == "" {
+		return generate()
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		sk, err := generate()
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("generated the %s key %s", name, file)
+		return sk, os.WriteFile(file, []byte(hex.EncodeToString(sk.Bytes())), 0600)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s key %s: %w", name, file, err)
+	}
+	// the decoded bytes of secret keys are not kept around
+	defer func() {
+		for i := range b {
+			b[i] = 0
+		}
+	}()
+	sk := new(core.SKey)
+	if err = sk.FromBytes(b); err != nil {
+		return nil, fmt.Errorf("%s key %s: %w", name, file, err)
 	}
+	return sk, nil
 }
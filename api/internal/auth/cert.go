@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/bingxueshuang/devspaces/db"
+	"github.com/golang-jwt/jwt/v4"
+	echojwt "github.com/labstack/echo-jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// certUser is the username of the verified client certificate
+// of the request, its subject common name.
+func certUser(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return "", false
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName, true
+}
+
+// Required authenticates the user of the request by the client
+// certificate of a mutual TLS connection, if verified, or else by
+// the login token. Handlers find the username in the claims of the
+// "user" token either way.
+func Required() echo.MiddlewareFunc {
+	withToken := echojwt.WithConfig(tokenConfig())
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		tokenNext := withToken(next)
+		return func(c echo.Context) error {
+			uname, ok := certUser(c.Request())
+			if !ok {
+				return tokenNext(c)
+			}
+			ok, _, err := db.GetUser(uname)
+			if err != nil {
+				return core.ServerError(err)
+			}
+			if !ok {
+				return core.ErrUnauthorized
+			}
+			c.Set("user", &jwt.Token{
+				Claims: &core.TokenClaims{Username: uname},
+				Valid:  true,
+			})
+			return next(c)
+		}
+	}
+}
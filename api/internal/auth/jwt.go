@@ -5,7 +5,6 @@ import (
 
 	"github.com/bingxueshuang/devspaces/api/internal/core"
 	"github.com/golang-jwt/jwt/v4"
-	_ "github.com/labstack/echo-jwt/v4"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 )
@@ -14,7 +13,7 @@ func getToken(username string) (string, error) {
 	claims := core.TokenClaims{
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 72)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(core.TokenTTL)),
 		},
 	}
 	// create a token with claims
@@ -23,9 +22,16 @@ func getToken(username string) (string, error) {
 	return token.SignedString(core.TokenSecret)
 }
 
-var Config = echojwt.Config{
-	NewClaimsFunc: func(c echo.Context) jwt.Claims {
-		return new(core.TokenClaims)
-	},
-	SigningKey: core.TokenSecret,
+// tokenConfig verifies the login tokens signed with the current
+// core.TokenSecret.
+func tokenConfig() echojwt.Config {
+	return echojwt.Config{
+		NewClaimsFunc: func(c echo.Context) jwt.Claims {
+			return new(core.TokenClaims)
+		},
+		SigningKey: core.TokenSecret,
+		ErrorHandler: func(c echo.Context, err error) error {
+			return core.ErrUnauthorized.WithInternal(err)
+		},
+	}
 }
@@ -19,10 +19,10 @@ func validateLogin(u *core.User) bool {
 func LoginHandler(c echo.Context) error {
 	req := new(core.User)
 	if err := c.Bind(req); err != nil {
-		return core.BadRequest(c, "invalid request body", err)
+		return core.ErrInvalidBody.WithDetail(err)
 	}
 	if !validateLogin(req) {
-		return core.BadRequest(c, "invalid request body", nil)
+		return core.ErrMissingFields
 	}
 	u := &db.User{
 		Username: *req.Username,
@@ -30,14 +30,14 @@ func LoginHandler(c echo.Context) error {
 	}
 	ok, err := db.MatchUser(u)
 	if err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
 	if !ok {
-		return core.BadRequest(c, "invalid username or password", nil)
+		return core.ErrInvalidCredentials
 	}
 	token, err := getToken(*req.Username)
 	if err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
 	return core.SendOK(c, map[string]string{
 		"token": token,
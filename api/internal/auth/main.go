@@ -5,6 +5,7 @@ import (
 )
 
 func Setup(g *echo.Group) {
+	g.GET("/nonce", NonceHandler)
 	g.POST("/register", RegisterHandler)
 	g.POST("/login", LoginHandler)
 	//g.GET("/debug", func(c echo.Context) error {
@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/labstack/echo/v4"
+)
+
+// NonceTTL is the duration for which a nonce can be used
+// in a proof of possession of a key.
+var NonceTTL = 5 * time.Minute
+
+// NonceHandler issues a nonce, which is signed in a proof of
+// possession when registering or updating a public key.
+func NonceHandler(c echo.Context) error {
+	n, err := core.NewNonce(time.Now().Add(NonceTTL))
+	if err != nil {
+		return core.ServerError(err)
+	}
+	return core.SendOK(c, map[string]any{
+		"nonce":   hex.EncodeToString(n.Value),
+		"expires": n.Expires,
+	})
+}
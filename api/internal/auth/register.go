@@ -1,9 +1,6 @@
 package auth
 
 import (
-	"encoding/hex"
-	"errors"
-
 	"github.com/bingxueshuang/devspaces/api/internal/core"
 	"github.com/bingxueshuang/devspaces/db"
 	"github.com/labstack/echo/v4"
@@ -13,7 +10,9 @@ func validateRegister(u *core.User) bool {
 	if u == nil ||
 		u.Username == nil ||
 		u.Password == nil ||
-		u.PubKey == nil {
+		u.PubKey == nil ||
+		u.Nonce == nil ||
+		u.Proof == nil {
 		return false
 	}
 	return true
@@ -22,14 +21,19 @@ func validateRegister(u *core.User) bool {
 func RegisterHandler(c echo.Context) error {
 	req := new(core.User)
 	if err := c.Bind(req); err != nil {
-		return core.BadRequest(c, "invalid request body", err)
+		return core.ErrInvalidBody.WithDetail(err)
 	}
 	if !validateRegister(req) {
-		return core.BadRequest(c, "invalid request body", nil)
+		return core.ErrMissingFields
+	}
+	pubkey, err := core.DecodePubkey(c, *req.PubKey)
+	if err != nil {
+		return err
 	}
-	pubkey, err := hex.DecodeString(*req.PubKey)
+	// the user proves to hold the secret key of the public key
+	err = core.CheckPossession(*req.Username, pubkey, *req.Nonce, *req.Proof)
 	if err != nil {
-		return core.BadRequest(c, "invalid public key", err)
+		return err
 	}
 	u := &db.User{
 		Username: *req.Username,
@@ -38,10 +42,10 @@ func RegisterHandler(c echo.Context) error {
 	}
 	ok, err := db.AddUser(u)
 	if err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
 	if !ok {
-		return core.BadRequest(c, "invalid username", errors.New("username already exists"))
+		return core.ErrUserExists
 	}
 	return core.SendOK(c, nil)
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/bingxueshuang/devspaces/api/internal/core"
 	"github.com/bingxueshuang/devspaces/db"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
 )
 
@@ -12,13 +13,82 @@ func UserHandler(c echo.Context) error {
 	username := c.Param("uname")
 	ok, user, err := db.GetUser(username)
 	if err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
 	if !ok {
-		return core.NotFound(c, "username do not exist")
+		return core.ErrUserNotFound
 	}
 	return core.SendOK(c, map[string]any{
 		"username": user.Username,
 		"pubkey":   hex.EncodeToString(user.Pubkey),
+		"version":  user.KeyVersion(),
+	})
+}
+
+// KeysHandler lists the versions of the public key of the user
+// with their validity periods, the current key last.
+func KeysHandler(c echo.Context) error {
+	username := c.Param("uname")
+	ok, user, err := db.GetUser(username)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	if !ok {
+		return core.ErrUserNotFound
+	}
+	res := make([]map[string]any, 0, len(user.Keys))
+	for _, k := range user.Keys {
+		key := map[string]any{
+			"version": k.Version,
+			"pubkey":  hex.EncodeToString(k.Pubkey),
+			"from":    k.From,
+			"until":   nil,
+		}
+		if !k.Until.IsZero() {
+			key["until"] = k.Until
+		}
+		res = append(res, key)
+	}
+	return core.SendOK(c, res)
+}
+
+func validateUserKey(k *core.UserKey) bool {
+	if k == nil || k.Pubkey == nil || k.Nonce == nil || k.Proof == nil {
+		return false
+	}
+	return true
+}
+
+// RotateKeyHandler replaces the public key of the logged in user. Tags
+// made for the previous key no longer match the messages of the user,
+// and are re-issued by the owners of the devspaces.
+func RotateKeyHandler(c echo.Context) error {
+	req := new(core.UserKey)
+	if err := c.Bind(req); err != nil {
+		return core.ErrInvalidBody.WithDetail(err)
+	}
+	if !validateUserKey(req) {
+		return core.ErrMissingFields
+	}
+	pubkey, err := core.DecodePubkey(c, *req.Pubkey)
+	if err != nil {
+		return err
+	}
+	u := c.Get("user").(*jwt.Token)
+	username := u.Claims.(*core.TokenClaims).Username
+	// the user proves to hold the new secret key
+	err = core.CheckPossession(username, pubkey, *req.Nonce, *req.Proof)
+	if err != nil {
+		return err
+	}
+	ok, version, err := db.RotateUserKey(username, pubkey)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	if !ok {
+		return core.ErrUserNotFound
+	}
+	return core.SendOK(c, map[string]any{
+		"version": version,
 	})
 }
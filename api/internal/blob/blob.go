@@ -0,0 +1,116 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrTooLarge = errors.New("blob exceeds the size limit")
+	ErrDigest   = errors.New("invalid blob digest")
+	ErrNotFound = errors.New("blob not found")
+)
+
+// Store is a content-addressed blob storage on the file system.
+// Blobs are named by the hex encoded SHA-256 of their content.
+type Store struct {
+	Dir string
+	// MaxSize is the largest blob accepted by Put.
+	MaxSize int64
+	// Quota is the largest total size of the blobs uploaded to a
+	// devspace, zero for no limit.
+	Quota int64
+}
+
+var Default = &Store{
+	Dir:     filepath.Join(os.TempDir(), "devspaces-blobs"),
+	MaxSize: 64 << 20,
+}
+
+// Digest returns the digest of the content.
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (s *Store) path(digest string) (string, error) {
+	sum, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok || len(sum) != 2*sha256.Size {
+		return "", ErrDigest
+	}
+	if _, err := hex.DecodeString(sum); err != nil {
+		return "", ErrDigest
+	}
+	return filepath.Join(s.Dir, sum[:2], sum), nil
+}
+
+// Put streams the content of r into the store without buffering
+// it in memory and returns its digest and size.
+func (s *Store) Put(r io.Reader) (digest string, size int64, err error) {
+	err = os.MkdirAll(s.Dir, 0o700)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(s.Dir, "upload-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	h := sha256.New()
+	// read one byte past the limit to detect oversized blobs
+	size, err = io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, s.MaxSize+1))
+	if err != nil {
+		return
+	}
+	if size > s.MaxSize {
+		err = ErrTooLarge
+		return
+	}
+	err = tmp.Close()
+	if err != nil {
+		return
+	}
+	digest = "sha256:" + hex.EncodeToString(h.Sum(nil))
+	name, err := s.path(digest)
+	if err != nil {
+		return
+	}
+	err = os.MkdirAll(filepath.Dir(name), 0o700)
+	if err != nil {
+		return
+	}
+	err = os.Rename(tmp.Name(), name)
+	return
+}
+
+// Open opens the blob with the given digest for reading.
+func (s *Store) Open(digest string) (*os.File, error) {
+	name, err := s.path(digest)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Remove removes the blob with the given digest, if it exists.
+func (s *Store) Remove(digest string) error {
+	name, err := s.path(digest)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,412 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/api/internal/server"
+	"github.com/bingxueshuang/devspaces/api/internal/space"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalid = errors.New("invalid configuration")
+
+// EnvPrefix prefixes the environment variables of the settings,
+// which are named after their flags, as DEVSPACES_ADDR for -addr.
+const EnvPrefix = "DEVSPACES_"
+
+// Config is the configuration of the api server. Its defaults are
+// overridden by the configuration file, the environment and the
+// flags, in this order.
+type Config struct {
+	Listen    Listen    `yaml:"listen"`
+	TLS       TLS       `yaml:"tls"`
+	Storage   Storage   `yaml:"storage"`
+	Keys      Keys      `yaml:"keys"`
+	JWT       JWT       `yaml:"jwt"`
+	CORS      CORS      `yaml:"cors"`
+	RateLimit RateLimit `yaml:"rate-limit"`
+	Retention Retention `yaml:"retention"`
+	Rotation  Rotation  `yaml:"rotation"`
+	Log       Log       `yaml:"log"`
+
+	// File is the configuration file read, if any.
+	File string `yaml:"-"`
+	// Print asks to print the configuration instead of serving.
+	Print bool `yaml:"-"`
+}
+
+// Listen configures the address of the server.
+type Listen struct {
+	Addr string `yaml:"addr"`
+	// ShutdownTimeout is the time to drain in-flight requests on shutdown.
+	ShutdownTimeout Duration `yaml:"shutdown-timeout"`
+}
+
+// TLS configures the TLS of the server, see server.TLSOptions.
+type TLS struct {
+	Cert       string `yaml:"cert"`
+	Key        string `yaml:"key"`
+	SelfSigned bool   `yaml:"self-signed"`
+	ClientCA   string `yaml:"client-ca"`
+}
+
+// Storage backends of the database.
+const BackendMemory = "memory"
+
+// Storage configures the database and the attachments.
+type Storage struct {
+	Backend string `yaml:"backend"`
+	DSN     string `yaml:"dsn"`
+	// BlobDir, BlobMaxSize and BlobQuota configure the store of the
+	// attachments, see blob.Store.
+	BlobDir     string `yaml:"blob-dir"`
+	BlobMaxSize int64  `yaml:"blob-max-size"`
+	BlobQuota   int64  `yaml:"blob-quota"`
+}
+
+// Keys configures the key material of the server.
+type Keys struct {
+	// Server is the file of the hex encoded secret key of the server,
+	// generated if it does not exist. An empty location generates
+	// a new key on every start.
+	Server string `yaml:"server"`
+	// Log is the file of the hex encoded secret key signing the key
+	// log, generated if it does not exist, log.key next to the server
+	// key by default. Clients pin the log key, and fail to verify keys
+	// once it changes.
+	Log string `yaml:"log"`
+}
+
+// LogFile is the file of the log key, empty if the key is generated
+// on every start.
+func (k Keys) LogFile() string {
+	if k.Log != "" || k.Server == "" {
+		return k.Log
+	}
+	return filepath.Join(filepath.Dir(k.Server), "log.key")
+}
+
+// JWT configures the login tokens.
+type JWT struct {
+	// Secret signs the login tokens. A random secret is generated
+	// on every start if empty.
+	Secret string   `yaml:"secret"`
+	TTL    Duration `yaml:"ttl"`
+}
+
+// CORS configures the cross-origin requests.
+type CORS struct {
+	Origins Strings `yaml:"origins"`
+}
+
+// RateLimit limits the requests of every client address.
+type RateLimit struct {
+	// RPS is the number of requests per second allowed from
+	// every client address, zero for no limit.
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+// Retention configures the removal of expired messages.
+type Retention struct {
+	// Interval is the time between the removals of expired messages.
+	Interval Duration `yaml:"interval"`
+	// MaxAge and MaxCount are the retention policy of the devspaces
+	// which have none of their own, zero for no limit.
+	MaxAge   Duration `yaml:"max-age"`
+	MaxCount int      `yaml:"max-count"`
+}
+
+// Rotation configures the key rotations of the devspaces.
+type Rotation struct {
+	// Window is the time for which the previous key of a devspace keeps
+	// routing messages after a rotation which asks for no window.
+	Window Duration `yaml:"window"`
+}
+
+// Log configures the server logs.
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+// Default returns the default configuration.
+func Default() *Config {
+	return &Config{
+		Listen: Listen{
+			Addr:            ":5005",
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		Storage: Storage{
+			Backend:     BackendMemory,
+			BlobDir:     filepath.Join(os.TempDir(), "devspaces-blobs"),
+			BlobMaxSize: 64 << 20,
+			BlobQuota:   1 << 30,
+		},
+		JWT:       JWT{TTL: Duration(72 * time.Hour)},
+		Retention: Retention{Interval: Duration(time.Minute)},
+		Rotation:  Rotation{Window: Duration(7 * 24 * time.Hour)},
+		Log:       Log{Level: "info"},
+	}
+}
+
+// flags binds the settings of c to the flags of fs.
+func (c *Config) flags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Listen.Addr, "addr", c.Listen.Addr, "address to listen on")
+	fs.Var(&c.Listen.ShutdownTimeout, "shutdown-timeout", "time to drain in-flight requests on shutdown")
+	fs.StringVar(&c.TLS.Cert, "tls-cert", c.TLS.Cert, "PEM certificate chain file, reloaded when changed")
+	fs.StringVar(&c.TLS.Key, "tls-key", c.TLS.Key, "PEM private key file of the certificate")
+	fs.BoolVar(&c.TLS.SelfSigned, "tls-self-signed", c.TLS.SelfSigned, "serve a self-signed certificate for localhost, for development")
+	fs.StringVar(&c.TLS.ClientCA, "client-ca", c.TLS.ClientCA, "PEM file of the CAs of client certificates, whose common name is the username")
+	fs.StringVar(&c.Storage.Backend, "storage", c.Storage.Backend, "storage backend of the database: memory")
+	fs.StringVar(&c.Storage.DSN, "storage-dsn", c.Storage.DSN, "data source name of the storage backend")
+	fs.StringVar(&c.Storage.BlobDir, "blob-dir", c.Storage.BlobDir, "directory of the attachments")
+	fs.Int64Var(&c.Storage.BlobMaxSize, "blob-max-size", c.Storage.BlobMaxSize, "largest attachment in bytes")
+	fs.Int64Var(&c.Storage.BlobQuota, "blob-quota", c.Storage.BlobQuota, "largest total size in bytes of the attachments of a devspace, 0 for no limit")
+	fs.StringVar(&c.Keys.Server, "server-key", c.Keys.Server, "file of the secret key of the server, generated if missing")
+	fs.StringVar(&c.Keys.Log, "log-key", c.Keys.Log, "file of the secret key signing the key log, generated if missing, log.key next to the server key if empty")
+	fs.StringVar(&c.JWT.Secret, "jwt-secret", c.JWT.Secret, "secret signing the login tokens, random if empty")
+	fs.Var(&c.JWT.TTL, "jwt-ttl", "validity of the login tokens")
+	fs.Var(&c.CORS.Origins, "cors-origins", "comma separated origins allowed to make cross-origin requests")
+	fs.Float64Var(&c.RateLimit.RPS, "rate-limit", c.RateLimit.RPS, "requests per second allowed from every client address, 0 for no limit")
+	fs.IntVar(&c.RateLimit.Burst, "rate-burst", c.RateLimit.Burst, "burst of requests allowed above the rate limit")
+	fs.Var(&c.Retention.Interval, "retention-interval", "time between the removals of expired messages")
+	fs.Var(&c.Retention.MaxAge, "retention-max-age", "age of the messages kept by devspaces without a retention policy, 0 for no limit")
+	fs.IntVar(&c.Retention.MaxCount, "retention-max-count", c.Retention.MaxCount, "messages kept per tag by devspaces without a retention policy, 0 for no limit")
+	fs.Var(&c.Rotation.Window, "rotation-window", "time the previous key of a devspace keeps routing after a rotation, 0 to retire it at once")
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "log level: debug, info, warn, error or off")
+	fs.StringVar(&c.File, "config", c.File, "YAML configuration file")
+	fs.BoolVar(&c.Print, "print-config", c.Print, "print the configuration and exit")
+}
+
+// envName is the environment variable of the flag.
+func envName(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// Load reads the configuration from the file given by the -config
+// flag or its environment variable, the environment and the flags
+// in args. It is not validated.
+func Load(name string, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	// the flags are parsed first to find the configuration file
+	first := flag.NewFlagSet(name, flag.ContinueOnError)
+	pre := Default()
+	pre.flags(first)
+	if err := first.Parse(args); err != nil {
+		return nil, err
+	}
+	file := pre.File
+	if file == "" {
+		file, _ = lookupEnv(envName("config"))
+	}
+
+	c := Default()
+	if file != "" {
+		if err := c.readFile(file); err != nil {
+			return nil, err
+		}
+		c.File = file
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c.flags(fs)
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := lookupEnv(envName(f.Name))
+		if !ok || err != nil {
+			return
+		}
+		if err = fs.Set(f.Name, value); err != nil {
+			err = fmt.Errorf("%s: %w", envName(f.Name), err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) readFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err = dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Validate checks the configuration, reporting every invalid setting.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+	if _, _, err := net.SplitHostPort(c.Listen.Addr); err != nil {
+		invalid("listen.addr", "%v", err)
+	}
+	if c.Listen.ShutdownTimeout <= 0 {
+		invalid("listen.shutdown-timeout", "must be positive")
+	}
+	if (c.TLS.Cert == "") != (c.TLS.Key == "") {
+		invalid("tls", "the certificate and key files go together")
+	}
+	if c.TLS.ClientCA != "" && !c.TLSOptions().Enabled() {
+		invalid("tls.client-ca", "client certificates need tls")
+	}
+	switch c.Storage.Backend {
+	case BackendMemory:
+		if c.Storage.DSN != "" {
+			invalid("storage.dsn", "the memory backend takes no dsn")
+		}
+	default:
+		invalid("storage.backend", "unsupported backend %q, the supported backends are: %s", c.Storage.Backend, BackendMemory)
+	}
+	if c.Storage.BlobDir == "" {
+		invalid("storage.blob-dir", "must not be empty")
+	}
+	if c.Storage.BlobMaxSize <= 0 {
+		invalid("storage.blob-max-size", "must be positive")
+	}
+	if c.Storage.BlobQuota < 0 {
+		invalid("storage.blob-quota", "must not be negative")
+	}
+	if c.JWT.Secret != "" && len(c.JWT.Secret) < 32 {
+		invalid("jwt.secret", "must be at least 32 bytes long")
+	}
+	if c.JWT.TTL <= 0 {
+		invalid("jwt.ttl", "must be positive")
+	}
+	for _, origin := range c.CORS.Origins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			invalid("cors.origins", "%q is not an origin such as https://example.com", origin)
+		}
+	}
+	if c.RateLimit.RPS < 0 {
+		invalid("rate-limit.rps", "must not be negative")
+	}
+	if c.RateLimit.Burst < 0 {
+		invalid("rate-limit.burst", "must not be negative")
+	}
+	if c.Retention.Interval <= 0 {
+		invalid("retention.interval", "must be positive")
+	}
+	if c.Retention.MaxAge < 0 {
+		invalid("retention.max-age", "must not be negative")
+	}
+	if c.Retention.MaxCount < 0 {
+		invalid("retention.max-count", "must not be negative")
+	}
+	if c.Rotation.Window < 0 || time.Duration(c.Rotation.Window) > space.MaxRotationWindow {
+		invalid("rotation.window", "must be between 0s and %v", space.MaxRotationWindow)
+	}
+	if _, ok := server.LogLevels[c.Log.Level]; !ok {
+		invalid("log.level", "unknown level %q", c.Log.Level)
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%w:\n%w", ErrInvalid, errors.Join(errs...))
+	}
+	return nil
+}
+
+// TLSOptions are the TLS options of the server.
+func (c *Config) TLSOptions() server.TLSOptions {
+	return server.TLSOptions{
+		CertFile:     c.TLS.Cert,
+		KeyFile:      c.TLS.Key,
+		SelfSigned:   c.TLS.SelfSigned,
+		ClientCAFile: c.TLS.ClientCA,
+	}
+}
+
+// Options are the middleware options of the server.
+func (c *Config) Options() server.Options {
+	return server.Options{
+		CORSOrigins: c.CORS.Origins,
+		RateLimit:   c.RateLimit.RPS,
+		RateBurst:   c.RateLimit.Burst,
+		LogLevel:    c.Log.Level,
+	}
+}
+
+// Redacted is printed in place of secrets.
+const Redacted = "<redacted>"
+
+// Write writes the configuration as YAML, which can be read back as
+// the configuration file, except for the secrets which are redacted.
+func (c *Config) Write(w io.Writer) error {
+	r := *c
+	if r.JWT.Secret != "" {
+		r.JWT.Secret = Redacted
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&r); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Duration is a time.Duration written as in "1m30s".
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	return d.Set(s)
+}
+
+// Strings is a list of strings set from a comma separated list.
+type Strings []string
+
+func (s Strings) String() string {
+	return strings.Join(s, ",")
+}
+
+func (s *Strings) Set(v string) error {
+	*s = nil
+	for _, e := range strings.Split(v, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			*s = append(*s, e)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func handleFatal(e error, i interface{ Fatal(args ...any) }) {
+	if e != nil {
+		i.Fatal(e)
+	}
+}
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "api.yaml")
+	err := os.WriteFile(file, []byte(`
+listen:
+  addr: 127.0.0.1:8000
+jwt:
+  ttl: 1h
+cors:
+  origins: [https://a.example.com]
+log:
+  level: warn
+`), 0644)
+	handleFatal(err, t)
+
+	t.Run("precedence", func(t *testing.T) {
+		c, err := Load("api", []string{"-log-level", "debug"}, env(map[string]string{
+			"DEVSPACES_CONFIG":       file,
+			"DEVSPACES_JWT_TTL":      "2h",
+			"DEVSPACES_LOG_LEVEL":    "error",
+			"DEVSPACES_CORS_ORIGINS": "https://b.example.com, https://c.example.com",
+		}))
+		handleFatal(err, t)
+		handleFatal(c.Validate(), t)
+		if c.Listen.Addr != "127.0.0.1:8000" {
+			t.Logf("expected: %v, got: %v", "127.0.0.1:8000", c.Listen.Addr)
+			t.Fatal("file is expected to override the defaults")
+		}
+		if c.JWT.TTL != Duration(2*time.Hour) {
+			t.Logf("expected: %v, got: %v", 2*time.Hour, c.JWT.TTL)
+			t.Fatal("environment is expected to override the file")
+		}
+		if c.Log.Level != "debug" {
+			t.Logf("expected: %v, got: %v", "debug", c.Log.Level)
+			t.Fatal("flags are expected to override the environment")
+		}
+		want := Strings{"https://b.example.com", "https://c.example.com"}
+		if !reflect.DeepEqual(c.CORS.Origins, want) {
+			t.Logf("expected: %v, got: %v", want, c.CORS.Origins)
+			t.Fatal("incorrect origins")
+		}
+		if c.Retention.Interval != Duration(time.Minute) {
+			t.Fatal("unset settings are expected to keep their defaults")
+		}
+	})
+	t.Run("flag file", func(t *testing.T) {
+		c, err := Load("api", []string{"-config", file}, env(nil))
+		handleFatal(err, t)
+		if c.File != file || c.Log.Level != "warn" {
+			t.Fatal("file of the -config flag is expected to be read")
+		}
+	})
+	t.Run("unknown key", func(t *testing.T) {
+		bad := filepath.Join(t.TempDir(), "bad.yaml")
+		handleFatal(os.WriteFile(bad, []byte("listen:\n  port: 80\n"), 0644), t)
+		_, err := Load("api", []string{"-config", bad}, env(nil))
+		if err == nil {
+			t.Fatal("unknown keys of the file are expected to fail")
+		}
+	})
+	t.Run("print", func(t *testing.T) {
+		c, err := Load("api", []string{"-config", file, "-jwt-secret", strings.Repeat("s", 32)}, env(nil))
+		handleFatal(err, t)
+		var out bytes.Buffer
+		handleFatal(c.Write(&out), t)
+		if strings.Contains(out.String(), c.JWT.Secret) {
+			t.Fatal("secret is expected to be redacted")
+		}
+		// the printed configuration reads back
+		printed := filepath.Join(t.TempDir(), "printed.yaml")
+		handleFatal(os.WriteFile(printed, out.Bytes(), 0644), t)
+		got, err := Load("api", []string{"-config", printed}, env(nil))
+		handleFatal(err, t)
+		got.File, got.JWT.Secret = c.File, c.JWT.Secret
+		if !reflect.DeepEqual(got, c) {
+			t.Logf("expected: %+v, got: %+v", c, got)
+			t.Fatal("printed configuration is expected to read back")
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	handleFatal(Default().Validate(), t)
+	c := Default()
+	c.Listen.Addr = "5005"
+	c.Storage.Backend = "postgres"
+	c.JWT.Secret = "short"
+	c.TLS.ClientCA = "ca.pem"
+	c.RateLimit.RPS = -1
+	c.Rotation.Window = Duration(-time.Second)
+	err := c.Validate()
+	if !errors.Is(err, ErrInvalid) {
+		t.Logf("expected: %v, got: %v", ErrInvalid, err)
+		t.Fatal("incorrect error of an invalid configuration")
+	}
+	for _, key := range []string{"listen.addr", "storage.backend", "jwt.secret", "tls.client-ca", "rate-limit.rps", "rotation.window"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Logf("expected: %v, got: %v", key, err)
+			t.Fatal("every invalid setting is expected to be reported")
+		}
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	for _, c := range []struct {
+		keys Keys
+		want string
+	}{
+		{Keys{}, ""},
+		{Keys{Server: "/etc/devspaces/server.key"}, "/etc/devspaces/log.key"},
+		{Keys{Server: "/etc/devspaces/server.key", Log: "/var/lib/log.key"}, "/var/lib/log.key"},
+	} {
+		got := c.keys.LogFile()
+		if got != c.want {
+			t.Logf("expected: %v, got: %v", c.want, got)
+			t.Fatal("incorrect file of the log key")
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Code identifies the kind of an API error so that
+// clients need not parse the error message.
+type Code string
+
+const (
+	CodeInvalidBody        Code = "invalid_body"
+	CodeMissingFields      Code = "missing_fields"
+	CodeValidation         Code = "validation_failed"
+	CodeInvalidParameter   Code = "invalid_parameter"
+	CodeInvalidPubkey      Code = "invalid_pubkey"
+	CodeInvalidTrapdoor    Code = "invalid_trapdoor"
+	CodeInvalidKeyword     Code = "invalid_keyword"
+	CodeInvalidData        Code = "invalid_data"
+	CodeInvalidAttachment  Code = "invalid_attachment"
+	CodeInvalidSignature   Code = "invalid_signature"
+	CodeInvalidProof       Code = "invalid_proof"
+	CodeInvalidCredentials Code = "invalid_credentials"
+	CodeUnauthorized       Code = "unauthorized"
+	CodeForbidden          Code = "forbidden"
+	CodeNotFound           Code = "not_found"
+	CodeUserNotFound       Code = "user_not_found"
+	CodeSpaceNotFound      Code = "space_not_found"
+	CodeMessageNotFound    Code = "message_not_found"
+	CodeWebhookNotFound    Code = "webhook_not_found"
+	CodeBlobNotFound       Code = "blob_not_found"
+	CodeMethodNotAllowed   Code = "method_not_allowed"
+	CodeUserExists         Code = "user_exists"
+	CodeSpaceExists        Code = "space_exists"
+	CodeTagExists          Code = "tag_exists"
+	CodeTooLarge           Code = "payload_too_large"
+	CodeRateLimited        Code = "rate_limited"
+	CodeInternal           Code = "internal_error"
+)
+
+// Error is the error body of an API response. Handlers return
+// an *Error and ErrorHandler renders it into the response.
+type Error struct {
+	Status  int    `json:"-"`
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+	// Detail explains the error to the client and is safe to expose.
+	Detail string `json:"detail,omitempty"`
+	// Internal is the cause of the error, which is logged
+	// but never sent to the client.
+	Internal error `json:"-"`
+}
+
+func NewError(status int, code Code, msg string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: msg,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return string(e.Code) + ": " + e.Message + ": " + e.Detail
+	}
+	return string(e.Code) + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Internal
+}
+
+// Is reports errors with the same code as equal, so that
+// errors.Is works with the predefined errors.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetail returns a copy of the error whose detail is set
+// from err, which must be safe to expose to the client.
+func (e *Error) WithDetail(err error) *Error {
+	c := *e
+	var he *echo.HTTPError
+	switch {
+	case errors.As(err, &he):
+		c.Detail = fmt.Sprint(he.Message)
+	case err != nil:
+		c.Detail = err.Error()
+	}
+	return &c
+}
+
+// WithInternal returns a copy of the error wrapping err.
+func (e *Error) WithInternal(err error) *Error {
+	c := *e
+	c.Internal = err
+	return &c
+}
+
+var (
+	ErrInvalidBody        = NewError(http.StatusBadRequest, CodeInvalidBody, "invalid request body")
+	ErrMissingFields      = NewError(http.StatusUnprocessableEntity, CodeMissingFields, "missing fields in request body")
+	ErrValidation         = NewError(http.StatusUnprocessableEntity, CodeValidation, "invalid field in request body")
+	ErrInvalidParameter   = NewError(http.StatusBadRequest, CodeInvalidParameter, "invalid request parameter")
+	ErrInvalidPubkey      = NewError(http.StatusUnprocessableEntity, CodeInvalidPubkey, "invalid public key")
+	ErrInvalidTrapdoor    = NewError(http.StatusUnprocessableEntity, CodeInvalidTrapdoor, "invalid trapdoor")
+	ErrInvalidKeyword     = NewError(http.StatusUnprocessableEntity, CodeInvalidKeyword, "invalid encrypted keyword")
+	ErrInvalidData        = NewError(http.StatusUnprocessableEntity, CodeInvalidData, "invalid message data")
+	ErrInvalidAttachment  = NewError(http.StatusUnprocessableEntity, CodeInvalidAttachment, "invalid attachment")
+	ErrInvalidSignature   = NewError(http.StatusUnprocessableEntity, CodeInvalidSignature, "invalid message signature")
+	ErrInvalidProof       = NewError(http.StatusUnprocessableEntity, CodeInvalidProof, "invalid proof of possession of the key")
+	ErrInvalidCredentials = NewError(http.StatusUnauthorized, CodeInvalidCredentials, "invalid username or password")
+	ErrUnauthorized       = NewError(http.StatusUnauthorized, CodeUnauthorized, "missing or invalid login token")
+	ErrForbidden          = NewError(http.StatusForbidden, CodeForbidden, "devspace is not owned by the user")
+	ErrNotFound           = NewError(http.StatusNotFound, CodeNotFound, "resource do not exist")
+	ErrUserNotFound       = NewError(http.StatusNotFound, CodeUserNotFound, "username do not exist")
+	ErrSpaceNotFound      = NewError(http.StatusNotFound, CodeSpaceNotFound, "devspace do not exist")
+	ErrMessageNotFound    = NewError(http.StatusNotFound, CodeMessageNotFound, "message do not exist")
+	ErrWebhookNotFound    = NewError(http.StatusNotFound, CodeWebhookNotFound, "webhook do not exist")
+	ErrBlobNotFound       = NewError(http.StatusNotFound, CodeBlobNotFound, "attachment do not exist")
+	ErrMethodNotAllowed   = NewError(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed")
+	ErrUserExists         = NewError(http.StatusConflict, CodeUserExists, "username already exists")
+	ErrSpaceExists        = NewError(http.StatusConflict, CodeSpaceExists, "devspace already exists")
+	ErrTagExists          = NewError(http.StatusConflict, CodeTagExists, "tag already exists")
+	ErrTooLarge           = NewError(http.StatusRequestEntityTooLarge, CodeTooLarge, "request exceeds the size limit")
+	ErrRateLimited        = NewError(http.StatusTooManyRequests, CodeRateLimited, "too many requests, retry later")
+	ErrInternal           = NewError(http.StatusInternalServerError, CodeInternal, "sorry, could not process your request")
+)
+
+// ServerError wraps an unexpected error, hiding it from the client.
+func ServerError(err error) *Error {
+	return ErrInternal.WithInternal(err)
+}
+
+// httpErrors maps the errors raised by echo itself.
+var httpErrors = map[int]*Error{
+	http.StatusBadRequest:            ErrInvalidBody,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusMethodNotAllowed:      ErrMethodNotAllowed,
+	http.StatusRequestEntityTooLarge: ErrTooLarge,
+	http.StatusTooManyRequests:       ErrRateLimited,
+}
+
+// ErrorHandler is the echo HTTPErrorHandler rendering every
+// error returned by handlers and middleware into the response
+// envelope with an error code.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	e := new(Error)
+	var he *echo.HTTPError
+	switch {
+	case errors.As(err, &e):
+	case errors.As(err, &he):
+		e = httpErrors[he.Code]
+		if e == nil {
+			e = NewError(he.Code, CodeInternal, http.StatusText(he.Code))
+		}
+		e = e.WithInternal(err)
+	default:
+		e = ServerError(err)
+	}
+	if e.Internal != nil && e.Status >= http.StatusInternalServerError {
+		c.Logger().Error(e.Internal)
+	}
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(e.Status)
+	} else {
+		err = c.JSON(e.Status, Response{
+			Ok:    false,
+			Data:  nil,
+			Error: e,
+		})
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
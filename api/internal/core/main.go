@@ -1,9 +1,17 @@
 package core
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/bingxueshuang/devspaces/core"
+	"github.com/bingxueshuang/devspaces/db"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
 )
@@ -12,6 +20,106 @@ type KeyContext struct {
 	echo.Context
 	SKey *core.SKey
 	PKey *core.PKeyServer
+	// LogKey signs the tree heads of the key log.
+	LogKey *core.SKey
+}
+
+// DecodePubkey decodes the hex public key of a user or a devspace, which
+// has to be a valid key on the curve of the server.
+func DecodePubkey(c echo.Context, s string) ([]byte, error) {
+	pubkey, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidPubkey.WithDetail(err)
+	}
+	pk := new(core.PKey)
+	if err = pk.FromBytes(pubkey); err != nil {
+		return nil, ErrInvalidPubkey.WithDetail(err)
+	}
+	server := c.Get("ServerKey").(KeyContext).PKey
+	if pk.Curve != server.Curve {
+		return nil, ErrInvalidPubkey.WithDetail(core.ErrCurve)
+	}
+	return pubkey, nil
+}
+
+var errNonce = errors.New("unknown or expired nonce")
+
+// nonceSize is the size of the random part of a nonce, which is
+// followed by its expiry in unix milliseconds and by its MAC.
+const nonceSize = 16
+
+// nonceMAC authenticates the random part and the expiry of a nonce,
+// with a key derived from TokenSecret.
+func nonceMAC(m []byte) []byte {
+	key := hmac.New(sha256.New, TokenSecret)
+	key.Write([]byte("devspaces nonce"))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write(m)
+	return mac.Sum(nil)
+}
+
+// NewNonce issues a nonce which expires at the time. Nonces are not
+// stored, but carry their expiry and are authenticated by the server,
+// so that issuing them takes no memory.
+func NewNonce(expires time.Time) (*db.Nonce, error) {
+	value := make([]byte, nonceSize, nonceSize+8+sha256.Size)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+	expires = time.UnixMilli(expires.UnixMilli())
+	value = binary.BigEndian.AppendUint64(value, uint64(expires.UnixMilli()))
+	value = append(value, nonceMAC(value)...)
+	return &db.Nonce{Value: value, Expires: expires}, nil
+}
+
+// useNonce checks that the nonce was issued by NewNonce and has not
+// expired, and records its use.
+func useNonce(value []byte, now time.Time) (bool, error) {
+	if len(value) != nonceSize+8+sha256.Size {
+		return false, nil
+	}
+	m := value[:nonceSize+8]
+	if !hmac.Equal(value[nonceSize+8:], nonceMAC(m)) {
+		return false, nil
+	}
+	expires := time.UnixMilli(int64(binary.BigEndian.Uint64(m[nonceSize:])))
+	if !now.Before(expires) {
+		return false, nil
+	}
+	return db.UseNonce(&db.Nonce{Value: value, Expires: expires}, now)
+}
+
+// CheckPossession checks the hex proof that the subject holds the secret
+// key of pubkey, for the hex nonce issued by the server. The nonce is
+// used up, whether the proof is valid or not.
+func CheckPossession(subject string, pubkey []byte, nonce, proof string) error {
+	n, err := hex.DecodeString(nonce)
+	if err != nil {
+		return ErrInvalidProof.WithDetail(err)
+	}
+	p, err := hex.DecodeString(proof)
+	if err != nil {
+		return ErrInvalidProof.WithDetail(err)
+	}
+	ok, err := useNonce(n, time.Now())
+	if err != nil {
+		return ServerError(err)
+	}
+	if !ok {
+		return ErrInvalidProof.WithDetail(errNonce)
+	}
+	pk := new(core.PKey)
+	if err = pk.FromBytes(pubkey); err != nil {
+		return ErrInvalidPubkey.WithDetail(err)
+	}
+	ok, err = core.VerifyPossession(subject, n, p, pk)
+	if err != nil {
+		return ErrInvalidProof.WithDetail(err)
+	}
+	if !ok {
+		return ErrInvalidProof
+	}
+	return nil
 }
 
 type Request struct {
@@ -21,22 +129,61 @@ type Request struct {
 	Secret *string `json:"secret"`
 }
 
+type Attachment struct {
+	Name   *string `json:"name"`
+	Digest *string `json:"digest"`
+}
+
 type Message struct {
-	From    *string `json:"from"`
-	On      *string `json:"on"`
-	To      *string `json:"to"`
-	Keyword *string `json:"keyword"`
-	Data    *string `json:"data"`
+	From        *string      `json:"from"`
+	On          *string      `json:"on"`
+	To          *string      `json:"to"`
+	Keyword     *string      `json:"keyword"`
+	Data        *string      `json:"data"`
+	Attachments []Attachment `json:"attachments"`
+	Signature   *string      `json:"signature"`
+}
+
+type Mark struct {
+	Read *bool `json:"read"`
+}
+
+type RetentionPolicy struct {
+	MaxAge   *string `json:"max_age"`
+	MaxCount *int    `json:"max_count"`
+}
+
+type Webhook struct {
+	URL    *string `json:"url"`
+	Tag    *string `json:"tag"`
+	Secret *string `json:"secret"`
 }
 
 type Tag struct {
-	Name     *string `json:"from"`
+	Name     *string `json:"name"`
 	Trapdoor *string `json:"trapdoor"`
+	Append   *bool   `json:"append"`
+	Sender   *string `json:"sender"`
+}
+
+type Rotation struct {
+	Pubkey *string `json:"pubkey"`
+	Nonce  *string `json:"nonce"`
+	Proof  *string `json:"proof"`
+	Tags   []Tag   `json:"tags"`
+	Window *string `json:"window"`
+}
+
+type UserKey struct {
+	Pubkey *string `json:"pubkey"`
+	Nonce  *string `json:"nonce"`
+	Proof  *string `json:"proof"`
 }
 
 type DevSpace struct {
 	Name   *string `json:"name"`
 	Pubkey *string `json:"pubkey"`
+	Scheme *string `json:"scheme"`
 }
 
 type Response struct {
@@ -49,6 +196,8 @@ type User struct {
 	Username *string `json:"username"`
 	Password *string `json:"password"`
 	PubKey   *string `json:"pubkey"`
+	Nonce    *string `json:"nonce"`
+	Proof    *string `json:"proof"`
 }
 
 type TokenClaims struct {
@@ -56,7 +205,11 @@ type TokenClaims struct {
 	jwt.RegisteredClaims
 }
 
-var TokenSecret = []byte("secret message")
+// TokenSecret signs the login tokens, which are valid for TokenTTL.
+var (
+	TokenSecret = []byte("secret message")
+	TokenTTL    = 72 * time.Hour
+)
 
 func SendOK(c echo.Context, data any) error {
 	return c.JSON(http.StatusOK, Response{
@@ -65,35 +218,3 @@ func SendOK(c echo.Context, data any) error {
 		Error: nil,
 	})
 }
-
-func BadRequest(c echo.Context, msg string, err error) error {
-	var e any
-	if err != nil {
-		e = err.Error()
-	}
-	return c.JSON(http.StatusBadRequest, Response{
-		Ok:    false,
-		Data:  e,
-		Error: msg,
-	})
-}
-
-func NotFound(c echo.Context, msg string) error {
-	return c.JSON(http.StatusNotFound, Response{
-		Ok:    false,
-		Data:  nil,
-		Error: msg,
-	})
-}
-
-func ServerError(c echo.Context, err error) error {
-	var msg any
-	if err != nil {
-		msg = err.Error()
-	}
-	return c.JSON(http.StatusInternalServerError, Response{
-		Ok:    false,
-		Data:  msg,
-		Error: "sorry, could not process your request",
-	})
-}
@@ -0,0 +1,170 @@
+package keylog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/api/internal/core"
+	peks "github.com/bingxueshuang/devspaces/core"
+	"github.com/bingxueshuang/devspaces/db"
+	"github.com/labstack/echo/v4"
+)
+
+// MaxEntries is the largest page of log entries served by EntriesHandler.
+var MaxEntries = 1000
+
+var errBinding = errors.New("binding is not in the log")
+
+func Setup(g *echo.Group) {
+	g.GET("/head", HeadHandler)
+	g.GET("/inclusion", InclusionHandler)
+	g.GET("/consistency", ConsistencyHandler)
+	g.GET("/entries", EntriesHandler)
+}
+
+func leaves(entries []db.LogEntry) [][]byte {
+	l := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		l = append(l, e.Leaf)
+	}
+	return l
+}
+
+func hexes(hashes [][]byte) []string {
+	s := make([]string, 0, len(hashes))
+	for _, h := range hashes {
+		s = append(s, hex.EncodeToString(h))
+	}
+	return s
+}
+
+// intParam parses the query parameter name between min and max,
+// defaulting to def when it is absent.
+func intParam(c echo.Context, name string, def, min, max int) (int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, core.ErrInvalidParameter.WithDetail(err)
+	}
+	if n < min || n > max {
+		return 0, core.ErrInvalidParameter.WithDetail(fmt.Errorf("%s must be between %d and %d", name, min, max))
+	}
+	return n, nil
+}
+
+// HeadHandler serves the current tree head of the key log,
+// signed with the log key of the server.
+func HeadHandler(c echo.Context) error {
+	keys := c.Get("ServerKey").(core.KeyContext)
+	l := leaves(db.KeyLog())
+	root := peks.MerkleRoot(l)
+	timestamp := time.Now().UnixMilli()
+	sig := peks.SignTreeHead(int64(len(l)), root, timestamp, keys.LogKey)
+	return core.SendOK(c, map[string]any{
+		"size":      len(l),
+		"root":      hex.EncodeToString(root),
+		"timestamp": timestamp,
+		"signature": hex.EncodeToString(sig),
+	})
+}
+
+// InclusionHandler proves that the binding of the public key to the
+// kind, name and epoch is in the tree of the log of the size.
+func InclusionHandler(c echo.Context) error {
+	entries := db.KeyLog()
+	kind, name := c.QueryParam("kind"), c.QueryParam("name")
+	if kind != peks.BindingUser && kind != peks.BindingSpace {
+		return core.ErrInvalidParameter.WithDetail(fmt.Errorf("unknown kind %q", kind))
+	}
+	epoch, err := strconv.Atoi(c.QueryParam("epoch"))
+	if err != nil {
+		return core.ErrInvalidParameter.WithDetail(err)
+	}
+	pubkey, err := hex.DecodeString(c.QueryParam("pubkey"))
+	if err != nil {
+		return core.ErrInvalidParameter.WithDetail(err)
+	}
+	size, err := intParam(c, "size", len(entries), 1, len(entries))
+	if err != nil {
+		return err
+	}
+	entries = entries[:size]
+	for i, e := range entries {
+		// the key is matched too, so that another key bound to the
+		// same name and epoch never stands in for it
+		if e.Kind != kind || e.Name != name || e.Epoch != epoch || !bytes.Equal(e.Pubkey, pubkey) {
+			continue
+		}
+		proof, err := peks.InclusionProof(leaves(entries), i)
+		if err != nil {
+			return core.ServerError(err)
+		}
+		return core.SendOK(c, map[string]any{
+			"index":  i,
+			"size":   size,
+			"hashes": hexes(proof),
+		})
+	}
+	return core.ErrNotFound.WithDetail(errBinding)
+}
+
+// ConsistencyHandler proves that the tree of the log of the first
+// size is a prefix of that of the second size.
+func ConsistencyHandler(c echo.Context) error {
+	entries := db.KeyLog()
+	second, err := intParam(c, "second", len(entries), 0, len(entries))
+	if err != nil {
+		return err
+	}
+	first, err := intParam(c, "first", 0, 0, second)
+	if err != nil {
+		return err
+	}
+	proof, err := peks.ConsistencyProof(leaves(entries[:second]), first)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	return core.SendOK(c, map[string]any{
+		"first":  first,
+		"second": second,
+		"hashes": hexes(proof),
+	})
+}
+
+// EntriesHandler lists the entries of the key log from start,
+// for monitors which check every key bound to a name.
+func EntriesHandler(c echo.Context) error {
+	entries := db.KeyLog()
+	start, err := intParam(c, "start", 0, 0, len(entries))
+	if err != nil {
+		return err
+	}
+	limit, err := intParam(c, "limit", MaxEntries, 1, MaxEntries)
+	if err != nil {
+		return err
+	}
+	end := len(entries)
+	if end-start > limit {
+		end = start + limit
+	}
+	res := make([]map[string]any, 0, end-start)
+	for i := start; i < end; i++ {
+		e := entries[i]
+		res = append(res, map[string]any{
+			"index":  i,
+			"kind":   e.Kind,
+			"name":   e.Name,
+			"pubkey": hex.EncodeToString(e.Pubkey),
+			"epoch":  e.Epoch,
+			"time":   e.Time,
+		})
+	}
+	return core.SendOK(c, res)
+}
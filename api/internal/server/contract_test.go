@@ -0,0 +1,621 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/api/internal/auth"
+	"github.com/bingxueshuang/devspaces/api/internal/blob"
+	spaces "github.com/bingxueshuang/devspaces/api/internal/space"
+	"github.com/bingxueshuang/devspaces/api/internal/webhook"
+	"github.com/bingxueshuang/devspaces/api/openapi"
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/bingxueshuang/devspaces/core"
+)
+
+// user is a registered and logged in user of the test server.
+type user struct {
+	name string
+	sk   *core.SKey
+	pk   *core.PKey
+	c    *client.Client
+}
+
+// testServer starts the api server, returning a function which
+// creates clients whose responses are checked against the spec.
+func testServer(t *testing.T) (srvKey *core.PKeyServer, newClient func(token string) *client.Client) {
+	sk, pk, err := core.KeyGenServer()
+	handleFatal(err, t)
+	logKey, _, err := core.KeyGen()
+	handleFatal(err, t)
+	blob.Default = &blob.Store{Dir: t.TempDir(), MaxSize: 1 << 20}
+	// the webhook receivers of the tests listen on loopback
+	webhook.Default = &webhook.Dispatcher{
+		Client:       http.DefaultClient,
+		MaxAttempts:  3,
+		Backoff:      10 * time.Millisecond,
+		AllowPrivate: true,
+	}
+	srv := httptest.NewServer(New(sk, pk, logKey))
+	t.Cleanup(srv.Close)
+	spec := loadSpec(t)
+	return pk, func(token string) *client.Client {
+		var tokens client.TokenSource
+		if token != "" {
+			tokens = client.StaticToken(token)
+		}
+		c := client.New(srv.URL, tokens)
+		c.HTTPClient = &http.Client{Transport: &contract{t: t, spec: spec, next: http.DefaultTransport}}
+		return c
+	}
+}
+
+func signup(t *testing.T, newClient func(string) *client.Client, name string) *user {
+	ctx := context.Background()
+	sk, pk, err := core.KeyGen()
+	handleFatal(err, t)
+	c := newClient("")
+	err = c.RegisterKey(ctx, name, "password of "+name, sk)
+	handleFatal(err, t)
+	token, err := c.Login(ctx, client.Credentials{Username: name, Password: "password of " + name})
+	handleFatal(err, t)
+	return &user{name: name, sk: sk, pk: pk, c: newClient(token.Token)}
+}
+
+// expectCode fails the test unless err is an api error with code.
+func expectCode(t *testing.T, err error, code string) {
+	t.Helper()
+	if !client.IsCode(err, code) {
+		t.Logf("expected: %v, got: %v", code, err)
+		t.Fatal("incorrect api error")
+	}
+}
+
+func TestContract(t *testing.T) {
+	ctx := context.Background()
+	srvKey, newClient := testServer(t)
+	alice := signup(t, newClient, "contract-alice")
+	bob := signup(t, newClient, "contract-bob")
+	spaceSK, spacePK, err := core.KeyGen()
+	handleFatal(err, t)
+	const space = "contract-space"
+
+	t.Run("auth", func(t *testing.T) {
+		c := newClient("")
+		err := c.RegisterKey(ctx, alice.name, "x", alice.sk)
+		expectCode(t, err, client.CodeUserExists)
+		legacy, _, err := core.KeyGenCurve(core.BN254)
+		handleFatal(err, t)
+		err = c.RegisterKey(ctx, "contract-legacy", "x", legacy)
+		expectCode(t, err, client.CodeInvalidPubkey)
+		invalid := bob.pk.Bytes()
+		invalid[len(invalid)-1] ^= 1
+		err = c.Register(ctx, client.Registration{Username: "contract-invalid", Password: "x", Pubkey: invalid})
+		expectCode(t, err, client.CodeInvalidPubkey)
+		// the key of another user is not registered without its secret key
+		rogue, _, err := core.KeyGen()
+		handleFatal(err, t)
+		nonce, proof, err := c.Prove(ctx, "contract-rogue", rogue)
+		handleFatal(err, t)
+		err = c.Register(ctx, client.Registration{Username: "contract-rogue", Password: "x", Pubkey: bob.pk.Bytes(), Nonce: nonce, Proof: proof})
+		expectCode(t, err, client.CodeInvalidProof)
+		// nonces are used once
+		nonce, proof, err = c.Prove(ctx, "contract-carol", rogue)
+		handleFatal(err, t)
+		_, pk, err := core.KeyGen()
+		handleFatal(err, t)
+		err = c.Register(ctx, client.Registration{Username: "contract-carol", Password: "x", Pubkey: pk.Bytes(), Nonce: nonce, Proof: proof})
+		expectCode(t, err, client.CodeInvalidProof)
+		rogueKey := new(core.PKey)
+		handleFatal(rogueKey.FromSKey(rogue), t)
+		err = c.Register(ctx, client.Registration{Username: "contract-carol", Password: "x", Pubkey: rogueKey.Bytes(), Nonce: nonce, Proof: proof})
+		expectCode(t, err, client.CodeInvalidProof)
+		// nonces are not stored, but have to be issued by the server
+		// and unexpired
+		nonce, _, err = c.Prove(ctx, "contract-carol", rogue)
+		handleFatal(err, t)
+		nonce[0] ^= 1
+		err = c.Register(ctx, client.Registration{Username: "contract-carol", Password: "x", Pubkey: rogueKey.Bytes(), Nonce: nonce, Proof: core.ProvePossession("contract-carol", nonce, rogue)})
+		expectCode(t, err, client.CodeInvalidProof)
+		ttl := auth.NonceTTL
+		auth.NonceTTL = -time.Second
+		nonce, proof, err = c.Prove(ctx, "contract-carol", rogue)
+		auth.NonceTTL = ttl
+		handleFatal(err, t)
+		err = c.Register(ctx, client.Registration{Username: "contract-carol", Password: "x", Pubkey: rogueKey.Bytes(), Nonce: nonce, Proof: proof})
+		expectCode(t, err, client.CodeInvalidProof)
+		_, err = c.Login(ctx, client.Credentials{Username: alice.name, Password: "wrong"})
+		expectCode(t, err, client.CodeInvalidCredentials)
+		key, err := c.User(ctx, bob.name)
+		handleFatal(err, t)
+		if !bytes.Equal(key.Pubkey, bob.pk.Bytes()) {
+			t.Fatal("user is expected to be served with the registered public key")
+		}
+		srv, err := c.ServerPubkey(ctx)
+		handleFatal(err, t)
+		if !bytes.Equal(srv.Bytes(), srvKey.Bytes()) {
+			t.Fatal("incorrect server public key")
+		}
+		_, err = c.ListSpaces(ctx)
+		expectCode(t, err, client.CodeUnauthorized)
+	})
+
+	t.Run("spaces", func(t *testing.T) {
+		err := alice.c.CreateSpace(ctx, client.NewSpace{Name: space, Pubkey: spacePK.Bytes()})
+		handleFatal(err, t)
+		err = bob.c.CreateSpace(ctx, client.NewSpace{Name: space, Pubkey: spacePK.Bytes()})
+		expectCode(t, err, client.CodeSpaceExists)
+		spaces, err := alice.c.ListSpaces(ctx)
+		handleFatal(err, t)
+		if len(spaces) != 1 || spaces[0].Name != space {
+			t.Logf("got: %+v", spaces)
+			t.Fatal("incorrect list of devspaces")
+		}
+		err = alice.c.Invite(ctx, space, client.Invitation{To: bob.name, Secret: spaceSK.Bytes()})
+		handleFatal(err, t)
+		invites, err := bob.c.Invites(ctx)
+		handleFatal(err, t)
+		if len(invites) != 1 || invites[0].On != space || invites[0].From != alice.name {
+			t.Logf("got: %+v", invites)
+			t.Fatal("incorrect list of invites")
+		}
+		sk, err := invites[0].Key()
+		handleFatal(err, t)
+		if sk.Key.Cmp(spaceSK.Key) != 0 {
+			t.Fatal("invite is expected to share the secret key of the devspace")
+		}
+		members, err := alice.c.Members(ctx, space)
+		handleFatal(err, t)
+		if len(members) != 1 || members[0].Username != bob.name || !bytes.Equal(members[0].Pubkey, bob.pk.Bytes()) {
+			t.Logf("got: %+v", members)
+			t.Fatal("incorrect list of members")
+		}
+		_, err = bob.c.Members(ctx, space)
+		expectCode(t, err, client.CodeForbidden)
+		_, err = alice.c.ListTags(ctx, "contract-missing")
+		expectCode(t, err, client.CodeSpaceNotFound)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		err := alice.c.CreateKeywordTag(ctx, space, "deploys", "deploy", nil, bob.name, spaceSK, false)
+		handleFatal(err, t)
+		td, err := core.Trapdoor(space, []byte("deploy"), srvKey, bob.pk, spaceSK)
+		handleFatal(err, t)
+		tag := client.NewTag{Name: "deploys", Trapdoor: td}
+		err = alice.c.CreateTag(ctx, space, tag)
+		expectCode(t, err, client.CodeTagExists)
+		tag.Append = true
+		err = alice.c.CreateTag(ctx, space, tag)
+		handleFatal(err, t)
+		err = bob.c.CreateTag(ctx, space, tag)
+		expectCode(t, err, client.CodeForbidden)
+		err = alice.c.CreateTag(ctx, space, client.NewTag{Name: "bad", Trapdoor: []byte{0xab, 0xcd}})
+		expectCode(t, err, client.CodeInvalidTrapdoor)
+		// the messages of a tag named after a route could not be listed
+		err = alice.c.CreateTag(ctx, space, client.NewTag{Name: "stream", Trapdoor: td})
+		expectCode(t, err, client.CodeValidation)
+		tags, err := alice.c.ListTags(ctx, space)
+		handleFatal(err, t)
+		if len(tags) != 2 || tags[0].Name != "deploys" {
+			t.Logf("got: %+v", tags)
+			t.Fatal("incorrect list of tags")
+		}
+	})
+
+	var hook *client.Webhook
+	// received reports whether the payloads are signed with the secret
+	received := make(chan bool, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		received <- err == nil && webhook.Verify([]byte(hook.Secret), body, r.Header.Get(webhook.SignatureHeader))
+	}))
+	t.Cleanup(receiver.Close)
+	t.Run("webhooks", func(t *testing.T) {
+		hook, err = alice.c.CreateWebhook(ctx, space, client.NewWebhook{URL: receiver.URL, Tag: "deploys"})
+		handleFatal(err, t)
+		if hook.Secret == "" {
+			t.Fatal("generated secret is expected to be revealed on creation")
+		}
+		hooks, err := alice.c.ListWebhooks(ctx, space)
+		handleFatal(err, t)
+		if len(hooks) != 1 || hooks[0].Secret != "" {
+			t.Logf("got: %+v", hooks)
+			t.Fatal("incorrect list of webhooks")
+		}
+	})
+
+	t.Run("messages", func(t *testing.T) {
+		stream, err := alice.c.Stream(ctx, space, []string{"deploys"}, "")
+		handleFatal(err, t)
+		defer stream.Close()
+		attachment := []byte("build log")
+		b, err := bob.c.UploadBlob(ctx, space, bytes.NewReader(attachment), int64(len(attachment)))
+		handleFatal(err, t)
+		// blobs are uploaded by collaborators, up to the quota
+		mallory := signup(t, newClient, "contract-mallory")
+		_, err = mallory.c.UploadBlob(ctx, space, bytes.NewReader(attachment), int64(len(attachment)))
+		expectCode(t, err, client.CodeForbidden)
+		blob.Default.Quota = int64(len(attachment)) + 1
+		_, err = bob.c.UploadBlob(ctx, space, strings.NewReader("ab"), 2)
+		expectCode(t, err, client.CodeTooLarge)
+		blob.Default.Quota = 0
+		for i := 0; i < 2; i++ {
+			data := []byte("deployed " + strconv.Itoa(i))
+			err = bob.c.SendKeyword(ctx, space, "deploy", nil, data, bob.sk, client.AttachmentRef{Name: "build.log", Digest: b.Digest})
+			handleFatal(err, t)
+		}
+		err = bob.c.Send(ctx, space, client.NewMessage{Data: []byte("x"), Keyword: []byte("not a ciphertext")})
+		expectCode(t, err, client.CodeInvalidKeyword)
+
+		m, err := stream.Next()
+		handleFatal(err, t)
+		if m.From != bob.name || m.Tag != "deploys" || string(m.Data) != "deployed 0" || stream.LastID != strconv.FormatInt(m.ID, 10) {
+			t.Logf("got: %+v", m)
+			t.Fatal("incorrect streamed message")
+		}
+		if ok, err := m.Verify(space, bob.pk); err != nil || !ok {
+			t.Fatal("message is expected to be signed by the sender")
+		}
+		page, err := alice.c.ListMessages(ctx, space, "deploys", &client.MessageQuery{Limit: 1})
+		handleFatal(err, t)
+		if len(page.Messages) != 1 || page.Next == nil || page.Messages[0].ID != m.ID {
+			t.Logf("got: %+v", page)
+			t.Fatal("incorrect first page of messages")
+		}
+		_, err = bob.c.ListMessages(ctx, space, "deploys", nil)
+		expectCode(t, err, client.CodeForbidden)
+
+		body, err := alice.c.DownloadBlob(ctx, space, page.Messages[0].Attachments[0].Digest)
+		handleFatal(err, t)
+		got, err := io.ReadAll(body)
+		body.Close()
+		handleFatal(err, t)
+		if !bytes.Equal(got, attachment) {
+			t.Fatal("downloaded attachment is expected to match the upload")
+		}
+
+		err = alice.c.MarkMessage(ctx, space, m.ID, true)
+		handleFatal(err, t)
+		page, err = alice.c.ListMessages(ctx, space, "deploys", &client.MessageQuery{Unread: true})
+		handleFatal(err, t)
+		if len(page.Messages) != 1 || page.Next != nil || page.Messages[0].ID == m.ID {
+			t.Logf("got: %+v", page)
+			t.Fatal("read message is expected to be filtered out")
+		}
+		err = alice.c.DeleteMessage(ctx, space, m.ID)
+		handleFatal(err, t)
+		err = alice.c.DeleteMessage(ctx, space, m.ID)
+		expectCode(t, err, client.CodeMessageNotFound)
+
+		// blobs are removed once no message is attached to them
+		_, err = spaces.PruneBlobs(time.Now().Add(spaces.BlobTTL))
+		handleFatal(err, t)
+		body, err = alice.c.DownloadBlob(ctx, space, b.Digest)
+		handleFatal(err, t)
+		body.Close()
+		err = alice.c.DeleteMessage(ctx, space, page.Messages[0].ID)
+		handleFatal(err, t)
+		n, err := spaces.PruneBlobs(time.Now().Add(spaces.BlobTTL))
+		handleFatal(err, t)
+		_, err = alice.c.DownloadBlob(ctx, space, b.Digest)
+		if n != 1 || !client.IsCode(err, client.CodeBlobNotFound) {
+			t.Logf("expected: %v, got: %v", client.CodeBlobNotFound, err)
+			t.Fatal("unattached blob is expected to be removed")
+		}
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		if !<-received {
+			t.Fatal("payload is expected to be signed with the returned secret")
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			log, err := alice.c.Deliveries(ctx, space, hook.ID)
+			handleFatal(err, t)
+			if len(log) != 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for delivery log")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		_, err := alice.c.DeadLetters(ctx, space, hook.ID)
+		handleFatal(err, t)
+		err = alice.c.DeleteWebhook(ctx, space, hook.ID)
+		handleFatal(err, t)
+		err = alice.c.DeleteWebhook(ctx, space, hook.ID)
+		expectCode(t, err, client.CodeWebhookNotFound)
+	})
+
+	t.Run("retention", func(t *testing.T) {
+		age, count := "24h", 10
+		r, err := alice.c.SetRetention(ctx, space, client.RetentionUpdate{MaxAge: &age, MaxCount: &count})
+		handleFatal(err, t)
+		got, err := alice.c.Retention(ctx, space)
+		handleFatal(err, t)
+		if *got != *r || r.MaxAge != "24h0m0s" || r.MaxCount != 10 {
+			t.Logf("got: %+v", got)
+			t.Fatal("incorrect retention policy")
+		}
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		newSK, newPK, err := core.KeyGen()
+		handleFatal(err, t)
+		td, err := alice.c.Trapdoor(ctx, space, "deploy", nil, bob.name, newSK)
+		handleFatal(err, t)
+		_, err = alice.c.RotateKey(ctx, space, client.Rotation{
+			Pubkey: newPK.Bytes(),
+			Tags:   []client.NewTag{{Name: "deploys", Trapdoor: td}},
+		})
+		expectCode(t, err, client.CodeInvalidProof)
+		nonce, proof, err := alice.c.Prove(ctx, space, newSK)
+		handleFatal(err, t)
+		epoch, err := alice.c.RotateKey(ctx, space, client.Rotation{
+			Pubkey: newPK.Bytes(),
+			Nonce:  nonce,
+			Proof:  proof,
+			Tags:   []client.NewTag{{Name: "deploys", Trapdoor: td}},
+		})
+		handleFatal(err, t)
+		key, err := bob.c.SpaceKey(ctx, space)
+		handleFatal(err, t)
+		if key.Epoch != epoch.Epoch || !bytes.Equal(key.Pubkey, newPK.Bytes()) {
+			t.Logf("got: %+v", key)
+			t.Fatal("incorrect devspace key after rotation")
+		}
+
+		// a leaked key is retired at once
+		newSK, newPK, err = core.KeyGen()
+		handleFatal(err, t)
+		nonce, proof, err = alice.c.Prove(ctx, space, newSK)
+		handleFatal(err, t)
+		rotation := client.Rotation{Pubkey: newPK.Bytes(), Nonce: nonce, Proof: proof, Window: "721h"}
+		_, err = alice.c.RotateKey(ctx, space, rotation)
+		expectCode(t, err, client.CodeValidation)
+		rotation.Window = "0s"
+		_, err = alice.c.RotateKey(ctx, space, rotation)
+		handleFatal(err, t)
+		tags, err := alice.c.ListTags(ctx, space)
+		handleFatal(err, t)
+		for _, tag := range tags {
+			if tag.Epoch == epoch.Epoch {
+				t.Logf("got: %+v", tags)
+				t.Fatal("tags of a key retired at once are not expected to route")
+			}
+		}
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		const shared = "contract-shared"
+		err := alice.c.CreateSpace(ctx, client.NewSpace{Name: "contract-bogus", Pubkey: spacePK.Bytes(), Scheme: "bogus"})
+		expectCode(t, err, client.CodeValidation)
+		err = alice.c.CreateSpace(ctx, client.NewSpace{Name: shared, Pubkey: spacePK.Bytes(), Scheme: client.SchemeShared})
+		handleFatal(err, t)
+		key, err := bob.c.SpaceKey(ctx, shared)
+		handleFatal(err, t)
+		if key.Scheme != client.SchemeShared {
+			t.Logf("got: %+v", key)
+			t.Fatal("devspace key is expected to carry the scheme")
+		}
+		err = alice.c.CreateKeywordTag(ctx, shared, "deploys", "deploy", nil, "", spaceSK, false)
+		handleFatal(err, t)
+		td, err := core.Trapdoor(shared, []byte("deploy"), srvKey, bob.pk, spaceSK)
+		handleFatal(err, t)
+		err = alice.c.CreateTag(ctx, shared, client.NewTag{Name: "pairwise", Trapdoor: td})
+		expectCode(t, err, client.CodeInvalidTrapdoor)
+		blind, err := bob.c.BlindPubkey(ctx)
+		handleFatal(err, t)
+		td, err = core.TrapdoorShared(shared, []byte("deploy"), blind, spaceSK)
+		handleFatal(err, t)
+		td[len(td)-1] ^= 1
+		err = alice.c.CreateTag(ctx, shared, client.NewTag{Name: "invalid", Trapdoor: td})
+		expectCode(t, err, client.CodeInvalidTrapdoor)
+
+		// a single tag matches the keyword from every sender
+		for _, u := range []*user{alice, bob} {
+			err = u.c.SendKeyword(ctx, shared, "deploy", nil, []byte("from "+u.name), u.sk)
+			handleFatal(err, t)
+		}
+		ct, err := bob.c.Encrypt(ctx, shared, "deploy", nil, nil)
+		handleFatal(err, t)
+		m := client.NewMessage{Data: []byte("unsigned"), Keyword: ct}
+		err = bob.c.Send(ctx, shared, m)
+		expectCode(t, err, client.CodeInvalidSignature)
+		m.Sign(shared, alice.sk)
+		err = bob.c.Send(ctx, shared, m)
+		expectCode(t, err, client.CodeInvalidSignature)
+
+		page, err := alice.c.ListMessages(ctx, shared, "deploys", nil)
+		handleFatal(err, t)
+		if len(page.Messages) != 2 {
+			t.Logf("got: %+v", page)
+			t.Fatal("messages of every sender are expected to be routed to the tag")
+		}
+		for i, u := range []*user{alice, bob} {
+			m := page.Messages[i]
+			if ok, err := m.Verify(shared, u.pk); m.From != u.name || err != nil || !ok {
+				t.Logf("got: %+v", m)
+				t.Fatal("message is expected to be signed by the sender")
+			}
+		}
+	})
+
+	t.Run("user keys", func(t *testing.T) {
+		const keys = "contract-keys"
+		dave := signup(t, newClient, "contract-dave")
+		err := alice.c.CreateSpace(ctx, client.NewSpace{Name: keys, Pubkey: spacePK.Bytes()})
+		handleFatal(err, t)
+		err = alice.c.CreateKeywordTag(ctx, keys, "deploys", "deploy", nil, dave.name, spaceSK, false)
+		handleFatal(err, t)
+		tags, err := alice.c.ListTags(ctx, keys)
+		handleFatal(err, t)
+		if len(tags) != 1 || tags[0].Sender != dave.name || tags[0].SenderKey != 1 {
+			t.Logf("got: %+v", tags)
+			t.Fatal("tag is expected to record the key version of the sender")
+		}
+		err = alice.c.CreateTag(ctx, keys, client.NewTag{Name: "nobody", Trapdoor: tags[0].Trapdoor, Sender: "contract-nobody"})
+		expectCode(t, err, client.CodeUserNotFound)
+
+		newSK, newPK, err := core.KeyGen()
+		handleFatal(err, t)
+		_, err = newClient("").RotateUserKey(ctx, dave.name, newSK)
+		expectCode(t, err, client.CodeUnauthorized)
+		_, err = dave.c.SetUserKey(ctx, client.NewUserKey{Pubkey: newPK.Bytes()})
+		expectCode(t, err, client.CodeInvalidProof)
+		// the proof is bound to the username of the logged in user
+		_, err = dave.c.RotateUserKey(ctx, bob.name, newSK)
+		expectCode(t, err, client.CodeInvalidProof)
+		version, err := dave.c.RotateUserKey(ctx, dave.name, newSK)
+		handleFatal(err, t)
+		if version.Version != 2 {
+			t.Logf("expected: %v, got: %v", 2, version.Version)
+			t.Fatal("incorrect key version after rotation")
+		}
+		key, err := bob.c.User(ctx, dave.name)
+		handleFatal(err, t)
+		if key.Version != 2 || !bytes.Equal(key.Pubkey, newPK.Bytes()) {
+			t.Logf("got: %+v", key)
+			t.Fatal("user is expected to be served with the rotated public key")
+		}
+		history, err := bob.c.UserKeys(ctx, dave.name)
+		handleFatal(err, t)
+		if len(history) != 2 ||
+			!bytes.Equal(history[0].Pubkey, dave.pk.Bytes()) || history[0].Until == nil ||
+			!bytes.Equal(history[1].Pubkey, newPK.Bytes()) || history[1].Until != nil {
+			t.Logf("got: %+v", history)
+			t.Fatal("incorrect key history")
+		}
+		_, err = bob.c.UserKeys(ctx, "contract-nobody")
+		expectCode(t, err, client.CodeUserNotFound)
+
+		// tags made for the previous key are stale until re-issued
+		stale, err := alice.c.StaleTags(ctx, keys)
+		handleFatal(err, t)
+		if len(stale) != 1 || stale[0].Name != "deploys" || stale[0].SenderKey != 1 {
+			t.Logf("got: %+v", stale)
+			t.Fatal("incorrect list of stale tags")
+		}
+		err = alice.c.CreateKeywordTag(ctx, keys, "deploys", "deploy", nil, dave.name, spaceSK, true)
+		handleFatal(err, t)
+		stale, err = alice.c.StaleTags(ctx, keys)
+		handleFatal(err, t)
+		if len(stale) != 0 {
+			t.Logf("got: %+v", stale)
+			t.Fatal("re-issued tags are not expected to be stale")
+		}
+		err = dave.c.SendKeyword(ctx, keys, "deploy", nil, []byte("rotated"), newSK)
+		handleFatal(err, t)
+		page, err := alice.c.ListMessages(ctx, keys, "deploys", nil)
+		handleFatal(err, t)
+		if len(page.Messages) != 1 || page.Messages[0].From != dave.name {
+			t.Logf("got: %+v", page)
+			t.Fatal("messages under the rotated key are expected to match the re-issued tag")
+		}
+	})
+
+	t.Run("key log", func(t *testing.T) {
+		var warnings []string
+		saved := 0
+		c := newClient("")
+		c.Tokens = alice.c.Tokens
+		c.KeyLog = &client.KeyLog{
+			Warn: func(msg string) { warnings = append(warnings, msg) },
+			Save: func(p *client.Pins) error { saved++; return nil },
+		}
+		pk, err := c.UserPubkey(ctx, alice.name)
+		handleFatal(err, t)
+		if !bytes.Equal(pk.Bytes(), alice.pk.Bytes()) || saved != 1 || c.KeyLog.Pins.Head == nil {
+			t.Logf("got: %+v", c.KeyLog.Pins)
+			t.Fatal("verified key is expected to be pinned")
+		}
+		_, err = c.SpacePubkey(ctx, space)
+		handleFatal(err, t)
+		erin := signup(t, newClient, "contract-erin")
+		_, err = c.UserPubkey(ctx, erin.name)
+		handleFatal(err, t)
+		newSK, newPK, err := core.KeyGen()
+		handleFatal(err, t)
+		_, err = erin.c.RotateUserKey(ctx, erin.name, newSK)
+		handleFatal(err, t)
+		pk, err = c.UserPubkey(ctx, erin.name)
+		handleFatal(err, t)
+		if !bytes.Equal(pk.Bytes(), newPK.Bytes()) || len(warnings) != 1 {
+			t.Logf("got: %v", warnings)
+			t.Fatal("change of a pinned key is expected to be warned of")
+		}
+
+		// keys which are not in the log are rejected
+		err = c.VerifyKey(ctx, client.Binding{Kind: core.BindingUser, Name: alice.name, Pubkey: bob.pk.Bytes(), Epoch: 1})
+		if !errors.Is(err, client.ErrKeyLog) {
+			t.Logf("expected: %v, got: %v", client.ErrKeyLog, err)
+			t.Fatal("substituted key is not expected to verify")
+		}
+		err = c.VerifyKey(ctx, client.Binding{Kind: core.BindingUser, Name: erin.name, Pubkey: erin.pk.Bytes(), Epoch: 1})
+		if !errors.Is(err, client.ErrKeyLog) {
+			t.Logf("expected: %v, got: %v", client.ErrKeyLog, err)
+			t.Fatal("key older than the pinned one is not expected to verify")
+		}
+		err = c.VerifyKey(ctx, client.Binding{Kind: core.BindingUser, Name: "contract-nobody", Pubkey: bob.pk.Bytes(), Epoch: 1})
+		expectCode(t, err, client.CodeNotFound)
+		// another key at the pinned epoch is an equivocation
+		pinned := c.KeyLog.Pins.Keys[core.BindingUser+"/"+alice.name]
+		forged := pinned
+		forged.Pubkey = bob.pk.Bytes()
+		c.KeyLog.Pins.Keys[core.BindingUser+"/"+alice.name] = forged
+		_, err = c.UserPubkey(ctx, alice.name)
+		if !errors.Is(err, client.ErrKeyLog) || len(warnings) != 1 {
+			t.Logf("expected: %v, got: %v", client.ErrKeyLog, err)
+			t.Fatal("different key at the pinned epoch is expected to fail")
+		}
+		c.KeyLog.Pins.Keys[core.BindingUser+"/"+alice.name] = pinned
+		// a log which is not an extension of the pinned one is rejected
+		head := *c.KeyLog.Pins.Head
+		forked := head
+		forked.Root = core.LeafHash([]byte("fork"))
+		c.KeyLog.Pins.Head = &forked
+		_, err = c.UserPubkey(ctx, bob.name)
+		if !errors.Is(err, client.ErrKeyLog) {
+			t.Logf("expected: %v, got: %v", client.ErrKeyLog, err)
+			t.Fatal("forked log is not expected to verify")
+		}
+		c.KeyLog.Pins.Head = &head
+		// a replaced log key is not trusted anew
+		logKey := c.KeyLog.Pins.LogKey
+		c.KeyLog.Pins.LogKey = bob.pk.Bytes()
+		_, err = c.UserPubkey(ctx, bob.name)
+		if !errors.Is(err, client.ErrLogKey) || c.KeyLog.Pins.Head == nil {
+			t.Logf("expected: %v, got: %v", client.ErrLogKey, err)
+			t.Fatal("changed log key is expected to fail and keep the pins")
+		}
+		c.KeyLog.Pins.LogKey = logKey
+
+		entries, err := c.LogEntries(ctx, 0, 0)
+		handleFatal(err, t)
+		last := entries[len(entries)-1]
+		if int64(len(entries)) != head.Size || last.Kind != core.BindingUser || last.Name != erin.name || last.Epoch != 2 {
+			t.Logf("got: %+v", last)
+			t.Fatal("incorrect entries of the key log")
+		}
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		res, err := http.Get(strings.TrimSuffix(alice.c.Server, "/") + "/openapi.json")
+		handleFatal(err, t)
+		defer res.Body.Close()
+		got, err := io.ReadAll(res.Body)
+		handleFatal(err, t)
+		if !bytes.Equal(got, openapi.Spec) {
+			t.Fatal("served specification is expected to match the embedded one")
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"time"
+
+	api "github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/labstack/gommon/log"
+	"golang.org/x/time/rate"
+)
+
+// LogLevels are the levels of the server logs by name.
+var LogLevels = map[string]log.Lvl{
+	"debug": log.DEBUG,
+	"info":  log.INFO,
+	"warn":  log.WARN,
+	"error": log.ERROR,
+	"off":   log.OFF,
+}
+
+// Options configures the middleware of the api server.
+type Options struct {
+	// CORSOrigins are the origins allowed to make cross-origin
+	// requests, none if empty.
+	CORSOrigins []string
+	// RateLimit is the number of requests per second allowed from
+	// every client address, in bursts of up to RateBurst requests.
+	// Zero disables the limit. The address is that of the connection,
+	// as the forwarding headers are set by clients.
+	RateLimit float64
+	RateBurst int
+	// LogLevel is one of LogLevels. Requests are logged at debug.
+	LogLevel string
+}
+
+// Configure adds the middleware of the options to the server
+// returned by New.
+func Configure(e *echo.Echo, o Options) {
+	if lvl, ok := LogLevels[o.LogLevel]; ok {
+		e.Logger.SetLevel(lvl)
+		if lvl == log.DEBUG {
+			e.Use(middleware.Logger())
+		}
+	}
+	if len(o.CORSOrigins) != 0 {
+		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins: o.CORSOrigins,
+		}))
+	}
+	if o.RateLimit > 0 {
+		e.IPExtractor = echo.ExtractIPDirect()
+		store := middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(o.RateLimit),
+			Burst:     o.RateBurst,
+			ExpiresIn: 3 * time.Minute,
+		})
+		e.Use(middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+			Store: store,
+			ErrorHandler: func(c echo.Context, err error) error {
+				return api.ServerError(err)
+			},
+			DenyHandler: func(c echo.Context, identifier string, err error) error {
+				return api.ErrRateLimited
+			},
+		}))
+	}
+}
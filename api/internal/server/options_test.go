@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/bingxueshuang/devspaces/core"
+)
+
+func TestRateLimit(t *testing.T) {
+	ctx := context.Background()
+	sk, pk, err := core.KeyGenServer()
+	handleFatal(err, t)
+	logKey, _, err := core.KeyGen()
+	handleFatal(err, t)
+	e := New(sk, pk, logKey)
+	Configure(e, Options{RateLimit: 1, RateBurst: 2, CORSOrigins: []string{"https://app.example.com"}})
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+
+	c := client.New(srv.URL, nil)
+	c.Retries = 0
+	for i := 0; i < 2; i++ {
+		_, err = c.LogHead(ctx)
+		handleFatal(err, t)
+	}
+	_, err = c.LogHead(ctx)
+	expectCode(t, err, client.CodeRateLimited)
+	// forwarding headers do not pass for another client
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/log/head", nil)
+	handleFatal(err, t)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("X-Real-Ip", "203.0.113.7")
+	res, err := http.DefaultClient.Do(req)
+	handleFatal(err, t)
+	res.Body.Close()
+	if res.StatusCode != http.StatusTooManyRequests {
+		t.Logf("expected: %v, got: %v", http.StatusTooManyRequests, res.StatusCode)
+		t.Fatal("forwarded address is not expected to escape the limit")
+	}
+
+	req, err = http.NewRequest(http.MethodOptions, srv.URL+"/pubkey", nil)
+	handleFatal(err, t)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	res, err = http.DefaultClient.Do(req)
+	handleFatal(err, t)
+	res.Body.Close()
+	got := res.Header.Get("Access-Control-Allow-Origin")
+	if got != "https://app.example.com" {
+		t.Logf("expected: %v, got: %v", "https://app.example.com", got)
+		t.Fatal("allowed origin is expected to pass the preflight request")
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/hex"
+
+	"github.com/bingxueshuang/devspaces/api/internal/auth"
+	api "github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/bingxueshuang/devspaces/api/internal/keylog"
+	"github.com/bingxueshuang/devspaces/api/internal/space"
+	"github.com/bingxueshuang/devspaces/api/openapi"
+	"github.com/bingxueshuang/devspaces/core"
+	"github.com/labstack/echo/v4"
+)
+
+func PubkeyHandler(c echo.Context) error {
+	serverKey := c.Get("ServerKey").(api.KeyContext)
+	pk := serverKey.PKey.Bytes()
+	logKey := new(core.PKey)
+	if err := logKey.FromSKey(serverKey.LogKey); err != nil {
+		return api.ServerError(err)
+	}
+	blindKey := new(core.PKeyBlind)
+	if err := blindKey.FromSKey(serverKey.SKey); err != nil {
+		return api.ServerError(err)
+	}
+	return api.SendOK(c, map[string]any{
+		"pubkey":       hex.EncodeToString(pk),
+		"log_pubkey":   hex.EncodeToString(logKey.Bytes()),
+		"blind_pubkey": hex.EncodeToString(blindKey.Bytes()),
+	})
+}
+
+// New returns the devspace api server routing requests with
+// the given server key pair, signing the key log with logKey.
+func New(sk *core.SKey, pk *core.PKeyServer, logKey *core.SKey) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	e.HTTPErrorHandler = api.ErrorHandler
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			kc := api.KeyContext{
+				Context: c,
+				SKey:    sk,
+				PKey:    pk,
+				LogKey:  logKey,
+			}
+			c.Set("ServerKey", kc)
+			return next(c)
+		}
+	})
+	authGroup := e.Group("/auth")
+	auth.Setup(authGroup)
+	e.GET("/user/:uname", auth.UserHandler)
+	e.GET("/user/:uname/keys", auth.KeysHandler)
+	e.PUT("/user/me/pubkey", auth.RotateKeyHandler, auth.Required())
+	ptdGroup := e.Group("/space", auth.Required())
+	space.Setup(ptdGroup)
+	logGroup := e.Group("/log")
+	keylog.Setup(logGroup)
+	e.GET("/dashboard", space.DashboardHandler, auth.Required())
+	e.GET("/pubkey", PubkeyHandler)
+	e.GET("/openapi.json", openapi.Handler)
+	e.GET("/", func(c echo.Context) error {
+		return api.SendOK(c, "hello world")
+	})
+	return e
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bingxueshuang/devspaces/api/openapi"
+	"github.com/bingxueshuang/devspaces/core"
+	"github.com/labstack/echo/v4"
+)
+
+// schema is the subset of an OpenAPI schema object checked by the tests.
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Nullable   bool               `json:"nullable"`
+	Enum       []any              `json:"enum"`
+	Pattern    string             `json:"pattern"`
+	Properties map[string]*schema `json:"properties"`
+	Required   []string           `json:"required"`
+	Items      *schema            `json:"items"`
+}
+
+type operation struct {
+	Responses map[string]struct {
+		Ref     string `json:"$ref"`
+		Content map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+type document struct {
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components struct {
+		Schemas   map[string]*schema `json:"schemas"`
+		Responses map[string]struct {
+			Content map[string]struct {
+				Schema *schema `json:"schema"`
+			} `json:"content"`
+		} `json:"responses"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) *document {
+	doc := new(document)
+	err := json.Unmarshal(openapi.Spec, doc)
+	handleFatal(err, t)
+	return doc
+}
+
+var pathParam = regexp.MustCompile(`^{[^}]+}$`)
+
+// matchPath matches the request path against the path template
+// of the specification and counts the parameters in the template.
+func matchPath(template, path string) (params int, ok bool) {
+	ts := strings.Split(template, "/")
+	ps := strings.Split(path, "/")
+	if len(ts) != len(ps) {
+		return 0, false
+	}
+	for i := range ts {
+		switch {
+		case pathParam.MatchString(ts[i]) && ps[i] != "":
+			params++
+		case ts[i] != ps[i]:
+			return 0, false
+		}
+	}
+	return params, true
+}
+
+// find returns the operation of the request path, preferring
+// static path segments over parameters like the echo router.
+func (d *document) find(method, path string) (string, *operation) {
+	found, least := "", -1
+	for p, ops := range d.Paths {
+		if _, ok := ops[strings.ToLower(method)]; !ok {
+			continue
+		}
+		n, ok := matchPath(p, path)
+		if ok && (least == -1 || n < least) {
+			found, least = p, n
+		}
+	}
+	if found == "" {
+		return "", nil
+	}
+	return found, d.Paths[found][strings.ToLower(method)]
+}
+
+// responseSchema returns the json schema of the response with the
+// given status, or nil if the response is not json.
+func (d *document) responseSchema(op *operation, status int) *schema {
+	r, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		r = op.Responses["default"]
+	}
+	content := r.Content
+	if r.Ref != "" {
+		content = d.Components.Responses[strings.TrimPrefix(r.Ref, "#/components/responses/")].Content
+	}
+	media, ok := content["application/json"]
+	if !ok {
+		return nil
+	}
+	return media.Schema
+}
+
+// validate checks that v, decoded from json, conforms to s.
+func (d *document) validate(s *schema, v any, at string) error {
+	if s.Ref != "" {
+		return d.validate(d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")], v, at)
+	}
+	if v == nil {
+		if s.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s: unexpected null", at)
+	}
+	if len(s.Enum) != 0 {
+		found := false
+		for _, e := range s.Enum {
+			found = found || e == v
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", at, v, s.Enum)
+		}
+	}
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", at, v)
+		}
+		for _, r := range s.Required {
+			if _, ok := obj[r]; !ok {
+				return fmt.Errorf("%s: missing required property %q", at, r)
+			}
+		}
+		for k, val := range obj {
+			p, ok := s.Properties[k]
+			if !ok {
+				if s.Properties == nil {
+					continue
+				}
+				return fmt.Errorf("%s: undocumented property %q", at, k)
+			}
+			if err := d.validate(p, val, at+"."+k); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", at, v)
+		}
+		for i, item := range arr {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string, got %T", at, v)
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(str) {
+			return fmt.Errorf("%s: %q does not match %s", at, str, s.Pattern)
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected integer, got %v", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", at, v)
+		}
+	}
+	return nil
+}
+
+// contract is an http transport validating every response
+// against the specification, reporting violations to t.
+type contract struct {
+	t    *testing.T
+	spec *document
+	next http.RoundTripper
+}
+
+func (c *contract) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := c.next.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	path, op := c.spec.find(req.Method, req.URL.Path)
+	if op == nil {
+		c.t.Errorf("%s %s: not documented", req.Method, req.URL.Path)
+		return res, nil
+	}
+	s := c.spec.responseSchema(op, res.StatusCode)
+	if s == nil || !strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		return res, nil
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		c.t.Errorf("%s %s: invalid json: %v", req.Method, path, err)
+		return res, nil
+	}
+	if err := c.spec.validate(s, v, "response"); err != nil {
+		c.t.Errorf("%s %s (%d): %v", req.Method, path, res.StatusCode, err)
+	}
+	return res, nil
+}
+
+func TestSpecRoutes(t *testing.T) {
+	sk, pk, err := core.KeyGenServer()
+	handleFatal(err, t)
+	e := New(sk, pk, sk)
+	spec := loadSpec(t)
+
+	// groups route unmatched paths to the not found handler
+	notFound := runtime.FuncForPC(reflect.ValueOf(echo.NotFoundHandler).Pointer()).Name()
+	var routes, documented []string
+	for _, r := range e.Routes() {
+		if r.Name == notFound {
+			continue
+		}
+		path := regexp.MustCompile(`:(\w+)`).ReplaceAllString(r.Path, "{$1}")
+		routes = append(routes, r.Method+" "+path)
+	}
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(routes)
+	sort.Strings(documented)
+	got := strings.Join(documented, "\n")
+	want := strings.Join(routes, "\n")
+	if got != want {
+		t.Logf("routes:\n%s\n\ndocumented:\n%s", want, got)
+		t.Fatal("specification is expected to document exactly the routes of the server")
+	}
+}
+
+func handleFatal(e error, i interface{ Fatal(args ...any) }) {
+	if e != nil {
+		i.Fatal(e)
+	}
+}
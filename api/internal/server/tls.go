@@ -0,0 +1,213 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrTLSConfig = errors.New("invalid tls configuration")
+
+// TLSOptions configures the TLS of the api server.
+type TLSOptions struct {
+	// CertFile and KeyFile hold the PEM encoded certificate chain
+	// and private key of the server. They are reloaded when changed.
+	CertFile string
+	KeyFile  string
+	// SelfSigned generates a self-signed certificate for localhost,
+	// for development. It is written to CertFile and KeyFile if they
+	// are given and do not exist, and kept in memory otherwise.
+	SelfSigned bool
+	// ClientCAFile holds the PEM encoded certificates of the CAs of
+	// the client certificates authenticating users. Clients without
+	// a certificate still authenticate with a login token.
+	ClientCAFile string
+}
+
+// Enabled reports whether the server is served over TLS.
+func (o TLSOptions) Enabled() bool {
+	return o.SelfSigned || o.CertFile != "" || o.KeyFile != ""
+}
+
+// TLSConfig returns the TLS configuration of the api server.
+func TLSConfig(o TLSOptions) (*tls.Config, error) {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, fmt.Errorf("%w: the certificate and key files go together", ErrTLSConfig)
+	}
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch {
+	case o.SelfSigned && o.CertFile == "":
+		cert, err := SelfSignedCert()
+		if err != nil {
+			return nil, err
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	case o.CertFile != "":
+		if o.SelfSigned {
+			if err := writeSelfSigned(o.CertFile, o.KeyFile); err != nil {
+				return nil, err
+			}
+		}
+		r, err := NewCertReloader(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.GetCertificate = r.GetCertificate
+	default:
+		return nil, fmt.Errorf("%w: no certificate", ErrTLSConfig)
+	}
+	if o.ClientCAFile != "" {
+		data, err := os.ReadFile(o.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%w: no certificates in %s", ErrTLSConfig, o.ClientCAFile)
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return conf, nil
+}
+
+// CertReloader serves the certificate of the files, loading them
+// again once they are changed, as when the certificate is renewed.
+type CertReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewCertReloader loads the certificate of the files.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	modTime, err := r.modified()
+	if err != nil {
+		return nil, err
+	}
+	if err = r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// modified is the latest modification time of the files.
+func (r *CertReloader) modified() (time.Time, error) {
+	var t time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return t, err
+		}
+		if fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+	return t, nil
+}
+
+func (r *CertReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// GetCertificate returns the certificate for tls.Config. A certificate
+// which fails to load is logged, and the previous one kept being served.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	modTime, err := r.modified()
+	if err == nil && !modTime.Equal(r.modTime) {
+		err = r.load(modTime)
+		if err == nil {
+			log.Println("tls: reloaded the certificate", r.certFile)
+		}
+	}
+	if err != nil {
+		log.Println("tls: keeping the previous certificate:", err)
+	}
+	return r.cert, nil
+}
+
+// SelfSignedCert generates a certificate for localhost, valid for a year.
+func SelfSignedCert() (tls.Certificate, error) {
+	certPEM, keyPEM, err := selfSigned()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+func selfSigned() (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "localhost", Organization: []string{"Devspace development"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// writeSelfSigned writes a self-signed certificate to the files,
+// unless the certificate file exists.
+func writeSelfSigned(certFile, keyFile string) error {
+	_, err := os.Stat(certFile)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	certPEM, keyPEM, err := selfSigned()
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+	log.Println("tls: generated the self-signed certificate", certFile)
+	return os.WriteFile(certFile, certPEM, 0644)
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/bingxueshuang/devspaces/core"
+)
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	handleFatal(writeSelfSigned(certFile, keyFile), t)
+	r, err := NewCertReloader(certFile, keyFile)
+	handleFatal(err, t)
+	first, err := r.GetCertificate(nil)
+	handleFatal(err, t)
+
+	// a renewed certificate is served once written
+	handleFatal(os.Remove(certFile), t)
+	handleFatal(writeSelfSigned(certFile, keyFile), t)
+	later := time.Now().Add(time.Minute)
+	handleFatal(os.Chtimes(certFile, later, later), t)
+	renewed, err := r.GetCertificate(nil)
+	handleFatal(err, t)
+	if bytes.Equal(first.Certificate[0], renewed.Certificate[0]) {
+		t.Fatal("renewed certificate is expected to be reloaded")
+	}
+
+	// a broken certificate keeps the previous one
+	handleFatal(os.WriteFile(certFile, []byte("not a certificate"), 0644), t)
+	later = later.Add(time.Minute)
+	handleFatal(os.Chtimes(certFile, later, later), t)
+	got, err := r.GetCertificate(nil)
+	handleFatal(err, t)
+	if !bytes.Equal(got.Certificate[0], renewed.Certificate[0]) {
+		t.Fatal("previous certificate is expected to be kept")
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	_, err := TLSConfig(TLSOptions{CertFile: "cert.pem"})
+	if err == nil {
+		t.Fatal("certificate without a key is expected to fail")
+	}
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	conf, err := TLSConfig(TLSOptions{CertFile: certFile, KeyFile: keyFile, SelfSigned: true})
+	handleFatal(err, t)
+	if conf.GetCertificate == nil {
+		t.Fatal("self-signed certificate files are expected to be reloaded")
+	}
+	// the written certificate is kept on restart
+	data, err := os.ReadFile(certFile)
+	handleFatal(err, t)
+	_, err = TLSConfig(TLSOptions{CertFile: certFile, KeyFile: keyFile, SelfSigned: true})
+	handleFatal(err, t)
+	again, err := os.ReadFile(certFile)
+	handleFatal(err, t)
+	if !bytes.Equal(data, again) {
+		t.Fatal("self-signed certificate is not expected to be overwritten")
+	}
+}
+
+// testCA issues client certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleFatal(err, t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	handleFatal(err, t)
+	cert, err := x509.ParseCertificate(der)
+	handleFatal(err, t)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, name string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	handleFatal(err, t)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	handleFatal(err, t)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestClientCert(t *testing.T) {
+	ctx := context.Background()
+	ca := newTestCA(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0644)
+	handleFatal(err, t)
+
+	sk, pk, err := core.KeyGenServer()
+	handleFatal(err, t)
+	logKey, _, err := core.KeyGen()
+	handleFatal(err, t)
+	srv := httptest.NewUnstartedServer(New(sk, pk, logKey))
+	srv.TLS, err = TLSConfig(TLSOptions{SelfSigned: true, ClientCAFile: caFile})
+	handleFatal(err, t)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	roots := x509.NewCertPool()
+	leaf, err := x509.ParseCertificate(srv.TLS.Certificates[0].Certificate[0])
+	handleFatal(err, t)
+	roots.AddCert(leaf)
+	newCertClient := func(token string, certs ...tls.Certificate) *client.Client {
+		var tokens client.TokenSource
+		if token != "" {
+			tokens = client.StaticToken(token)
+		}
+		c := client.New(srv.URL, tokens)
+		c.Retries = 0
+		c.HTTPClient = &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+		return c
+	}
+	newClient := func(token string) *client.Client {
+		return newCertClient(token)
+	}
+	alice := signup(t, newClient, "tls-alice")
+
+	t.Run("token", func(t *testing.T) {
+		_, err := alice.c.ListSpaces(ctx)
+		handleFatal(err, t)
+		_, err = newClient("").ListSpaces(ctx)
+		expectCode(t, err, "unauthorized")
+	})
+	t.Run("certificate", func(t *testing.T) {
+		c := newCertClient("", ca.issue(t, alice.name))
+		_, err := c.ListSpaces(ctx)
+		handleFatal(err, t)
+	})
+	t.Run("unknown user", func(t *testing.T) {
+		c := newCertClient("", ca.issue(t, "tls-mallory"))
+		_, err := c.ListSpaces(ctx)
+		expectCode(t, err, "unauthorized")
+	})
+	t.Run("untrusted ca", func(t *testing.T) {
+		c := newCertClient("", newTestCA(t).issue(t, alice.name))
+		_, err := c.ListSpaces(ctx)
+		if err == nil {
+			t.Fatal("certificate of an untrusted ca is expected to be rejected")
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package space
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/api/internal/blob"
+	"github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/bingxueshuang/devspaces/db"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// BlobTTL is the time for which an uploaded blob is kept
+// before it has to be attached to a message.
+var BlobTTL = time.Hour
+
+// UploadBlob streams the raw request body into the blob store so that
+// it can be attached to messages sent on the devspace by its owner and
+// the collaborators invited to it.
+func UploadBlob(c echo.Context) error {
+	u := c.Get("user").(*jwt.Token)
+	claims := u.Claims.(*core.TokenClaims)
+	space, err := memberSpace(c)
+	if err != nil {
+		return err
+	}
+	store := *blob.Default
+	var errQuota error
+	if store.Quota > 0 {
+		used, err := db.BlobsSize(space.Name)
+		if err != nil {
+			return core.ServerError(err)
+		}
+		if left := store.Quota - used; left < store.MaxSize {
+			store.MaxSize = left
+			errQuota = fmt.Errorf("attachments of the devspace exceed the quota of %d bytes", store.Quota)
+		}
+	}
+	tooLarge := core.ErrTooLarge
+	if errQuota != nil {
+		tooLarge = tooLarge.WithDetail(errQuota)
+	}
+	if c.Request().ContentLength > store.MaxSize {
+		return tooLarge
+	}
+	digest, size, err := store.Put(c.Request().Body)
+	if errors.Is(err, blob.ErrTooLarge) {
+		return tooLarge
+	}
+	if err != nil {
+		return core.ServerError(err)
+	}
+	ok, err := db.AddBlob(&db.Blob{
+		Digest:   digest,
+		On:       space.Name,
+		Size:     size,
+		Uploader: claims.Username,
+	})
+	if !ok || err != nil {
+		return core.ServerError(err)
+	}
+	return core.SendOK(c, map[string]any{
+		"digest": digest,
+		"size":   size,
+	})
+}
+
+// PruneBlobs drops the blobs which no message is attached to once
+// BlobTTL has passed since their upload, e.g. after the messages were
+// deleted or expired, and removes the content no devspace has left.
+// It returns the number of blobs whose content was removed.
+func PruneBlobs(now time.Time) (n int, err error) {
+	unused, err := db.PruneBlobs(now.Add(-BlobTTL))
+	if err != nil {
+		return 0, err
+	}
+	for _, digest := range unused {
+		if err = blob.Default.Remove(digest); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// DownloadBlob streams an attachment of the devspace to its owner.
+func DownloadBlob(c echo.Context) error {
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	ok, b, err := db.FindBlob(space.Name, c.Param("digest"))
+	if err != nil {
+		return core.ServerError(err)
+	}
+	if !ok {
+		return core.ErrBlobNotFound
+	}
+	f, err := blob.Default.Open(b.Digest)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	defer f.Close()
+	return c.Stream(http.StatusOK, echo.MIMEOctetStream, f)
+}
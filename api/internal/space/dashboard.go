@@ -15,9 +15,9 @@ func DashboardHandler(c echo.Context) error {
 	uname := claims.Username
 	requests, err := db.RequestsTo(uname)
 	if err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
-	res := make([]map[string]any, len(requests))
+	res := make([]map[string]any, 0, len(requests))
 	for _, r := range requests {
 		res = append(res, map[string]any{
 			"from":   r.From,
@@ -2,13 +2,66 @@ package space
 
 import (
 	"encoding/hex"
+	"errors"
+	"path/filepath"
 
 	"github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/bingxueshuang/devspaces/api/internal/webhook"
+	peks "github.com/bingxueshuang/devspaces/core"
 	"github.com/bingxueshuang/devspaces/db"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
 )
 
+// findSpace finds the devspace in the request path.
+func findSpace(c echo.Context) (db.Space, error) {
+	ok, space, err := db.FindSpace(c.Param("dev"))
+	if err != nil {
+		return space, core.ServerError(err)
+	}
+	if !ok {
+		return space, core.ErrSpaceNotFound
+	}
+	return space, nil
+}
+
+// ownedSpace finds the devspace in the request path and
+// checks that it is owned by the logged in user.
+func ownedSpace(c echo.Context) (db.Space, error) {
+	space, err := findSpace(c)
+	if err != nil {
+		return space, err
+	}
+	u := c.Get("user").(*jwt.Token)
+	claims := u.Claims.(*core.TokenClaims)
+	if space.Owner != claims.Username {
+		return space, core.ErrForbidden
+	}
+	return space, nil
+}
+
+// memberSpace finds the devspace in the request path and checks
+// that the logged in user owns it or was invited to it.
+func memberSpace(c echo.Context) (db.Space, error) {
+	space, err := findSpace(c)
+	if err != nil {
+		return space, err
+	}
+	u := c.Get("user").(*jwt.Token)
+	claims := u.Claims.(*core.TokenClaims)
+	if space.Owner == claims.Username {
+		return space, nil
+	}
+	ok, err := db.IsMember(space.Name, claims.Username)
+	if err != nil {
+		return space, core.ServerError(err)
+	}
+	if !ok {
+		return space, core.ErrForbidden
+	}
+	return space, nil
+}
+
 func validateRequest(r *core.Request) bool {
 	if r == nil ||
 		r.To == nil ||
@@ -21,89 +74,205 @@ func validateRequest(r *core.Request) bool {
 func RequestHandler(c echo.Context) error {
 	req := new(core.Request)
 	if err := c.Bind(req); err != nil {
-		return core.BadRequest(c, "invalid collaboration request", err)
+		return core.ErrInvalidBody.WithDetail(err)
 	}
 	if !validateRequest(req) {
-		return core.BadRequest(c, "missing fields in request body", nil)
+		return core.ErrMissingFields
+	}
+	secret, err := hex.DecodeString(*req.Secret)
+	if err != nil {
+		return core.ErrInvalidData.WithDetail(err)
 	}
 	u := c.Get("user").(*jwt.Token)
 	claims := u.Claims.(*core.TokenClaims)
-	from := claims.Username
-	secret, err := hex.DecodeString(*req.Secret)
+	space, err := findSpace(c)
+	if err != nil {
+		return err
+	}
+	ok, _, err := db.GetUser(*req.To)
 	if err != nil {
-		return core.BadRequest(c, "invalid request secret", err)
+		return core.ServerError(err)
+	}
+	if !ok {
+		return core.ErrUserNotFound
 	}
-	devspace := c.Param("dev")
-	ok, err := db.AddRequest(&db.Request{
-		From:   from,
-		On:     devspace,
+	ok, err = db.AddRequest(&db.Request{
+		From:   claims.Username,
+		On:     space.Name,
 		To:     *req.To,
 		Secret: secret,
 	})
 	if !ok || err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
 	return core.SendOK(c, nil)
 }
 
+// ListMembers lists the collaborators invited to the devspace
+// along with their public keys.
+func ListMembers(c echo.Context) error {
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	members, err := db.Members(space.Name)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	res := make([]map[string]any, 0, len(members))
+	for _, name := range members {
+		ok, user, err := db.GetUser(name)
+		if err != nil {
+			return core.ServerError(err)
+		}
+		if !ok {
+			continue
+		}
+		res = append(res, map[string]any{
+			"username": user.Username,
+			"pubkey":   hex.EncodeToString(user.Pubkey),
+			"version":  user.KeyVersion(),
+		})
+	}
+	return core.SendOK(c, res)
+}
+
 func validateSend(m *core.Message) bool {
 	if m == nil ||
 		m.Data == nil ||
 		m.Keyword == nil {
 		return false
 	}
+	for _, a := range m.Attachments {
+		if a.Name == nil || a.Digest == nil {
+			return false
+		}
+	}
 	return true
 }
 
+// verifySignature checks the signature of the message against the
+// public key of the sender. Signatures are required on devspaces of
+// the shared scheme, whose tags do not authenticate the sender.
+func verifySignature(req *core.Message, space db.Space, from string, data, ciphertext []byte) ([]byte, error) {
+	if req.Signature == nil {
+		if space.Scheme == db.SchemeShared {
+			return nil, core.ErrInvalidSignature.WithDetail(errors.New("messages of the shared scheme are signed"))
+		}
+		return nil, nil
+	}
+	signature, err := hex.DecodeString(*req.Signature)
+	if err != nil {
+		return nil, core.ErrInvalidSignature.WithDetail(err)
+	}
+	ok, user, err := db.GetUser(from)
+	if err != nil {
+		return nil, core.ServerError(err)
+	}
+	if !ok {
+		return nil, core.ErrUserNotFound
+	}
+	pk := new(peks.PKey)
+	if err = pk.FromBytes(user.Pubkey); err != nil {
+		return nil, core.ServerError(err)
+	}
+	digests := make([]string, 0, len(req.Attachments))
+	for _, a := range req.Attachments {
+		digests = append(digests, *a.Digest)
+	}
+	msg := peks.MessageDigest(space.Name, ciphertext, data, digests)
+	ok, err = peks.Verify(msg, signature, pk)
+	if err != nil {
+		return nil, core.ErrInvalidSignature.WithDetail(err)
+	}
+	if !ok {
+		return nil, core.ErrInvalidSignature
+	}
+	return signature, nil
+}
+
 func SendHandler(c echo.Context) error {
 	req := new(core.Message)
 	if err := c.Bind(req); err != nil {
-		return core.BadRequest(c, "invalid send format", err)
+		return core.ErrInvalidBody.WithDetail(err)
 	}
 	if !validateSend(req) {
-		return core.BadRequest(c, "missing data or keyword", nil)
+		return core.ErrMissingFields
 	}
 	data, err := hex.DecodeString(*req.Data)
 	if err != nil {
-		return core.BadRequest(c, "invalid data format", err)
+		return core.ErrInvalidData.WithDetail(err)
 	}
 	ciphertext, err := hex.DecodeString(*req.Keyword)
 	if err != nil {
-		return core.BadRequest(c, "invalid keyword format", err)
+		return core.ErrInvalidKeyword.WithDetail(err)
 	}
 	u := c.Get("user").(*jwt.Token)
 	claims := u.Claims.(*core.TokenClaims)
 	from := claims.Username
-	ok, space, err := db.FindSpace(c.Param("dev"))
-	if !ok || err != nil {
-		return core.ServerError(c, err)
+	space, err := findSpace(c)
+	if err != nil {
+		return err
+	}
+	signature, err := verifySignature(req, space, from, data, ciphertext)
+	if err != nil {
+		return err
+	}
+	attachments := make([]db.Attachment, 0, len(req.Attachments))
+	for _, a := range req.Attachments {
+		ok, b, err := db.FindBlob(space.Name, *a.Digest)
+		if err != nil {
+			return core.ServerError(err)
+		}
+		if !ok {
+			return core.ErrInvalidAttachment.WithDetail(errors.New("blob not uploaded: " + *a.Digest))
+		}
+		attachments = append(attachments, db.Attachment{
+			Name:   filepath.Base(*a.Name),
+			Digest: b.Digest,
+			Size:   b.Size,
+		})
 	}
 	serverKey := c.Get("ServerKey").(core.KeyContext)
 	tag, err := db.MessageTag(ciphertext, serverKey.SKey, space)
+	if errors.Is(err, peks.ErrCiphertext) {
+		return core.ErrInvalidKeyword.WithDetail(err)
+	}
+	if errors.Is(err, peks.ErrTrapdoor) {
+		// stored trapdoors are validated when tags are created
+		return core.ServerError(err)
+	}
 	if err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
-	ok, err = db.AddMessage(&db.Message{
-		From:    from,
-		To:      space.Owner,
-		On:      space.Name,
-		Tag:     tag,
-		Data:    data,
-		Keyword: ciphertext,
-	})
+	msg := &db.Message{
+		From:        from,
+		To:          space.Owner,
+		On:          space.Name,
+		Tag:         tag,
+		Data:        data,
+		Keyword:     ciphertext,
+		Signature:   signature,
+		Attachments: attachments,
+	}
+	ok, err := db.AddMessage(msg)
 	if !ok || err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
+	messageHub.publish(*msg)
+	webhook.Default.Dispatch(*msg)
 	return core.SendOK(c, nil)
 }
 
 func PubkeyHandler(c echo.Context) error {
-	ok, space, err := db.FindSpace(c.Param("dev"))
-	if !ok || err != nil {
-		return core.ServerError(c, err)
+	space, err := findSpace(c)
+	if err != nil {
+		return err
 	}
 	pk := hex.EncodeToString(space.Pubkey)
 	return core.SendOK(c, map[string]any{
 		"pubkey": pk,
+		"epoch":  space.Epoch,
+		"scheme": space.Scheme,
 	})
 }
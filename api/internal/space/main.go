@@ -1,14 +1,49 @@
 package space
 
-import "github.com/labstack/echo/v4"
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// MaxMessageSize limits the request body of a message sent
+// on a devspace. Larger payloads are sent as attachments.
+var MaxMessageSize = "1M"
+
+// reservedTags are the names of the routes under a devspace, which
+// take precedence over listing the messages of a tag of the name.
+var reservedTags = map[string]bool{
+	"request":   true,
+	"members":   true,
+	"send":      true,
+	"blobs":     true,
+	"pubkey":    true,
+	"stream":    true,
+	"retention": true,
+	"messages":  true,
+	"webhooks":  true,
+}
 
 func Setup(g *echo.Group) {
 	g.POST("/:dev/request", RequestHandler)
-	g.POST("/:dev/send", SendHandler)
+	g.GET("/:dev/members", ListMembers)
+	g.POST("/:dev/send", SendHandler, middleware.BodyLimit(MaxMessageSize))
+	g.POST("/:dev/blobs", UploadBlob)
+	g.GET("/:dev/blobs/:digest", DownloadBlob)
 	g.POST("/", CreateDev)
 	g.GET("/", ListDev)
 	g.POST("/:dev", CreateTag)
 	g.GET("/:dev", ListTags)
 	g.GET("/:dev/pubkey", PubkeyHandler)
+	g.PUT("/:dev/pubkey", RotateKey)
+	g.GET("/:dev/stream", StreamHandler)
+	g.GET("/:dev/retention", RetentionHandler)
+	g.PUT("/:dev/retention", SetRetention)
+	g.DELETE("/:dev/messages/:id", DeleteMessage)
+	g.PATCH("/:dev/messages/:id", MarkMessage)
+	g.POST("/:dev/webhooks", CreateWebhook)
+	g.GET("/:dev/webhooks", ListWebhooks)
+	g.DELETE("/:dev/webhooks/:id", DeleteWebhook)
+	g.GET("/:dev/webhooks/:id/deliveries", ListDeliveries)
+	g.GET("/:dev/webhooks/:id/dead", ListDeadLetters)
 	g.GET("/:dev/:tag", ListMessages)
 }
@@ -0,0 +1,132 @@
+package space
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/bingxueshuang/devspaces/db"
+	"github.com/labstack/echo/v4"
+)
+
+func DeleteMessage(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return core.ErrInvalidParameter.WithDetail(err)
+	}
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	ok, err := db.DeleteMessage(space.Name, id)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	if !ok {
+		return core.ErrMessageNotFound
+	}
+	return core.SendOK(c, nil)
+}
+
+func MarkMessage(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return core.ErrInvalidParameter.WithDetail(err)
+	}
+	req := new(core.Mark)
+	if err := c.Bind(req); err != nil {
+		return core.ErrInvalidBody.WithDetail(err)
+	}
+	if req.Read == nil {
+		return core.ErrMissingFields
+	}
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	ok, err := db.MarkMessage(space.Name, id, *req.Read)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	if !ok {
+		return core.ErrMessageNotFound
+	}
+	return core.SendOK(c, nil)
+}
+
+func retentionResponse(r db.Retention) map[string]any {
+	return map[string]any{
+		"max_age":   r.MaxAge.String(),
+		"max_count": r.MaxCount,
+	}
+}
+
+func RetentionHandler(c echo.Context) error {
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	return core.SendOK(c, retentionResponse(space.Retention))
+}
+
+func SetRetention(c echo.Context) error {
+	req := new(core.RetentionPolicy)
+	if err := c.Bind(req); err != nil {
+		return core.ErrInvalidBody.WithDetail(err)
+	}
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	policy := space.Retention
+	if req.MaxAge != nil {
+		policy.MaxAge, err = time.ParseDuration(*req.MaxAge)
+		if err != nil {
+			return core.ErrValidation.WithDetail(err)
+		}
+	}
+	if req.MaxCount != nil {
+		policy.MaxCount = *req.MaxCount
+	}
+	if policy.MaxAge < 0 || policy.MaxCount < 0 {
+		return core.ErrValidation.WithDetail(errors.New("retention limits must not be negative"))
+	}
+	ok, err := db.SetRetention(space.Name, policy)
+	if !ok || err != nil {
+		return core.ServerError(err)
+	}
+	return core.SendOK(c, retentionResponse(policy))
+}
+
+// Janitor enforces the retention policies of the devspaces and
+// removes the blobs no longer attached, every interval until done
+// is closed.
+func Janitor(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			n, err := db.PruneMessages(now)
+			if err != nil {
+				log.Println("janitor:", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("janitor: removed %d expired messages", n)
+			}
+			n, err = PruneBlobs(now)
+			if err != nil {
+				log.Println("janitor:", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("janitor: removed %d unattached blobs", n)
+			}
+		}
+	}
+}
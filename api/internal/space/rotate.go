@@ -0,0 +1,100 @@
+package space
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/bingxueshuang/devspaces/db"
+	"github.com/labstack/echo/v4"
+)
+
+// RotationWindow is the duration for which tags of the previous
+// key epoch keep routing messages after a key rotation, unless the
+// rotation asks for another window of up to MaxRotationWindow.
+var (
+	RotationWindow    = 7 * 24 * time.Hour
+	MaxRotationWindow = 30 * 24 * time.Hour
+)
+
+// rotationWindow parses the window of the rotation, which retires the
+// previous key at once if zero, e.g. when it leaked.
+func rotationWindow(r *core.Rotation) (time.Duration, error) {
+	if r.Window == nil {
+		return RotationWindow, nil
+	}
+	window, err := time.ParseDuration(*r.Window)
+	if err != nil {
+		return 0, core.ErrValidation.WithDetail(err)
+	}
+	if window < 0 || window > MaxRotationWindow {
+		return 0, core.ErrValidation.WithDetail(fmt.Errorf("window must be between 0s and %v", MaxRotationWindow))
+	}
+	return window, nil
+}
+
+func validateRotation(r *core.Rotation) bool {
+	if r == nil || r.Pubkey == nil || r.Nonce == nil || r.Proof == nil {
+		return false
+	}
+	for i := range r.Tags {
+		if !validateTag(&r.Tags[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func RotateKey(c echo.Context) error {
+	req := new(core.Rotation)
+	if err := c.Bind(req); err != nil {
+		return core.ErrInvalidBody.WithDetail(err)
+	}
+	if !validateRotation(req) {
+		return core.ErrMissingFields
+	}
+	pubkey, err := core.DecodePubkey(c, *req.Pubkey)
+	if err != nil {
+		return err
+	}
+	window, err := rotationWindow(req)
+	if err != nil {
+		return err
+	}
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	// the owner proves to hold the new secret key of the devspace
+	err = core.CheckPossession(space.Name, pubkey, *req.Nonce, *req.Proof)
+	if err != nil {
+		return err
+	}
+	tags := make([]*db.Tag, 0, len(req.Tags))
+	for _, t := range req.Tags {
+		if err := checkTagName(*t.Name); err != nil {
+			return err
+		}
+		trapdoor, err := decodeTrapdoor(c, *t.Trapdoor, space.Scheme)
+		if err != nil {
+			return err
+		}
+		sender, version, err := tagSender(t)
+		if err != nil {
+			return err
+		}
+		tags = append(tags, &db.Tag{
+			Name:      *t.Name,
+			Trapdoor:  trapdoor,
+			Sender:    sender,
+			SenderKey: version,
+		})
+	}
+	ok, epoch, err := db.RotateKey(space.Name, pubkey, tags, window)
+	if !ok || err != nil {
+		return core.ServerError(err)
+	}
+	return core.SendOK(c, map[string]any{
+		"epoch": epoch,
+	})
+}
@@ -0,0 +1,163 @@
+package space
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/bingxueshuang/devspaces/db"
+	"github.com/labstack/echo/v4"
+)
+
+// StreamHeartbeat is the interval at which idle streams
+// are sent a comment to keep the connection alive.
+var StreamHeartbeat = 30 * time.Second
+
+// subscriber receives the messages routed on a devspace.
+// closed is set once the hub gives up on a slow subscriber.
+type subscriber struct {
+	space  string
+	ch     chan db.Message
+	closed bool
+}
+
+// hub fans out routed messages to the streams of a devspace.
+type hub struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+}
+
+var messageHub = &hub{subs: make(map[*subscriber]struct{})}
+
+func (h *hub) subscribe(space string) *subscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := &subscriber{
+		space: space,
+		ch:    make(chan db.Message, 64),
+	}
+	h.subs[s] = struct{}{}
+	return s
+}
+
+func (h *hub) unsubscribe(s *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		if !s.closed {
+			close(s.ch)
+		}
+	}
+}
+
+// publish never blocks the sender. A subscriber whose buffer is
+// full is disconnected and expected to resume using Last-Event-ID.
+func (h *hub) publish(m db.Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if s.space != m.On || s.closed {
+			continue
+		}
+		select {
+		case s.ch <- m:
+		default:
+			s.closed = true
+			close(s.ch)
+		}
+	}
+}
+
+func writeEvent(w *echo.Response, m db.Message) error {
+	data, err := json.Marshal(messageResponse(m))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", m.ID, data)
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+// StreamHandler pushes the messages routed on the devspace to the
+// owner as server-sent events, optionally filtered by tag. Messages
+// after the Last-Event-ID header are replayed on reconnection.
+func StreamHandler(c echo.Context) error {
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	var lastID int64
+	if v := c.Request().Header.Get("Last-Event-ID"); v != "" {
+		lastID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return core.ErrInvalidParameter.WithDetail(err)
+		}
+	}
+	tags := make(map[string]bool)
+	for _, t := range c.QueryParams()["tag"] {
+		tags[t] = true
+	}
+	wanted := func(m db.Message) bool {
+		return m.ID > lastID && (len(tags) == 0 || tags[m.Tag])
+	}
+
+	// subscribe before replaying so that no message is missed
+	sub := messageHub.subscribe(space.Name)
+	defer messageHub.unsubscribe(sub)
+	var backlog []db.Message
+	if lastID != 0 {
+		backlog, _, err = db.ListMessages("", space.Name, db.MessageQuery{After: lastID})
+		if err != nil {
+			return core.ServerError(err)
+		}
+	}
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+	for _, m := range backlog {
+		if !wanted(m) {
+			continue
+		}
+		if err := writeEvent(w, m); err != nil {
+			return nil
+		}
+		lastID = m.ID
+	}
+
+	heartbeat := time.NewTicker(StreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		case m, ok := <-sub.ch:
+			if !ok {
+				return nil
+			}
+			if !wanted(m) {
+				continue
+			}
+			if err := writeEvent(w, m); err != nil {
+				return nil
+			}
+			lastID = m.ID
+		}
+	}
+}
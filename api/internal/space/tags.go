@@ -2,8 +2,13 @@ package space
 
 import (
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/bingxueshuang/devspaces/api/internal/core"
+	peks "github.com/bingxueshuang/devspaces/core"
 	"github.com/bingxueshuang/devspaces/db"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/labstack/echo/v4"
@@ -21,14 +26,21 @@ func validateSpace(s *core.DevSpace) bool {
 func CreateDev(c echo.Context) error {
 	req := new(core.DevSpace)
 	if err := c.Bind(req); err != nil {
-		return core.BadRequest(c, "invalid request body", err)
+		return core.ErrInvalidBody.WithDetail(err)
 	}
-	pubkey, err := hex.DecodeString(*req.Pubkey)
+	if !validateSpace(req) {
+		return core.ErrMissingFields
+	}
+	pubkey, err := core.DecodePubkey(c, *req.Pubkey)
 	if err != nil {
-		return core.BadRequest(c, "invalid public key", err)
+		return err
 	}
-	if !validateSpace(req) {
-		return core.BadRequest(c, "missing fields in request body", nil)
+	scheme := db.SchemeSender
+	if req.Scheme != nil {
+		scheme = *req.Scheme
+	}
+	if scheme != db.SchemeSender && scheme != db.SchemeShared {
+		return core.ErrValidation.WithDetail(fmt.Errorf("unknown scheme %q", scheme))
 	}
 	u := c.Get("user").(*jwt.Token)
 	claims := u.Claims.(*core.TokenClaims)
@@ -37,10 +49,14 @@ func CreateDev(c echo.Context) error {
 		Name:   *req.Name,
 		Owner:  owner,
 		Pubkey: pubkey,
+		Scheme: scheme,
 		Tags:   nil,
 	})
-	if !ok || err != nil {
-		return core.ServerError(c, err)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	if !ok {
+		return core.ErrSpaceExists
 	}
 	return core.SendOK(c, nil)
 }
@@ -51,13 +67,14 @@ func ListDev(c echo.Context) error {
 	owner := claims.Username
 	spaces, err := db.ListSpaces(owner)
 	if err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
 	res := make([]map[string]any, 0, len(spaces))
 	for _, s := range spaces {
 		res = append(res, map[string]any{
 			"name":   s.Name,
 			"pubkey": hex.EncodeToString(s.Pubkey),
+			"scheme": s.Scheme,
 		})
 	}
 	return core.SendOK(c, res)
@@ -72,59 +89,212 @@ func validateTag(t *core.Tag) bool {
 	return true
 }
 
+// decodeTrapdoor decodes a hex trapdoor, which has to be valid
+// for the scheme of the devspace on the curve of the server.
+func decodeTrapdoor(c echo.Context, s string, scheme string) ([]byte, error) {
+	trapdoor, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, core.ErrInvalidTrapdoor.WithDetail(err)
+	}
+	curve := c.Get("ServerKey").(core.KeyContext).PKey.Curve
+	validate := peks.ValidateTrapdoor
+	if scheme == db.SchemeShared {
+		validate = peks.ValidateTrapdoorShared
+	}
+	if err = validate(curve, trapdoor); err != nil {
+		return nil, core.ErrInvalidTrapdoor.WithDetail(err)
+	}
+	return trapdoor, nil
+}
+
+// tagSender resolves the sender named by the tag to the version of
+// its current key, which the trapdoor of the tag is taken to match.
+func tagSender(t core.Tag) (sender string, version int, err error) {
+	if t.Sender == nil {
+		return "", 0, nil
+	}
+	ok, user, err := db.GetUser(*t.Sender)
+	if err != nil {
+		return "", 0, core.ServerError(err)
+	}
+	if !ok {
+		return "", 0, core.ErrUserNotFound
+	}
+	return user.Username, user.KeyVersion(), nil
+}
+
+// checkTagName refuses the names of tags whose messages could
+// not be listed, see reservedTags.
+func checkTagName(name string) error {
+	if reservedTags[name] {
+		return core.ErrValidation.WithDetail(fmt.Errorf("tag name %q is reserved", name))
+	}
+	return nil
+}
+
 func CreateTag(c echo.Context) error {
 	req := new(core.Tag)
 	if err := c.Bind(req); err != nil {
-		return core.BadRequest(c, "invalid request body", err)
+		return core.ErrInvalidBody.WithDetail(err)
 	}
 	if !validateTag(req) {
-		return core.BadRequest(c, "missing fields in request body", nil)
+		return core.ErrMissingFields
+	}
+	if err := checkTagName(*req.Name); err != nil {
+		return err
 	}
-	trapdoor, err := hex.DecodeString(*req.Trapdoor)
+	space, err := ownedSpace(c)
 	if err != nil {
-		return core.BadRequest(c, "invalid trapdoor", err)
+		return err
 	}
-	space := c.Param("dev")
-	ok, err := db.AddTag(space, &db.Tag{
-		Name:     *req.Name,
-		Trapdoor: trapdoor,
+	trapdoor, err := decodeTrapdoor(c, *req.Trapdoor, space.Scheme)
+	if err != nil {
+		return err
+	}
+	sender, version, err := tagSender(*req)
+	if err != nil {
+		return err
+	}
+	// more trapdoors are added to an existing tag only when asked to,
+	// e.g. to match the keyword from more than one sender
+	if req.Append == nil || !*req.Append {
+		for _, t := range space.Tags {
+			if t.Name == *req.Name && t.Epoch == space.Epoch {
+				return core.ErrTagExists
+			}
+		}
+	}
+	ok, err := db.AddTag(space.Name, &db.Tag{
+		Name:      *req.Name,
+		Trapdoor:  trapdoor,
+		Sender:    sender,
+		SenderKey: version,
 	})
 	if !ok || err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
 	return core.SendOK(c, nil)
 }
 
 func ListTags(c echo.Context) error {
-	space := c.Param("dev")
-	tags, err := db.ListTags(space)
+	space, err := findSpace(c)
+	if err != nil {
+		return err
+	}
+	tags, err := db.ListTags(space.Name)
 	if err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
 	res := make([]map[string]any, 0, len(tags))
 	for _, t := range tags {
 		res = append(res, map[string]any{
-			"name":     t.Name,
-			"trapdoor": hex.EncodeToString(t.Trapdoor),
+			"name":       t.Name,
+			"trapdoor":   hex.EncodeToString(t.Trapdoor),
+			"epoch":      t.Epoch,
+			"sender":     t.Sender,
+			"sender_key": t.SenderKey,
 		})
 	}
 	return core.SendOK(c, res)
 }
 
+func messageResponse(m db.Message) map[string]any {
+	attachments := make([]map[string]any, 0, len(m.Attachments))
+	for _, a := range m.Attachments {
+		attachments = append(attachments, map[string]any{
+			"name":   a.Name,
+			"digest": a.Digest,
+			"size":   a.Size,
+		})
+	}
+	return map[string]any{
+		"attachments": attachments,
+		"id":          m.ID,
+		"received":    m.Received,
+		"from":        m.From,
+		"tag":         m.Tag,
+		"read":        m.Read,
+		"data":        hex.EncodeToString(m.Data),
+		"keyword":     hex.EncodeToString(m.Keyword),
+		"signature":   hex.EncodeToString(m.Signature),
+	}
+}
+
+// MaxMessages is the largest page size served by ListMessages.
+var MaxMessages = 1000
+
+func parseMessageQuery(c echo.Context) (q db.MessageQuery, err error) {
+	q.Limit = MaxMessages
+	if v := c.QueryParam("limit"); v != "" {
+		q.Limit, err = strconv.Atoi(v)
+		if err != nil {
+			return
+		}
+		if q.Limit <= 0 || q.Limit > MaxMessages {
+			err = fmt.Errorf("limit must be between 1 and %d", MaxMessages)
+			return
+		}
+	}
+	if v := c.QueryParam("cursor"); v != "" {
+		q.After, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return
+		}
+	}
+	if v := c.QueryParam("since"); v != "" {
+		q.Since, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return
+		}
+	}
+	if v := c.QueryParam("until"); v != "" {
+		q.Until, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return
+		}
+	}
+	switch c.QueryParam("order") {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		err = errors.New("order must be asc or desc")
+		return
+	}
+	if v := c.QueryParam("unread"); v != "" {
+		q.Unread, err = strconv.ParseBool(v)
+		if err != nil {
+			return
+		}
+	}
+	q.From = c.QueryParam("from")
+	return
+}
+
 func ListMessages(c echo.Context) error {
-	space := c.Param("dev")
 	tag := c.Param("tag")
-	mlist, err := db.ListMessages(tag, space)
+	query, err := parseMessageQuery(c)
+	if err != nil {
+		return core.ErrInvalidParameter.WithDetail(err)
+	}
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	mlist, more, err := db.ListMessages(tag, space.Name, query)
 	if err != nil {
-		return core.ServerError(c, err)
+		return core.ServerError(err)
 	}
 	msgs := make([]map[string]any, 0, len(mlist))
 	for _, m := range mlist {
-		msgs = append(msgs, map[string]any{
-			"from":    m.From,
-			"data":    hex.EncodeToString(m.Data),
-			"keyword": hex.EncodeToString(m.Keyword),
-		})
+		msgs = append(msgs, messageResponse(m))
+	}
+	var next any
+	if more {
+		next = strconv.FormatInt(mlist[len(mlist)-1].ID, 10)
 	}
-	return core.SendOK(c, msgs)
+	return core.SendOK(c, map[string]any{
+		"messages": msgs,
+		"next":     next,
+	})
 }
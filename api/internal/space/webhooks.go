@@ -0,0 +1,180 @@
+package space
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/bingxueshuang/devspaces/api/internal/core"
+	"github.com/bingxueshuang/devspaces/api/internal/webhook"
+	"github.com/bingxueshuang/devspaces/db"
+	"github.com/labstack/echo/v4"
+)
+
+func validateWebhook(w *core.Webhook) bool {
+	if w == nil || w.URL == nil {
+		return false
+	}
+	return true
+}
+
+func webhookResponse(w db.Webhook) map[string]any {
+	return map[string]any{
+		"id":      w.ID,
+		"url":     w.URL,
+		"tag":     w.Tag,
+		"created": w.Created,
+	}
+}
+
+func CreateWebhook(c echo.Context) error {
+	req := new(core.Webhook)
+	if err := c.Bind(req); err != nil {
+		return core.ErrInvalidBody.WithDetail(err)
+	}
+	if !validateWebhook(req) {
+		return core.ErrMissingFields
+	}
+	u, err := url.Parse(*req.URL)
+	if err != nil {
+		return core.ErrValidation.WithDetail(err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return core.ErrValidation.WithDetail(errors.New("webhook url must be an absolute http url"))
+	}
+	if err = webhook.Default.CheckURL(u); err != nil {
+		return core.ErrValidation.WithDetail(err)
+	}
+	// payloads are signed with the secret as given, or with the
+	// hex encoding of a generated one, which is what is returned
+	var secret []byte
+	if req.Secret != nil {
+		secret = []byte(*req.Secret)
+	} else {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return core.ServerError(err)
+		}
+		secret = []byte(hex.EncodeToString(key))
+	}
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	w := &db.Webhook{
+		On:     space.Name,
+		URL:    u.String(),
+		Secret: secret,
+	}
+	if req.Tag != nil {
+		w.Tag = *req.Tag
+	}
+	ok, err := db.AddWebhook(w)
+	if !ok || err != nil {
+		return core.ServerError(err)
+	}
+	res := webhookResponse(*w)
+	if req.Secret == nil {
+		// generated secrets are only ever revealed here
+		res["secret"] = string(secret)
+	}
+	return core.SendOK(c, res)
+}
+
+func ListWebhooks(c echo.Context) error {
+	space, err := ownedSpace(c)
+	if err != nil {
+		return err
+	}
+	hooks, err := db.ListWebhooks(space.Name)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	res := make([]map[string]any, 0, len(hooks))
+	for _, w := range hooks {
+		res = append(res, webhookResponse(w))
+	}
+	return core.SendOK(c, res)
+}
+
+// ownedWebhook finds the webhook in the request path
+// on a devspace owned by the logged in user.
+func ownedWebhook(c echo.Context) (ok bool, w db.Webhook, err error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return false, w, nil
+	}
+	space, err := ownedSpace(c)
+	if err != nil {
+		return
+	}
+	return db.FindWebhook(space.Name, id)
+}
+
+func DeleteWebhook(c echo.Context) error {
+	ok, w, err := ownedWebhook(c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return core.ErrWebhookNotFound
+	}
+	ok, err = db.DeleteWebhook(w.On, w.ID)
+	if !ok || err != nil {
+		return core.ServerError(err)
+	}
+	return core.SendOK(c, nil)
+}
+
+func ListDeliveries(c echo.Context) error {
+	ok, w, err := ownedWebhook(c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return core.ErrWebhookNotFound
+	}
+	log, err := db.ListDeliveries(w.ID)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	res := make([]map[string]any, 0, len(log))
+	for _, d := range log {
+		res = append(res, map[string]any{
+			"message":  d.Message,
+			"attempt":  d.Attempt,
+			"status":   d.Status,
+			"error":    d.Error,
+			"time":     d.Time,
+			"duration": d.Duration.String(),
+		})
+	}
+	return core.SendOK(c, res)
+}
+
+func ListDeadLetters(c echo.Context) error {
+	ok, w, err := ownedWebhook(c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return core.ErrWebhookNotFound
+	}
+	dead, err := db.ListDeadLetters(w.ID)
+	if err != nil {
+		return core.ServerError(err)
+	}
+	res := make([]map[string]any, 0, len(dead))
+	for _, d := range dead {
+		res = append(res, map[string]any{
+			"message":  d.Message,
+			"attempts": d.Attempts,
+			"error":    d.Error,
+			"time":     d.Time,
+			"payload":  string(d.Payload),
+		})
+	}
+	return core.SendOK(c, res)
+}
@@ -0,0 +1,231 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/db"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the request
+	// body keyed with the webhook secret, as sha256=<hex>.
+	SignatureHeader = "X-Devspace-Signature"
+	EventHeader     = "X-Devspace-Event"
+	DeliveryHeader  = "X-Devspace-Delivery"
+)
+
+type Attachment struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+type Payload struct {
+	Event    string    `json:"event"`
+	Devspace string    `json:"devspace"`
+	Tag      string    `json:"tag"`
+	ID       int64     `json:"id"`
+	Received time.Time `json:"received"`
+	From     string    `json:"from"`
+	Data     string    `json:"data"`
+	Keyword  string    `json:"keyword"`
+	// Signature of the sender, empty for unsigned messages.
+	Signature string `json:"signature,omitempty"`
+
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Dispatcher delivers messages to the webhooks registered on
+// their tag, retrying failed deliveries with exponential backoff.
+type Dispatcher struct {
+	Client *http.Client
+	// MaxAttempts is the number of attempts before a delivery
+	// is moved to the dead-letter list.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled
+	// on every following retry.
+	Backoff time.Duration
+	// AllowPrivate accepts webhooks on loopback, link-local and
+	// private addresses, which are otherwise refused so that devspace
+	// owners cannot reach the internal network of the server. The
+	// Client must refuse to connect to them on its own, as the
+	// client of Default does.
+	AllowPrivate bool
+}
+
+var Default = &Dispatcher{
+	Client: &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: PublicTransport(),
+	},
+	MaxAttempts: 5,
+	Backoff:     time.Second,
+}
+
+var ErrPrivateAddress = errors.New("webhook address is not public")
+
+// privateIP reports whether ip is not a public unicast address.
+func privateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
+
+// CheckURL refuses the webhook url whose host is a private address
+// or a name of the local host, unless the dispatcher allows them.
+// Names resolving to private addresses are refused on delivery.
+func (d *Dispatcher) CheckURL(u *url.URL) error {
+	if d.AllowPrivate {
+		return nil
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && privateIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// PublicTransport is an http transport which refuses to connect to
+// private addresses, whatever the names of the hosts resolve to.
+func PublicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || privateIP(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = dialer.DialContext
+	return t
+}
+
+// Sign computes the signature header value of body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid signature of body.
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Dispatch delivers the message in the background to every
+// webhook registered on its tag.
+func (d *Dispatcher) Dispatch(m db.Message) {
+	hooks, err := db.WebhooksFor(m.On, m.Tag)
+	if err != nil {
+		log.Println("webhook:", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	attachments := make([]Attachment, 0, len(m.Attachments))
+	for _, a := range m.Attachments {
+		attachments = append(attachments, Attachment(a))
+	}
+	body, err := json.Marshal(Payload{
+		Event:    "message",
+		Devspace: m.On,
+		Tag:      m.Tag,
+		ID:       m.ID,
+		Received: m.Received,
+		From:     m.From,
+		Data:     hex.EncodeToString(m.Data),
+		Keyword:  hex.EncodeToString(m.Keyword),
+
+		Signature:   hex.EncodeToString(m.Signature),
+		Attachments: attachments,
+	})
+	if err != nil {
+		log.Println("webhook:", err)
+		return
+	}
+	for _, h := range hooks {
+		go d.deliver(h, m.ID, body)
+	}
+}
+
+func (d *Dispatcher) deliver(h db.Webhook, message int64, body []byte) {
+	delay := d.Backoff
+	var lastErr string
+	for attempt := 1; attempt <= d.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		start := time.Now()
+		status, err := d.post(h, message, body)
+		entry := &db.Delivery{
+			Webhook:  h.ID,
+			Message:  message,
+			Attempt:  attempt,
+			Status:   status,
+			Time:     start.UTC(),
+			Duration: time.Since(start),
+		}
+		if err == nil && (status < 200 || status > 299) {
+			err = fmt.Errorf("unexpected status %d", status)
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			lastErr = entry.Error
+		}
+		_, _ = db.LogDelivery(entry)
+		if err == nil {
+			return
+		}
+	}
+	_, _ = db.AddDeadLetter(&db.DeadLetter{
+		Webhook:  h.ID,
+		Message:  message,
+		Payload:  body,
+		Attempts: d.MaxAttempts,
+		Error:    lastErr,
+		Time:     time.Now().UTC(),
+	})
+}
+
+func (d *Dispatcher) post(h db.Webhook, message int64, body []byte) (int, error) {
+	req, err := http.NewRequest("POST", h.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, "message")
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(h.ID, 10)+"-"+strconv.FormatInt(message, 10))
+	req.Header.Set(SignatureHeader, Sign(h.Secret, body))
+	res, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/db"
+)
+
+var secret = []byte("webhook secret")
+
+// receiver is a webhook endpoint which fails the first n deliveries.
+type receiver struct {
+	fail     int32
+	received int32
+	payloads chan Payload
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil || !Verify(secret, body, req.Header.Get(SignatureHeader)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if atomic.AddInt32(&r.received, 1) <= atomic.LoadInt32(&r.fail) {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	p := Payload{}
+	_ = json.Unmarshal(body, &p)
+	r.payloads <- p
+}
+
+func setup(t *testing.T, space string, fail int32) (*receiver, db.Webhook) {
+	r := &receiver{fail: fail, payloads: make(chan Payload, 1)}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	h := &db.Webhook{On: space, Tag: "deploys", URL: srv.URL, Secret: secret}
+	_, err := db.AddWebhook(h)
+	handleFatal(err, t)
+	return r, *h
+}
+
+func testDispatcher() *Dispatcher {
+	return &Dispatcher{
+		Client:      http.DefaultClient,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	}
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for delivery")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"message"}`)
+	sig := Sign(secret, body)
+	t.Run("valid", func(t *testing.T) {
+		if !Verify(secret, body, sig) {
+			t.Fatal("signature is expected to verify")
+		}
+	})
+	t.Run("tampered", func(t *testing.T) {
+		if Verify(secret, []byte(`{"event":"other"}`), sig) {
+			t.Fatal("signature of tampered body is expected to fail")
+		}
+	})
+	t.Run("secret", func(t *testing.T) {
+		if Verify([]byte("other secret"), body, sig) {
+			t.Fatal("signature with wrong secret is expected to fail")
+		}
+	})
+}
+
+func TestDispatch(t *testing.T) {
+	d := testDispatcher()
+	t.Run("delivered", func(t *testing.T) {
+		r, h := setup(t, "delivered", 0)
+		d.Dispatch(db.Message{ID: 1, On: "delivered", Tag: "deploys", From: "alice"})
+		p := <-r.payloads
+		if p.ID != 1 || p.From != "alice" || p.Tag != "deploys" {
+			t.Logf("got: %+v", p)
+			t.Fatal("incorrect webhook payload")
+		}
+		waitFor(t, func() bool {
+			log, _ := db.ListDeliveries(h.ID)
+			return len(log) == 1
+		})
+	})
+	t.Run("other tag", func(t *testing.T) {
+		r, h := setup(t, "filtered", 0)
+		d.Dispatch(db.Message{ID: 2, On: "filtered", Tag: "others"})
+		time.Sleep(50 * time.Millisecond)
+		log, _ := db.ListDeliveries(h.ID)
+		if len(log) != 0 || atomic.LoadInt32(&r.received) != 0 {
+			t.Fatal("webhook is expected to be notified of its tag only")
+		}
+	})
+	t.Run("retry", func(t *testing.T) {
+		r, h := setup(t, "retried", 2)
+		d.Dispatch(db.Message{ID: 3, On: "retried", Tag: "deploys"})
+		<-r.payloads
+		waitFor(t, func() bool {
+			log, _ := db.ListDeliveries(h.ID)
+			return len(log) == 3
+		})
+		log, _ := db.ListDeliveries(h.ID)
+		got := log[len(log)-1].Status
+		want := http.StatusOK
+		if got != want {
+			t.Logf("expected: %v, got: %v", want, got)
+			t.Fatal("last attempt is expected to succeed")
+		}
+	})
+	t.Run("dead letter", func(t *testing.T) {
+		_, h := setup(t, "dead", 10)
+		d.Dispatch(db.Message{ID: 4, On: "dead", Tag: "deploys"})
+		waitFor(t, func() bool {
+			dead, _ := db.ListDeadLetters(h.ID)
+			return len(dead) == 1
+		})
+		log, _ := db.ListDeliveries(h.ID)
+		got := len(log)
+		want := d.MaxAttempts
+		if got != want {
+			t.Logf("expected: %v, got: %v", want, got)
+			t.Fatal("incorrect number of delivery attempts")
+		}
+	})
+	t.Run("deleted", func(t *testing.T) {
+		max := db.MaxDeadLetters
+		db.MaxDeadLetters = 1
+		t.Cleanup(func() { db.MaxDeadLetters = max })
+		_, h := setup(t, "deleted", 10)
+		d.Dispatch(db.Message{ID: 5, On: "deleted", Tag: "deploys"})
+		d.Dispatch(db.Message{ID: 6, On: "deleted", Tag: "deploys"})
+		waitFor(t, func() bool {
+			log, _ := db.ListDeliveries(h.ID)
+			return len(log) == 2*d.MaxAttempts
+		})
+		waitFor(t, func() bool {
+			dead, _ := db.ListDeadLetters(h.ID)
+			return len(dead) == 1
+		})
+		time.Sleep(50 * time.Millisecond)
+		dead, _ := db.ListDeadLetters(h.ID)
+		if len(dead) != 1 {
+			t.Logf("expected: %v, got: %v", 1, len(dead))
+			t.Fatal("dead letters are expected to be capped per webhook")
+		}
+		_, err := db.DeleteWebhook(h.On, h.ID)
+		handleFatal(err, t)
+		log, _ := db.ListDeliveries(h.ID)
+		dead, _ = db.ListDeadLetters(h.ID)
+		if len(log) != 0 || len(dead) != 0 {
+			t.Fatal("deliveries of a deleted webhook are not expected to be kept")
+		}
+	})
+}
+
+func TestPrivateAddress(t *testing.T) {
+	d := &Dispatcher{}
+	for _, raw := range []string{
+		"http://localhost:8080/hook",
+		"http://api.localhost/hook",
+		"http://127.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.8/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		u, err := url.Parse(raw)
+		handleFatal(err, t)
+		if err = d.CheckURL(u); !errors.Is(err, ErrPrivateAddress) {
+			t.Logf("expected: %v, got: %v", ErrPrivateAddress, err)
+			t.Fatalf("webhook on %s is expected to be refused", raw)
+		}
+	}
+	u, err := url.Parse("https://hooks.example.com/devspace")
+	handleFatal(err, t)
+	handleFatal(d.CheckURL(u), t)
+
+	// names resolving to private addresses are refused on delivery
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+	c := &http.Client{Transport: PublicTransport()}
+	_, err = c.Get(strings.Replace(srv.URL, "127.0.0.1", "localhost", 1))
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Logf("expected: %v, got: %v", ErrPrivateAddress, err)
+		t.Fatal("delivery to a private address is expected to be refused")
+	}
+}
+
+func handleFatal(e error, i interface{ Fatal(args ...any) }) {
+	if e != nil {
+		i.Fatal(e)
+	}
+}
@@ -0,0 +1,22 @@
+// Package openapi embeds the OpenAPI 3 specification of the
+// devspace api server.
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Spec is the OpenAPI document describing every route of the
+// api server. It must be kept in sync with the routes; the
+// contract tests of the server check that it is.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Handler serves the OpenAPI document.
+func Handler(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, Spec)
+}
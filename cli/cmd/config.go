@@ -0,0 +1,43 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"github.com/bingxueshuang/devspaces/cli/config"
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the configuration profiles",
+	Long: `Manage the configuration profiles.
+
+A profile holds the server url, username, devspace, login token
+file and key files used by default by the other commands. The
+profile is selected with --profile, otherwise the current profile
+is used. Profiles are stored in devspaces/config.yaml under the
+user config directory.`,
+	// profiles are edited, not applied, by the config commands
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+// readConfig loads the configuration file and returns it
+// along with its location.
+func readConfig() (*config.Config, string, error) {
+	path, err := config.Path()
+	if err != nil {
+		return nil, "", err
+	}
+	conf, err := config.Load(path)
+	return conf, path, err
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/bingxueshuang/devspaces/cli/config"
+	"github.com/spf13/cobra"
+)
+
+// configGetCmd represents the configGet command
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Show the settings of a profile",
+	Long: `Show the settings of a profile.
+
+Print the value of the setting key, or every setting
+of the profile when no key is given.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: config.Keys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// flags
+		name, err := cmd.Flags().GetString("profile")
+		if err != nil {
+			return err
+		}
+
+		// input
+		conf, _, err := readConfig()
+		if err != nil {
+			return err
+		}
+		p := conf.Profile(name)
+		if p == nil {
+			if name != "" {
+				return fmt.Errorf("profile %q not found", name)
+			}
+			p = new(config.Profile)
+		}
+
+		// output
+		if len(args) == 1 {
+			value, err := p.Get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		}
+		for _, key := range config.Keys {
+			value, _ := p.Get(key)
+			if value != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", key, value)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/bingxueshuang/devspaces/cli/config"
+	"github.com/spf13/cobra"
+)
+
+// configSetCmd represents the configSet command
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a setting of a profile",
+	Long: `Set a setting of a profile.
+
+The profile is created if it does not exist. An empty value
+unsets the setting. File locations are stored as absolute paths.
+
+Settings: server, username, devspace, token, skey, pkey, server-key,
+ca-cert, client-cert, client-key
+
+The server-key is the fingerprint of the public key of the server,
+pinned on first use. Unset it to pin a replaced server key anew.
+
+The ca-cert holds the certificates trusted to serve the server over
+TLS, such as its self-signed development certificate. The client-cert
+and client-key authenticate the user by a client certificate, in place
+of the login token, if the server accepts it.`,
+	Args:      cobra.ExactArgs(2),
+	ValidArgs: config.Keys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// flags
+		name, err := cmd.Flags().GetString("profile")
+		if err != nil {
+			return err
+		}
+		key, value := args[0], args[1]
+
+		// input
+		conf, path, err := readConfig()
+		if err != nil {
+			return err
+		}
+		if config.FileKeys[key] && value != "" {
+			value, err = filepath.Abs(value)
+			if err != nil {
+				return err
+			}
+		}
+
+		// core
+		err = conf.Ensure(name).Set(key, value)
+		if err != nil {
+			return err
+		}
+
+		// output
+		return conf.Save(path)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// configUseCmd represents the configUse command
+var configUseCmd = &cobra.Command{
+	Use:   "use-profile [name]",
+	Short: "Select the current profile",
+	Long: `Select the current profile.
+
+Make the named profile the one used when --profile is not
+given. Without a name, list the profiles and mark the current one.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// input
+		conf, path, err := readConfig()
+		if err != nil {
+			return err
+		}
+
+		// output
+		if len(args) == 0 {
+			current := conf.Name("")
+			for _, name := range conf.Names() {
+				mark := " "
+				if name == current {
+					mark = "*"
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), mark, name)
+			}
+			return nil
+		}
+
+		// core
+		name := args[0]
+		if conf.Profiles[name] == nil {
+			return fmt.Errorf("profile %q not found, create it with 'dev config set --profile %s'", name, name)
+		}
+		conf.Current = name
+		return conf.Save(path)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configUseCmd)
+}
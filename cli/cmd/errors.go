@@ -0,0 +1,67 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bingxueshuang/devspaces/client"
+)
+
+// friendlyErrors explains the api error codes to the user.
+var friendlyErrors = map[string]string{
+	client.CodeInvalidBody:        "the server could not understand the request",
+	client.CodeMissingFields:      "the request is missing required fields",
+	client.CodeValidation:         "the request contains an invalid value",
+	client.CodeInvalidParameter:   "the request contains an invalid parameter",
+	client.CodeInvalidPubkey:      "the public key is not valid",
+	client.CodeInvalidTrapdoor:    "the trapdoor is not valid, generate it again with 'dev trapdoor'",
+	client.CodeInvalidKeyword:     "the encrypted keyword is not valid, generate it again with 'dev peks'",
+	client.CodeInvalidData:        "the message data is not valid",
+	client.CodeInvalidAttachment:  "the attachment was not uploaded to the devspace",
+	client.CodeInvalidSignature:   "the message signature is missing or invalid, sign it with your key using --skey",
+	client.CodeInvalidCredentials: "wrong username or password",
+	client.CodeUnauthorized:       "login token is missing or expired, login again with 'dev login'",
+	client.CodeForbidden:          "you do not own this devspace",
+	client.CodeNotFound:           "the requested resource does not exist",
+	client.CodeUserNotFound:       "no such user",
+	client.CodeSpaceNotFound:      "no such devspace",
+	client.CodeMessageNotFound:    "no such message",
+	client.CodeWebhookNotFound:    "no such webhook",
+	client.CodeBlobNotFound:       "no such attachment",
+	client.CodeUserExists:         "the username is already taken",
+	client.CodeSpaceExists:        "a devspace with this name already exists",
+	client.CodeTagExists:          "a tag with this name already exists",
+	client.CodeTooLarge:           "the request is too large, send big payloads as attachments",
+	client.CodeRateLimited:        "the server is rate limiting requests, try again later",
+	client.CodeInternal:           "the server failed to process the request, try again later",
+}
+
+// explain replaces the message of an api error in err
+// with an explanation of its code for the user, and tells
+// how to trust a replaced log key of the server.
+func explain(err error) string {
+	if errors.Is(err, client.ErrLogKey) {
+		dir, dirErr := pinsDir()
+		if dirErr != nil {
+			return err.Error()
+		}
+		return err.Error() + "; if the server replaced its log key, remove its pinned keys from " + dir + " to trust the new log"
+	}
+	var e *client.Error
+	if !errors.As(err, &e) {
+		return err.Error()
+	}
+	msg, ok := friendlyErrors[e.Code]
+	if !ok {
+		return err.Error()
+	}
+	if e.Detail != "" {
+		msg += " (" + e.Detail + ")"
+	}
+	return strings.Replace(err.Error(), e.Error(), msg, 1)
+}
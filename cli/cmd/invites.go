@@ -8,10 +8,9 @@ package cmd
 import (
 	"encoding/json"
 	"errors"
+
 	"github.com/bingxueshuang/devspaces/cli/keyio"
 	"github.com/spf13/cobra"
-	"net/http"
-	"net/url"
 )
 
 // invitesCmd represents the invites command
@@ -19,7 +18,7 @@ var invitesCmd = &cobra.Command{
 	Use:       "invites",
 	Short:     "List all collaboration invites",
 	Long:      `List all collaboration invites.`,
-	Args:      cobra.ExactArgs(1),
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
@@ -27,7 +26,7 @@ var invitesCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		server := args[0]
+		server := serverArg(args)
 
 		// input
 		if server == "" {
@@ -39,36 +38,13 @@ var invitesCmd = &cobra.Command{
 		}
 
 		// core
-		client := new(http.Client)
-		serverURL, err := url.JoinPath(server, "/dashboard")
-		if err != nil {
-			return err
-		}
-		req, err := http.NewRequest("GET", serverURL, nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+string(token))
-		res, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
+		invites, err := newClient(server, token).Invites(cmd.Context())
 		if err != nil {
 			return err
 		}
 
 		// output
-		if res.StatusCode != http.StatusOK {
-			return errors.New(res.Status)
-		}
-		devs, ok := data.Data.([]any)
-		if !ok {
-			return errors.New("invalid json response")
-		}
-		err = json.NewEncoder(cmd.OutOrStdout()).Encode(devs)
+		err = json.NewEncoder(cmd.OutOrStdout()).Encode(invites)
 		return err
 	},
 }
@@ -76,4 +52,5 @@ var invitesCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(invitesCmd)
 	invitesCmd.Flags().StringP("token", "k", "", "login token")
+	bindProfile(invitesCmd.Flags(), "token", "token")
 }
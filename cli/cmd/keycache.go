@@ -0,0 +1,124 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/bingxueshuang/devspaces/core"
+)
+
+// keyCacheTTL is how long fetched public keys are reused. It is well
+// within the window during which the previous key of a rotated devspace
+// keeps routing messages.
+const keyCacheTTL = time.Hour
+
+type cachedKey struct {
+	Pubkey  string    `json:"pubkey"`
+	Scheme  string    `json:"scheme,omitempty"`
+	Fetched time.Time `json:"fetched"`
+}
+
+func (k *cachedKey) fresh() bool {
+	return k != nil && time.Since(k.Fetched) < keyCacheTTL
+}
+
+// keyCache keeps the public keys fetched from a server across
+// invocations, in a file under the user cache directory. The
+// cache is best effort: a cache which cannot be read or written
+// only leads to the keys being fetched again.
+type keyCache struct {
+	path   string
+	server string
+	Server *cachedKey            `json:"server,omitempty"`
+	Spaces map[string]*cachedKey `json:"spaces,omitempty"`
+}
+
+// loadKeyCache reads the cached public keys of the server.
+func loadKeyCache(server string) *keyCache {
+	cache := &keyCache{server: server, Spaces: make(map[string]*cachedKey)}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return cache
+	}
+	cache.path = filepath.Join(dir, "devspaces", "keys", url.PathEscape(normalizeServer(server))+".json")
+	data, err := os.ReadFile(cache.path)
+	if err == nil && json.Unmarshal(data, cache) == nil && cache.Spaces == nil {
+		cache.Spaces = make(map[string]*cachedKey)
+	}
+	return cache
+}
+
+func (k *keyCache) save() {
+	if k.path == "" {
+		return
+	}
+	data, err := json.Marshal(k)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(k.path), 0700) == nil {
+		_ = os.WriteFile(k.path, data, 0600)
+	}
+}
+
+// serverPubkey returns the public key of the server, fetching
+// it when it is not cached. Cached keys are checked against the
+// key pinned for the profile or the url as well.
+func (k *keyCache) serverPubkey(ctx context.Context, c *client.Client) (*core.PKeyServer, error) {
+	pk := new(core.PKeyServer)
+	if k.Server.fresh() && fromHex(pk, k.Server.Pubkey) == nil {
+		if err := checkServerKey(k.server, pk); err != nil {
+			return nil, err
+		}
+		return pk, nil
+	}
+	pk, err := serverPubkey(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	k.Server = &cachedKey{Pubkey: hex.EncodeToString(pk.Bytes()), Fetched: time.Now()}
+	return pk, nil
+}
+
+// spacePubkey returns the public key and the scheme of the
+// devspace, fetching them when they are not cached.
+func (k *keyCache) spacePubkey(ctx context.Context, c *client.Client, space string) (*core.PKey, string, error) {
+	pk := new(core.PKey)
+	cached := k.Spaces[space]
+	if cached.fresh() && cached.Scheme != "" && fromHex(pk, cached.Pubkey) == nil {
+		return pk, cached.Scheme, nil
+	}
+	key, err := c.SpaceKey(ctx, space)
+	if err != nil {
+		return nil, "", err
+	}
+	err = c.VerifyKey(ctx, client.Binding{Kind: core.BindingSpace, Name: space, Pubkey: key.Pubkey, Epoch: key.Epoch})
+	if err != nil {
+		return nil, "", err
+	}
+	err = pk.FromBytes(key.Pubkey)
+	if err != nil {
+		return nil, "", err
+	}
+	k.Spaces[space] = &cachedKey{Pubkey: hex.EncodeToString(key.Pubkey), Scheme: key.Scheme, Fetched: time.Now()}
+	return pk, key.Scheme, nil
+}
+
+func fromHex(key core.EllipticKey, s string) error {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return key.FromBytes(data)
+}
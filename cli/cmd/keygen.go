@@ -20,6 +20,11 @@ var keygenCmd = &cobra.Command{
 
 Create a new user public key and private key pair
 and output the private key to the user.
+
+The key pair is generated on the given curve, one of
+bn254 or bls12-381. Keys, ciphertexts and trapdoors of
+distinct curves never work together, so the curve must
+match the one of the devspace server.
 `,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -32,12 +37,23 @@ and output the private key to the user.
 		if err != nil {
 			return err
 		}
+		curveFlag, err := cmd.Flags().GetString("curve")
+		if err != nil {
+			return err
+		}
+
+		// input
+		curve, err := core.CurveByName(curveFlag)
+		if err != nil {
+			return err
+		}
 
 		// core logic
-		sk, pk, err := core.KeyGen()
+		sk, pk, err := core.KeyGenCurve(curve)
 		if err != nil {
 			return err
 		}
+		defer sk.Destroy()
 
 		// output
 		err = keyio.WriteString(hex.EncodeToString(sk.Bytes()), skFlag, true)
@@ -58,4 +74,5 @@ func init() {
 
 	keygenCmd.Flags().StringP("skey", "s", "", "file to output secret key")
 	keygenCmd.Flags().StringP("pkey", "p", "", "file to output public key")
+	keygenCmd.Flags().String("curve", core.DefaultCurve.String(), "elliptic curve of the key pair")
 }
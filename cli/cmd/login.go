@@ -6,13 +6,11 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
+
 	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
 	"github.com/spf13/cobra"
-	"net/http"
-	"net/url"
 )
 
 // loginCmd represents the login command
@@ -23,7 +21,7 @@ var loginCmd = &cobra.Command{
 
 Take the username and password of the user and login to
 the devspace api server`,
-	Args:      cobra.ExactArgs(1),
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
@@ -39,7 +37,7 @@ the devspace api server`,
 		if err != nil {
 			return err
 		}
-		server := args[0]
+		server := serverArg(args)
 
 		// input
 		if server == "" {
@@ -56,48 +54,16 @@ the devspace api server`,
 		}
 
 		// core logic
-		client := http.Client{}
-		buf := new(bytes.Buffer)
-		err = json.NewEncoder(buf).Encode(map[string]any{
-			"username": username,
-			"password": password,
+		token, err := newClient(server, nil).Login(cmd.Context(), client.Credentials{
+			Username: username,
+			Password: password,
 		})
 		if err != nil {
 			return err
 		}
-		serverURL, err := url.JoinPath(server, "/auth/login")
-		if err != nil {
-			return err
-		}
-		req, err := http.NewRequest("POST", serverURL, buf)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		res, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
-		if err != nil {
-			return err
-		}
 
 		// output
-		code := res.StatusCode
-		if code != http.StatusOK {
-			return errors.New(http.StatusText(code))
-		}
-		datamap, ok := data.Data.(map[string]any)
-		if !ok {
-			return errors.New("invalid json response")
-		}
-		token, ok := datamap["token"].(string)
-		if !ok {
-			return errors.New("invalid json response")
-		}
-		err = keyio.WriteString(token, oFlag, true)
+		err = keyio.WriteString(token.Token, oFlag, true)
 		return err
 	},
 }
@@ -108,5 +74,7 @@ func init() {
 	loginCmd.Flags().StringP("username", "u", "", "Username for Signup")
 	loginCmd.Flags().StringP("password", "p", "", "Password for Signup")
 	loginCmd.Flags().StringP("output", "o", "", "file to output login token")
+	bindProfile(loginCmd.Flags(), "username", "username")
+	bindProfile(loginCmd.Flags(), "output", "token")
 	_ = loginCmd.MarkFlagRequired("username")
 }
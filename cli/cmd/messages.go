@@ -0,0 +1,31 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// messagesCmd represents the messages command
+var messagesCmd = &cobra.Command{
+	Use:   "messages",
+	Short: "Manage messages of a devspace",
+	Long: `Manage messages of a devspace.
+
+Delete messages received on a devspace or mark them
+as read or unread.`,
+}
+
+func init() {
+	rootCmd.AddCommand(messagesCmd)
+
+	messagesCmd.PersistentFlags().StringP("devspace", "d", "", "devspace of the messages")
+	messagesCmd.PersistentFlags().StringP("token", "k", "", "login token")
+	bindProfile(messagesCmd.PersistentFlags(), "devspace", "devspace")
+	bindProfile(messagesCmd.PersistentFlags(), "token", "token")
+	_ = messagesCmd.MarkPersistentFlagRequired("devspace")
+	_ = messagesCmd.MarkPersistentFlagRequired("token")
+}
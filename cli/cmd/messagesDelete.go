@@ -0,0 +1,104 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/spf13/cobra"
+)
+
+// updateMessages applies update to each of the message
+// ids in the devspace.
+func updateMessages(cmd *cobra.Command, server string, update func(c *client.Client, devspace string, id int64) error) error {
+	// flags
+	devspace, err := cmd.Flags().GetString("devspace")
+	if err != nil {
+		return err
+	}
+	tokenFlag, err := cmd.Flags().GetString("token")
+	if err != nil {
+		return err
+	}
+	ids, err := cmd.Flags().GetInt64Slice("id")
+	if err != nil {
+		return err
+	}
+
+	// input
+	if server == "" {
+		return errors.New("server url not supplied")
+	}
+	if len(ids) == 0 {
+		return errors.New("no message id supplied")
+	}
+	token, err := keyio.ReadFile(tokenFlag, false)
+	if err != nil {
+		return err
+	}
+
+	// core
+	c := newClient(server, token)
+	for _, id := range ids {
+		err = update(c, devspace, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messagesDeleteCmd represents the messagesDelete command
+var messagesDeleteCmd = &cobra.Command{
+	Use:       "delete",
+	Short:     "Delete messages of a devspace",
+	Long:      `Delete messages of a devspace.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateMessages(cmd, serverArg(args), func(c *client.Client, devspace string, id int64) error {
+			return c.DeleteMessage(cmd.Context(), devspace, id)
+		})
+	},
+}
+
+// messagesReadCmd represents the messagesRead command
+var messagesReadCmd = &cobra.Command{
+	Use:       "read",
+	Short:     "Mark messages of a devspace as read",
+	Long:      `Mark messages of a devspace as read.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateMessages(cmd, serverArg(args), func(c *client.Client, devspace string, id int64) error {
+			return c.MarkMessage(cmd.Context(), devspace, id, true)
+		})
+	},
+}
+
+// messagesUnreadCmd represents the messagesUnread command
+var messagesUnreadCmd = &cobra.Command{
+	Use:       "unread",
+	Short:     "Mark messages of a devspace as unread",
+	Long:      `Mark messages of a devspace as unread.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateMessages(cmd, serverArg(args), func(c *client.Client, devspace string, id int64) error {
+			return c.MarkMessage(cmd.Context(), devspace, id, false)
+		})
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{messagesDeleteCmd, messagesReadCmd, messagesUnreadCmd} {
+		messagesCmd.AddCommand(c)
+		c.Flags().Int64SliceP("id", "i", nil, "ids of the messages")
+		_ = c.MarkFlagRequired("id")
+	}
+}
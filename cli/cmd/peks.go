@@ -19,7 +19,15 @@ var peksCmd = &cobra.Command{
 	Long: `Get Public key searchable encryption of provided keyword.
 
 Take keyword, sender private key, receiver public key and server public key
-as input and output the public key searchable encryption of the keyword.`,
+as input and output the public key searchable encryption of the keyword.
+
+With --shared, the keyword is encrypted for devspaces of the shared
+scheme, which takes no sender private key.
+
+The keyword is normalized, case folded and trimmed before it is
+encrypted. With --stem, english words are stemmed as well, and the
+trapdoor has to be computed with --stem to match. Keywords are
+hashed apart on every devspace, given by --devspace.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
 		oFlag, err := cmd.Flags().GetString("output")
@@ -58,12 +66,27 @@ as input and output the public key searchable encryption of the keyword.`,
 		if err != nil {
 			return err
 		}
+		shared, err := cmd.Flags().GetBool("shared")
+		if err != nil {
+			return err
+		}
+		stem, err := cmd.Flags().GetBool("stem")
+		if err != nil {
+			return err
+		}
+		devspace, err := cmd.Flags().GetString("devspace")
+		if err != nil {
+			return err
+		}
 
 		// input
 		sk := new(core.SKey)
-		err = keyio.ReadKey(sk, skFlag, skHex, false)
-		if err != nil {
-			return err
+		defer sk.Destroy()
+		if !shared {
+			err = keyio.ReadKey(sk, skFlag, skHex, false)
+			if err != nil {
+				return err
+			}
 		}
 		pk := new(core.PKey)
 		err = keyio.ReadKey(pk, pkFlag, pkHex, false)
@@ -75,6 +98,10 @@ as input and output the public key searchable encryption of the keyword.`,
 		if err != nil {
 			return err
 		}
+		err = checkServerKey("", server)
+		if err != nil {
+			return err
+		}
 		if keyword == "" {
 			kwBytes, err := keyio.ReadFile(kwFlag, true)
 			if err != nil {
@@ -84,7 +111,13 @@ as input and output the public key searchable encryption of the keyword.`,
 		}
 
 		// core logic
-		peks, err := core.PEKS([]byte(keyword), server, pk, sk)
+		scheme := core.Scheme{Canon: canon(stem)}
+		var peks []byte
+		if shared {
+			peks, err = scheme.PEKSShared(devspace, []byte(keyword), server, pk)
+		} else {
+			peks, err = scheme.PEKS(devspace, []byte(keyword), server, pk, sk)
+		}
 		if err != nil {
 			return err
 		}
@@ -99,6 +132,14 @@ as input and output the public key searchable encryption of the keyword.`,
 	},
 }
 
+// canon returns the keyword canonicalization of the --stem flag.
+func canon(stem bool) *core.Canon {
+	if stem {
+		return core.CanonV1Stem
+	}
+	return core.CanonV1
+}
+
 func init() {
 	rootCmd.AddCommand(peksCmd)
 
@@ -115,7 +156,12 @@ func init() {
 	peksCmd.Flags().StringP("file", "f", "", "keyword file")
 	_ = peksCmd.MarkFlagFilename("file")
 	peksCmd.Flags().StringP("keyword", "k", "", "keyword text")
+	peksCmd.Flags().Bool("shared", false, "encrypt for the shared scheme")
+	peksCmd.Flags().Bool("stem", false, "stem the english words of the keyword")
+	peksCmd.Flags().StringP("devspace", "d", "", "devspace on which the keyword is sent")
 	peksCmd.MarkFlagsMutuallyExclusive("skey", "skey-hex")
 	peksCmd.MarkFlagsMutuallyExclusive("pkey", "pkey-hex")
 	peksCmd.MarkFlagsMutuallyExclusive("file", "keyword")
+	bindProfile(peksCmd.Flags(), "devspace", "devspace")
+	_ = peksCmd.MarkFlagRequired("devspace")
 }
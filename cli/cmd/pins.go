@@ -0,0 +1,191 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/bingxueshuang/devspaces/core"
+)
+
+// errServerKey is returned when the public key of the server does not
+// match the fingerprint pinned for the selected profile, or for the
+// url of the server.
+var errServerKey = errors.New("server key does not match the pinned key")
+
+// checkServerKey checks the public key of the server against the key
+// pinned for the selected profile, if the profile is of the server, or
+// else against the key pinned for the url of the server. Keys read from
+// files, with an empty server, are checked against the key pinned for
+// the profile. Fetched keys are pinned on first use.
+func checkServerKey(server string, pk *core.PKeyServer) error {
+	got := pk.Fingerprint()
+	if profileName != "" && (server == "" || normalizeServer(server) == normalizeServer(profile.Server)) {
+		return checkProfileKey(server, got)
+	}
+	if server == "" {
+		return nil
+	}
+	path, err := pinsFile(server)
+	if err != nil {
+		return err
+	}
+	pinned, err := os.ReadFile(path + ".key")
+	if err == nil {
+		if want := strings.TrimSpace(string(pinned)); got != want {
+			return fmt.Errorf("%w: the key of the server is %s, but %s is pinned for %s; remove %s if the server key was replaced",
+				errServerKey, got, want, normalizeServer(server), path+".key")
+		}
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err = os.WriteFile(path+".key", []byte(got+"\n"), 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Pinned the server key %s for %s\n", got, normalizeServer(server))
+	return nil
+}
+
+// checkProfileKey checks the fingerprint of the server key against
+// the key pinned for the selected profile, pinning it on first use.
+func checkProfileKey(server, got string) error {
+	if profile.ServerKey != "" {
+		if got != profile.ServerKey {
+			return fmt.Errorf("%w: the key of the server is %s, but %s is pinned for profile %q; unset its server-key setting if the server key was replaced",
+				errServerKey, got, profile.ServerKey, profileName)
+		}
+		return nil
+	}
+	if server == "" {
+		return nil
+	}
+	conf, path, err := readConfig()
+	if err != nil {
+		return err
+	}
+	err = conf.Ensure(profileName).Set("server-key", got)
+	if err != nil {
+		return err
+	}
+	if err = conf.Save(path); err != nil {
+		return err
+	}
+	profile.ServerKey = got
+	fmt.Fprintf(os.Stderr, "Pinned the server key %s for profile %q\n", got, profileName)
+	return nil
+}
+
+// normalizeServer returns the url of the server in a canonical form, so
+// that the spellings of a url share their pins: the scheme and the host
+// are lower cased, and default ports and trailing slashes are dropped.
+func normalizeServer(server string) string {
+	u, err := url.Parse(strings.TrimSpace(server))
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(server, "/")
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// serverPubkey fetches the public key of the server, checking
+// it against the key pinned for the selected profile.
+func serverPubkey(ctx context.Context, c *client.Client) (*core.PKeyServer, error) {
+	pk, err := c.ServerPubkey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkServerKey(c.Server, pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// blindPubkey fetches the blinding key of the server, checking it
+// against the key pinned for the selected profile.
+func blindPubkey(ctx context.Context, c *client.Client) (*core.PKeyBlind, error) {
+	if _, err := serverPubkey(ctx, c); err != nil {
+		return nil, err
+	}
+	return c.BlindPubkey(ctx)
+}
+
+// loadKeyLog returns the key log verifying the public keys fetched from
+// the server, with the keys pinned by earlier invocations. Unlike the
+// key cache, the pins are kept under the user configuration directory,
+// as losing them means trusting every key anew.
+func loadKeyLog(server string) *client.KeyLog {
+	l := &client.KeyLog{
+		Warn: func(msg string) {
+			fmt.Fprintln(os.Stderr, "Warning:", msg)
+		},
+	}
+	path, err := pinsFile(server)
+	if err != nil {
+		return l
+	}
+	path += ".json"
+	if data, err := os.ReadFile(path); err == nil {
+		if err = json.Unmarshal(data, &l.Pins); err != nil {
+			l.Pins = client.Pins{}
+			l.Warn(fmt.Sprintf("ignoring the unreadable pinned keys in %s: %v", path, err))
+		}
+	}
+	l.Save = func(p *client.Pins) error {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		return os.WriteFile(path, data, 0600)
+	}
+	return l
+}
+
+// pinsDir is the directory of the keys pinned from the key logs.
+func pinsDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "devspaces", "pins"), nil
+}
+
+// pinsFile is the path of the files of the keys pinned for the server,
+// without extension: the server key and the keys of its key log.
+func pinsFile(server string) (string, error) {
+	dir, err := pinsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, url.PathEscape(normalizeServer(server))), nil
+}
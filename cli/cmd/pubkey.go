@@ -6,11 +6,9 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
+	"context"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
-	"net/http"
-	"net/url"
 
 	"github.com/bingxueshuang/devspaces/cli/keyio"
 	"github.com/bingxueshuang/devspaces/core"
@@ -30,6 +28,7 @@ func pkFromSkey(cmd *cobra.Command) (core.EllipticKey, error) {
 
 	// input
 	sk := new(core.SKey)
+	defer sk.Destroy()
 	err = keyio.ReadKey(sk, skFile, skHex, true)
 	if err != nil {
 		return nil, err
@@ -41,47 +40,11 @@ func pkFromSkey(cmd *cobra.Command) (core.EllipticKey, error) {
 	return pk, err
 }
 
-func pkFromServer(srv string) (core.EllipticKey, error) {
-	// core
-	client := new(http.Client)
-	serverURL, err := url.JoinPath(srv, "/pubkey")
-	if err != nil {
-		return nil, err
-	}
-	req, err := http.NewRequest("GET", serverURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	data := new(Response)
-	err = json.NewDecoder(res.Body).Decode(data)
-	if err != nil {
-		return nil, err
-	}
-
-	// output
-	if res.StatusCode != http.StatusOK {
-		return nil, errors.New(res.Status)
+func pkFromServer(ctx context.Context, srv string, blind bool) (core.EllipticKey, error) {
+	if blind {
+		return blindPubkey(ctx, newClient(srv, nil))
 	}
-	pk, ok := data.Data.(map[string]any)
-	if !ok {
-		return nil, errors.New("invalid response")
-	}
-	pkHex, ok := pk["pubkey"].(string)
-	if !ok {
-		return nil, errors.New("invalid response")
-	}
-	pkey := new(core.PKeyServer)
-	pkBytes, err := hex.DecodeString(pkHex)
-	if err != nil {
-		return nil, err
-	}
-	err = pkey.FromBytes(pkBytes)
-	return pkey, err
+	return serverPubkey(ctx, newClient(srv, nil))
 }
 
 // pubkeyCmd represents the pubkey command
@@ -90,7 +53,15 @@ var pubkeyCmd = &cobra.Command{
 	Short: "Generate Public Key from a given Private key",
 	Long: `Generate Public Key from a given Private key
 
-Take user private key and output the corresponding public key`,
+Take user private key and output the corresponding public key.
+Given a server url instead, fetch the public key of the server,
+which is pinned for the profile of the server, or for its url
+without a profile of the server, on first use.
+With --blind, output the blinding key of the server instead,
+which computes trapdoors of the shared scheme.
+
+With --fingerprint, output the fingerprint of the public key,
+for comparing keys at a glance, e.g. SHA256:47DEQpj8HBSa+/TImW...`,
 	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -100,11 +71,22 @@ Take user private key and output the corresponding public key`,
 		if err != nil {
 			return err
 		}
+		fingerprint, err := cmd.Flags().GetBool("fingerprint")
+		if err != nil {
+			return err
+		}
+		blind, err := cmd.Flags().GetBool("blind")
+		if err != nil {
+			return err
+		}
 		switch len(args) {
 		case 0:
+			if blind {
+				return errors.New("blinding key is of a server, no server url supplied")
+			}
 			pk, err = pkFromSkey(cmd)
 		case 1:
-			pk, err = pkFromServer(args[0])
+			pk, err = pkFromServer(cmd.Context(), args[0], blind)
 		default:
 			panic("accepts only one argument")
 		}
@@ -112,6 +94,9 @@ Take user private key and output the corresponding public key`,
 			return err
 		}
 		// output
+		if fingerprint {
+			return keyio.WriteString(pk.Fingerprint(), pkFile, true)
+		}
 		return keyio.WriteString(hex.EncodeToString(pk.Bytes()), pkFile, true)
 	},
 }
@@ -124,4 +109,6 @@ func init() {
 	_ = pubkeyCmd.MarkFlagFilename("skey") // error happens only when flag does not exist
 	pubkeyCmd.Flags().String("skey-hex", "", "hexadecimal user private key")
 	pubkeyCmd.MarkFlagsMutuallyExclusive("skey", "skey-hex")
+	pubkeyCmd.Flags().BoolP("fingerprint", "f", false, "output the fingerprint of the public key")
+	pubkeyCmd.Flags().Bool("blind", false, "output the blinding key of the server")
 }
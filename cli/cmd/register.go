@@ -6,15 +6,11 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
-	"bytes"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
+
 	"github.com/bingxueshuang/devspaces/cli/keyio"
 	"github.com/bingxueshuang/devspaces/core"
 	"github.com/spf13/cobra"
-	"net/http"
-	"net/url"
 )
 
 // registerCmd represents the register command
@@ -24,16 +20,20 @@ var registerCmd = &cobra.Command{
 	Long: `Sign up a user.
 
 Register a new user to the devspace server using
-username, password and public key`,
-	Args:      cobra.ExactArgs(1),
+username, password and the key pair of the user.
+
+The public key is derived from the secret key, which
+signs a nonce of the server to prove that the user
+holds it. The secret key never leaves the machine.`,
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
-		pkFlag, err := cmd.Flags().GetString("pkey")
+		skFlag, err := cmd.Flags().GetString("skey")
 		if err != nil {
 			return err
 		}
-		pkHex, err := cmd.Flags().GetString("pkey-hex")
+		skHex, err := cmd.Flags().GetString("skey-hex")
 		if err != nil {
 			return err
 		}
@@ -45,7 +45,7 @@ username, password and public key`,
 		if err != nil {
 			return err
 		}
-		server := args[0]
+		server := serverArg(args)
 
 		// input
 		if server == "" {
@@ -60,48 +60,18 @@ username, password and public key`,
 				return err
 			}
 		}
-		pk := new(core.PKey)
-		err = keyio.ReadKey(pk, pkFlag, pkHex, false)
-		if err != nil {
-			return err
-		}
-
-		// core logic
-		client := http.Client{}
-		buf := new(bytes.Buffer)
-		err = json.NewEncoder(buf).Encode(map[string]any{
-			"username": username,
-			"password": password,
-			"pubkey":   hex.EncodeToString(pk.Bytes()),
-		})
-		if err != nil {
-			return err
-		}
-		serverURL, err := url.JoinPath(server, "/auth/register")
-		if err != nil {
-			return err
-		}
-		req, err := http.NewRequest("POST", serverURL, buf)
-		if err != nil {
-			return err
+		if skFlag == "" && skHex == "" {
+			skFlag = profile.SecretKey
 		}
-		req.Header.Set("Content-Type", "application/json")
-		res, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
+		sk := new(core.SKey)
+		defer sk.Destroy()
+		err = keyio.ReadKey(sk, skFlag, skHex, false)
 		if err != nil {
 			return err
 		}
 
-		// output
-		code := res.StatusCode
-		if code != http.StatusOK {
-			return errors.New(http.StatusText(code))
-		}
-		return nil
+		// core logic
+		return newClient(server, nil).RegisterKey(cmd.Context(), username, password, sk)
 	},
 }
 
@@ -109,9 +79,10 @@ func init() {
 	rootCmd.AddCommand(registerCmd)
 
 	registerCmd.Flags().StringP("username", "u", "", "Username for Signup")
+	bindProfile(registerCmd.Flags(), "username", "username")
 	registerCmd.Flags().StringP("password", "p", "", "Password for Signup")
-	registerCmd.Flags().StringP("pkey", "k", "", "public key file")
-	_ = cobra.MarkFlagFilename(registerCmd.Flags(), "pkey")
-	registerCmd.Flags().StringP("pkey-hex", "x", "", "hexadecimal public key")
-	registerCmd.MarkFlagsMutuallyExclusive("pkey", "pkey-hex")
+	registerCmd.Flags().StringP("skey", "s", "", "secret key file")
+	_ = cobra.MarkFlagFilename(registerCmd.Flags(), "skey")
+	registerCmd.Flags().StringP("skey-hex", "x", "", "hexadecimal secret key")
+	registerCmd.MarkFlagsMutuallyExclusive("skey", "skey-hex")
 }
@@ -6,9 +6,14 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
+	"fmt"
+	"net/http"
 	"os"
 
+	"github.com/bingxueshuang/devspaces/cli/config"
+	"github.com/bingxueshuang/devspaces/client"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -21,6 +26,9 @@ Dev is a CLI tool for PEKS utilities for interaction with
 Devspace. Dev provides useful methods for key generation,
 encryption of data, create shared keys, ciphertext for
 given keyword and trapdoor generation.`,
+	// errors are printed by Execute to explain api errors
+	SilenceErrors:     true,
+	PersistentPreRunE: loadProfile,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -28,12 +36,91 @@ given keyword and trapdoor generation.`,
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		rootCmd.PrintErrln("Error:", explain(err))
 		os.Exit(1)
 	}
 }
 
-type Response struct {
-	Ok    bool `json:"ok"`
-	Data  any  `json:"data"`
-	Error any  `json:"error"`
+// newClient returns a client of the devspace api server
+// authorized with the login token, if it is not empty. The
+// public keys fetched by the client are verified against the
+// key log of the server. The TLS settings of the profile failing
+// to load fail its requests.
+func newClient(server string, token []byte) *client.Client {
+	var tokens client.TokenSource
+	if len(token) != 0 {
+		tokens = client.StaticToken(token)
+	}
+	c := client.New(server, tokens)
+	c.KeyLog = loadKeyLog(server)
+	hc, err := httpClient()
+	if err != nil {
+		hc = &http.Client{Transport: errTransport{err}}
+		c.Retries = 0
+	}
+	c.HTTPClient = hc
+	return c
+}
+
+// profile holds the defaults of the selected configuration profile,
+// whose name is profileName if it is read from the configuration file.
+var (
+	profile     = new(config.Profile)
+	profileName string
+)
+
+// profileKey annotates the flags defaulting to a profile setting.
+const profileKey = "devspaces_profile_key"
+
+// bindProfile makes the setting key of the selected profile
+// the default of the flag name.
+func bindProfile(flags *pflag.FlagSet, name, key string) {
+	_ = flags.SetAnnotation(name, profileKey, []string{key})
+}
+
+// loadProfile reads the selected profile from the configuration
+// file and sets the flags bound to it which were not provided.
+func loadProfile(cmd *cobra.Command, args []string) error {
+	name, err := cmd.Flags().GetString("profile")
+	if err != nil {
+		return err
+	}
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+	conf, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if p := conf.Profile(name); p != nil {
+		profile = p
+		profileName = conf.Name(name)
+	} else if name != "" {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		key, ok := f.Annotations[profileKey]
+		if !ok || f.Changed || err != nil {
+			return
+		}
+		value, _ := profile.Get(key[0])
+		if value != "" {
+			err = cmd.Flags().Set(f.Name, value)
+		}
+	})
+	return err
+}
+
+// serverArg returns the server url given as the argument,
+// defaulting to the server of the selected profile.
+func serverArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return profile.Server
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("profile", "", "configuration profile to use")
 }
@@ -47,6 +47,7 @@ can perform independently.`,
 
 		// input
 		sk := new(core.SKey)
+		defer sk.Destroy()
 		err = keyio.ReadKey(sk, skFlag, skHex, false)
 		if err != nil {
 			return err
@@ -23,5 +23,6 @@ func init() {
 	rootCmd.AddCommand(spaceCmd)
 
 	spaceCmd.PersistentFlags().StringP("token", "k", "", "login token")
+	bindProfile(spaceCmd.PersistentFlags(), "token", "token")
 	_ = spaceCmd.MarkPersistentFlagRequired("token")
 }
@@ -6,23 +6,26 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
-	"bytes"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
+	"fmt"
+
 	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
 	"github.com/bingxueshuang/devspaces/core"
 	"github.com/spf13/cobra"
-	"net/http"
-	"net/url"
 )
 
 // spaceCreateCmd represents the spaceCreate command
 var spaceCreateCmd = &cobra.Command{
-	Use:       "create",
-	Short:     "Create a new DevSpace",
-	Long:      `Create a new DevSpace.`,
-	Args:      cobra.ExactArgs(1),
+	Use:   "create",
+	Short: "Create a new DevSpace",
+	Long: `Create a new DevSpace.
+
+The keyword encryption scheme of the devspace is chosen with
+--scheme. Tags of the sender scheme match the keyword from a
+single sender, while tags of the shared scheme match it from
+any sender, who then signs its messages.`,
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
@@ -38,7 +41,11 @@ var spaceCreateCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		server := args[0]
+		scheme, err := cmd.Flags().GetString("scheme")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
 
 		// input
 		if server == "" {
@@ -47,6 +54,9 @@ var spaceCreateCmd = &cobra.Command{
 		if name == "" {
 			return errors.New("no devspace name provided")
 		}
+		if scheme != client.SchemeSender && scheme != client.SchemeShared {
+			return fmt.Errorf("unknown scheme %q", scheme)
+		}
 		pk := new(core.PKey)
 		err = keyio.ReadKey(pk, pubkey, "", true)
 		if err != nil {
@@ -58,37 +68,11 @@ var spaceCreateCmd = &cobra.Command{
 		}
 
 		// core
-		client := new(http.Client)
-		serverURL, err := url.JoinPath(server, "/space/")
-		buf := new(bytes.Buffer)
-		err = json.NewEncoder(buf).Encode(map[string]any{
-			"name":   name,
-			"pubkey": hex.EncodeToString(pk.Bytes()),
+		return newClient(server, token).CreateSpace(cmd.Context(), client.NewSpace{
+			Name:   name,
+			Pubkey: pk.Bytes(),
+			Scheme: scheme,
 		})
-		if err != nil {
-			return err
-		}
-		req, err := http.NewRequest("POST", serverURL, buf)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+string(token))
-		res, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
-		if err != nil {
-			return err
-		}
-
-		// output
-		if res.StatusCode != http.StatusOK {
-			return errors.New(res.Status)
-		}
-		return nil
 	},
 }
 
@@ -97,5 +81,6 @@ func init() {
 
 	spaceCreateCmd.Flags().StringP("name", "n", "", "name of the devspace")
 	spaceCreateCmd.Flags().StringP("pubkey", "p", "", "public key of the devspace")
+	spaceCreateCmd.Flags().String("scheme", client.SchemeSender, "keyword encryption scheme, sender or shared")
 	_ = spaceCreateCmd.MarkFlagRequired("name")
 }
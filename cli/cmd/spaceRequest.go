@@ -6,15 +6,12 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
-	"bytes"
-	"encoding/hex"
-	"encoding/json"
 	"errors"
+
 	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
 	"github.com/bingxueshuang/devspaces/core"
 	"github.com/spf13/cobra"
-	"net/http"
-	"net/url"
 )
 
 // spaceRequestCmd represents the spaceRequest command
@@ -24,7 +21,7 @@ var spaceRequestCmd = &cobra.Command{
 	Long: `Request for collaboration.
 
 Invite a user for collaboration on a devspace.`,
-	Args:      cobra.ExactArgs(1),
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
@@ -44,13 +41,14 @@ Invite a user for collaboration on a devspace.`,
 		if err != nil {
 			return err
 		}
-		server := args[0]
+		server := serverArg(args)
 
 		// input
 		if server == "" {
 			return errors.New("server url not provided")
 		}
 		sk := new(core.SKey)
+		defer sk.Destroy()
 		err = keyio.ReadKey(sk, skFlag, "", true)
 		if err != nil {
 			return err
@@ -61,37 +59,10 @@ Invite a user for collaboration on a devspace.`,
 		}
 
 		// core
-		client := new(http.Client)
-		serverURL, err := url.JoinPath(server, "/space/", devspace, "request")
-		buf := new(bytes.Buffer)
-		err = json.NewEncoder(buf).Encode(map[string]any{
-			"to":     username,
-			"secret": hex.EncodeToString(sk.Bytes()),
+		return newClient(server, token).Invite(cmd.Context(), devspace, client.Invitation{
+			To:     username,
+			Secret: sk.Bytes(),
 		})
-		if err != nil {
-			return err
-		}
-		req, err := http.NewRequest("POST", serverURL, buf)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+string(token))
-		res, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
-		if err != nil {
-			return err
-		}
-
-		// output
-		if res.StatusCode != http.StatusOK {
-			return errors.New(res.Status)
-		}
-		return nil
 	},
 }
 
@@ -101,6 +72,7 @@ func init() {
 	spaceRequestCmd.Flags().StringP("devspace", "d", "", "the devspace on which invite is requested")
 	spaceRequestCmd.Flags().StringP("username", "u", "", "username of user to be invited")
 	spaceRequestCmd.Flags().StringP("secret", "s", "", "secret key of the devspace")
+	bindProfile(spaceRequestCmd.Flags(), "devspace", "devspace")
 	_ = spaceRequestCmd.MarkFlagRequired("devspace")
 	_ = spaceRequestCmd.MarkFlagRequired("username")
 }
@@ -0,0 +1,91 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/spf13/cobra"
+)
+
+// spaceRetentionCmd represents the spaceRetention command
+var spaceRetentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Show or set the retention policy of a devspace",
+	Long: `Show or set the retention policy of a devspace.
+
+Messages older than the maximum age, or beyond the maximum
+count per tag, are periodically removed by the server. A zero
+value disables the respective limit. Without any flags, the
+current policy is shown.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// flags
+		tokenFlag, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return err
+		}
+		devspace, err := cmd.Flags().GetString("devspace")
+		if err != nil {
+			return err
+		}
+		maxAge, err := cmd.Flags().GetDuration("max-age")
+		if err != nil {
+			return err
+		}
+		maxCount, err := cmd.Flags().GetInt("max-count")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
+
+		// input
+		if server == "" {
+			return errors.New("server url not provided")
+		}
+		token, err := keyio.ReadFile(tokenFlag, false)
+		if err != nil {
+			return err
+		}
+		update := client.RetentionUpdate{}
+		if cmd.Flags().Changed("max-age") {
+			age := maxAge.String()
+			update.MaxAge = &age
+		}
+		if cmd.Flags().Changed("max-count") {
+			update.MaxCount = &maxCount
+		}
+
+		// core
+		c := newClient(server, token)
+		var policy *client.Retention
+		if update.MaxAge == nil && update.MaxCount == nil {
+			policy, err = c.Retention(cmd.Context(), devspace)
+		} else {
+			policy, err = c.SetRetention(cmd.Context(), devspace, update)
+		}
+		if err != nil {
+			return err
+		}
+
+		// output
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(policy)
+	},
+}
+
+func init() {
+	spaceCmd.AddCommand(spaceRetentionCmd)
+
+	spaceRetentionCmd.Flags().StringP("devspace", "d", "", "devspace whose retention policy is acted upon")
+	spaceRetentionCmd.Flags().Duration("max-age", 0, "maximum age of messages")
+	spaceRetentionCmd.Flags().Int("max-count", 0, "maximum number of messages per tag")
+	bindProfile(spaceRetentionCmd.Flags(), "devspace", "devspace")
+	_ = spaceRetentionCmd.MarkFlagRequired("devspace")
+}
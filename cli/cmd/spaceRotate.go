@@ -0,0 +1,230 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/bingxueshuang/devspaces/core"
+	"github.com/spf13/cobra"
+)
+
+// spaceRotateCmd represents the spaceRotate command
+var spaceRotateCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Rotate the key pair of a devspace",
+	Long: `Rotate the key pair of a devspace.
+
+Generate a new key pair for the devspace, re-issue the trapdoors
+of all the existing tags with the new secret key and update the
+devspace public key on the server. Messages encrypted under the
+previous key keep getting routed during a transition window, the
+default of the server unless given by --window. A leaked key is
+retired at once with --window 0s.
+
+The keyword of every existing tag must be supplied, for example:
+--keyword deploys=deploy. Every trapdoor of the tag is re-issued for
+the sender recorded with it, and with the keyword canonicalization it
+was created with, e.g. stemmed. Trapdoors recorded without a sender
+take the sender given by --sender, for example: --sender deploys=alice
+Tags of devspaces of the shared scheme take no sender.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// flags
+		tokenFlag, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return err
+		}
+		devspace, err := cmd.Flags().GetString("devspace")
+		if err != nil {
+			return err
+		}
+		keywords, err := cmd.Flags().GetStringToString("keyword")
+		if err != nil {
+			return err
+		}
+		senders, err := cmd.Flags().GetStringToString("sender")
+		if err != nil {
+			return err
+		}
+		skFlag, err := cmd.Flags().GetString("skey")
+		if err != nil {
+			return err
+		}
+		pkFlag, err := cmd.Flags().GetString("pkey")
+		if err != nil {
+			return err
+		}
+		window, err := cmd.Flags().GetDuration("window")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
+
+		// input
+		if server == "" {
+			return errors.New("server url not provided")
+		}
+		token, err := keyio.ReadFile(tokenFlag, false)
+		if err != nil {
+			return err
+		}
+		c := newClient(server, token)
+		current, scheme, err := currentTags(cmd.Context(), c, devspace)
+		if err != nil {
+			return err
+		}
+
+		srv, err := serverPubkey(cmd.Context(), c)
+		if err != nil {
+			return err
+		}
+
+		// core
+		sk, pk, err := core.KeyGenCurve(srv.Curve)
+		if err != nil {
+			return err
+		}
+		defer sk.Destroy()
+		tags := make([]client.NewTag, 0, len(current))
+		for _, t := range current {
+			word, ok := keywords[t.name]
+			if !ok {
+				return fmt.Errorf("no keyword provided for tag %q", t.name)
+			}
+			var td []byte
+			sender := t.sender
+			if scheme == client.SchemeShared {
+				td, err = c.TrapdoorShared(cmd.Context(), devspace, word, t.canon, sk)
+			} else {
+				if sender == "" {
+					sender, ok = senders[t.name]
+					if !ok {
+						return fmt.Errorf("no sender provided for tag %q", t.name)
+					}
+				}
+				td, err = c.Trapdoor(cmd.Context(), devspace, word, t.canon, sender, sk)
+			}
+			if err != nil {
+				return err
+			}
+			tags = append(tags, client.NewTag{
+				Name:     t.name,
+				Trapdoor: td,
+				Sender:   sender,
+			})
+		}
+		nonce, proof, err := c.Prove(cmd.Context(), devspace, sk)
+		if err != nil {
+			return err
+		}
+		// the new secret key is saved before the old one is replaced,
+		// and only moved over the key file once the server took it
+		var tmp string
+		if skFlag != "" {
+			tmp, err = keyio.WriteTemp(hex.EncodeToString(sk.Bytes()), skFlag)
+			if err != nil {
+				return err
+			}
+		}
+		rotation := client.Rotation{
+			Pubkey: pk.Bytes(),
+			Nonce:  nonce,
+			Proof:  proof,
+			Tags:   tags,
+		}
+		if cmd.Flags().Changed("window") {
+			rotation.Window = window.String()
+		}
+		_, err = c.RotateKey(cmd.Context(), devspace, rotation)
+		var rejected *client.Error
+		if err != nil && tmp != "" && errors.As(err, &rejected) {
+			_ = os.Remove(tmp)
+			return err
+		}
+		// the key may have been replaced without a response
+		if err != nil && tmp != "" {
+			return fmt.Errorf("%w; the new secret key is kept in %s", err, tmp)
+		}
+		if err != nil {
+			return err
+		}
+
+		// output
+		if tmp != "" {
+			err = os.Rename(tmp, skFlag)
+		} else {
+			err = keyio.WriteString(hex.EncodeToString(sk.Bytes()), "", true)
+		}
+		if err != nil {
+			return err
+		}
+		if pkFlag != "" {
+			return keyio.WriteString(hex.EncodeToString(pk.Bytes()), pkFlag, false)
+		}
+		return nil
+	},
+}
+
+// issuedTrapdoor is a trapdoor of a tag, by the sender it matches
+// and the pipeline that canonicalized its keyword.
+type issuedTrapdoor struct {
+	name   string
+	sender string
+	canon  *core.Canon
+}
+
+// currentTags returns the trapdoors issued under the current key
+// epoch of the devspace, once for every name, sender and pipeline,
+// and the scheme of the devspace.
+func currentTags(ctx context.Context, c *client.Client, devspace string) ([]issuedTrapdoor, string, error) {
+	key, err := c.SpaceKey(ctx, devspace)
+	if err != nil {
+		return nil, "", err
+	}
+	tags, err := c.ListTags(ctx, devspace)
+	if err != nil {
+		return nil, "", err
+	}
+	seen := make(map[issuedTrapdoor]bool)
+	current := make([]issuedTrapdoor, 0, len(tags))
+	for _, tag := range tags {
+		if tag.Epoch != key.Epoch {
+			continue
+		}
+		canon, err := core.CanonOf(tag.Trapdoor)
+		if err != nil {
+			return nil, "", fmt.Errorf("tag %q: %w", tag.Name, err)
+		}
+		t := issuedTrapdoor{name: tag.Name, sender: tag.Sender, canon: canon}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		current = append(current, t)
+	}
+	return current, key.Scheme, nil
+}
+
+func init() {
+	spaceCmd.AddCommand(spaceRotateCmd)
+
+	spaceRotateCmd.Flags().StringP("devspace", "d", "", "devspace whose key is rotated")
+	spaceRotateCmd.Flags().StringToStringP("keyword", "w", nil, "keyword of each tag as name=keyword")
+	spaceRotateCmd.Flags().StringToStringP("sender", "u", nil, "sender of each tag as name=username")
+	spaceRotateCmd.Flags().StringP("skey", "s", "", "file to output new secret key")
+	spaceRotateCmd.Flags().StringP("pkey", "p", "", "file to output new public key")
+	spaceRotateCmd.Flags().Duration("window", 0, "time the previous key keeps routing messages, 0s to retire it at once")
+	bindProfile(spaceRotateCmd.Flags(), "devspace", "devspace")
+	_ = spaceRotateCmd.MarkFlagRequired("devspace")
+}
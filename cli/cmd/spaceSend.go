@@ -6,18 +6,38 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
-	"bytes"
+	"context"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
-	"net/http"
-	"net/url"
+	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/bingxueshuang/devspaces/cli/keyio"
-
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/bingxueshuang/devspaces/core"
 	"github.com/spf13/cobra"
 )
 
+// uploadBlob streams the file to the blob store of the
+// devspace and returns the digest of its content.
+func uploadBlob(ctx context.Context, c *client.Client, devspace, filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	blob, err := c.UploadBlob(ctx, devspace, file, info.Size())
+	if err != nil {
+		return "", fmt.Errorf("upload %s: %w", filename, err)
+	}
+	return blob.Digest, nil
+}
+
 // spaceSendCmd represents the spaceSend command
 var spaceSendCmd = &cobra.Command{
 	Use:   "send",
@@ -25,8 +45,20 @@ var spaceSendCmd = &cobra.Command{
 	Long: `Send a message on devspace.
 
 This message gets automatically sorted by the server
-based on the encrypted keyword using PEKS.`,
-	Args:      cobra.ExactArgs(1),
+based on the encrypted keyword using PEKS. Files given
+with --attach are streamed to the server and attached
+to the message, which only the owner of the devspace and
+the collaborators invited to it can do.
+
+With --word, the keyword is encrypted with the secret key of
+the sender given by --skey, using the public keys of the server
+and of the devspace, which are fetched and cached for an hour.
+With --stem, its english words are stemmed, and the trapdoors of
+the tags have to be created with --stem to match.
+
+Messages are signed with the secret key given by --skey, which
+devspaces of the shared scheme require.`,
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
@@ -47,23 +79,58 @@ based on the encrypted keyword using PEKS.`,
 			return err
 		}
 		kwHex, err := cmd.Flags().GetString("keyword-hex")
-		server := args[0]
+		if err != nil {
+			return err
+		}
+		attach, err := cmd.Flags().GetStringSlice("attach")
+		if err != nil {
+			return err
+		}
+		word, err := cmd.Flags().GetString("word")
+		if err != nil {
+			return err
+		}
+		stem, err := cmd.Flags().GetBool("stem")
+		if err != nil {
+			return err
+		}
+		skFlag, err := cmd.Flags().GetString("skey")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
 
 		// input
 		if server == "" {
 			return errors.New("server url not provided")
 		}
-		msg, err := keyio.ReadFile(msgFlag, true)
-		if err != nil {
-			return err
+		// the message body is optional when sending attachments
+		var msg []byte
+		if msgFlag != "" || len(attach) == 0 {
+			msg, err = keyio.ReadFile(msgFlag, true)
+			if err != nil {
+				return err
+			}
 		}
-		keyword := kwHex
-		if keyword == "" {
+		if kwHex == "" && word == "" {
 			kw, err := keyio.ReadFile(kwFlag, false)
 			if err != nil {
 				return err
 			}
-			keyword = string(kw)
+			kwHex = string(kw)
+		}
+		keyword, err := hex.DecodeString(kwHex)
+		if err != nil {
+			return fmt.Errorf("invalid keyword: %w", err)
+		}
+		var sk *core.SKey
+		if word != "" || skFlag != "" {
+			sk = new(core.SKey)
+			defer sk.Destroy()
+			err = keyio.ReadKey(sk, skFlag, "", false)
+			if err != nil {
+				return err
+			}
 		}
 		token, err := keyio.ReadFile(tokenFlag, false)
 		if err != nil {
@@ -71,40 +138,53 @@ based on the encrypted keyword using PEKS.`,
 		}
 
 		// core
-		client := new(http.Client)
-		serverURL, err := url.JoinPath(server, "/space/", devspace, "send")
-		buf := new(bytes.Buffer)
-		err = json.NewEncoder(buf).Encode(map[string]any{
-			"data":    hex.EncodeToString(msg),
-			"keyword": keyword,
-		})
-		if err != nil {
-			return err
-		}
-		req, err := http.NewRequest("POST", serverURL, buf)
-		if err != nil {
-			return err
+		c := newClient(server, token)
+		if word != "" {
+			keyword, err = encryptWord(cmd.Context(), c, server, devspace, word, canon(stem), sk)
+			if err != nil {
+				return err
+			}
 		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+string(token))
-		res, err := client.Do(req)
-		if err != nil {
-			return err
+		attachments := make([]client.AttachmentRef, 0, len(attach))
+		for _, name := range attach {
+			digest, err := uploadBlob(cmd.Context(), c, devspace, name)
+			if err != nil {
+				return err
+			}
+			attachments = append(attachments, client.AttachmentRef{
+				Name:   filepath.Base(name),
+				Digest: digest,
+			})
 		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
-		if err != nil {
-			return err
+		m := client.NewMessage{
+			Data:        msg,
+			Keyword:     keyword,
+			Attachments: attachments,
 		}
-
-		// output
-		if res.StatusCode != http.StatusOK {
-			return errors.New(res.Status)
+		if sk != nil {
+			m.Sign(devspace, sk)
 		}
-		return nil
+		return c.Send(cmd.Context(), devspace, m)
 	},
 }
 
+// encryptWord computes the ciphertext of word, canonicalized by canon,
+// under the scheme of the devspace, with the public keys cached from
+// the server.
+func encryptWord(ctx context.Context, c *client.Client, server, devspace, word string, canon *core.Canon, sk *core.SKey) ([]byte, error) {
+	cache := loadKeyCache(server)
+	srv, err := cache.serverPubkey(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	pk, scheme, err := cache.spacePubkey(ctx, c, devspace)
+	if err != nil {
+		return nil, err
+	}
+	cache.save()
+	return client.EncryptScheme(scheme, devspace, []byte(word), canon, srv, pk, sk)
+}
+
 func init() {
 	spaceCmd.AddCommand(spaceSendCmd)
 
@@ -112,6 +192,14 @@ func init() {
 	spaceSendCmd.Flags().StringP("message", "m", "", "content of the message")
 	spaceSendCmd.Flags().StringP("keyword", "w", "", "encrypted keyword on message")
 	spaceSendCmd.Flags().StringP("keyword-hex", "x", "", "hexadecimal keyword")
-	spaceSendCmd.MarkFlagsMutuallyExclusive("keyword", "keyword-hex")
+	spaceSendCmd.Flags().StringSliceP("attach", "a", nil, "files to attach to the message")
+	_ = spaceSendCmd.MarkFlagFilename("attach")
+	spaceSendCmd.Flags().String("word", "", "plain keyword to encrypt and send")
+	spaceSendCmd.Flags().Bool("stem", false, "stem the english words of the plain keyword")
+	spaceSendCmd.Flags().StringP("skey", "s", "", "secret key file of the sender, signing the message")
+	_ = spaceSendCmd.MarkFlagFilename("skey")
+	spaceSendCmd.MarkFlagsMutuallyExclusive("keyword", "keyword-hex", "word")
+	bindProfile(spaceSendCmd.Flags(), "skey", "skey")
+	bindProfile(spaceSendCmd.Flags(), "devspace", "devspace")
 	_ = spaceSendCmd.MarkFlagRequired("devspace")
 }
@@ -24,6 +24,8 @@ func init() {
 
 	tagsCmd.PersistentFlags().StringP("devspace", "d", "", "The devspace whose tags are acted upon")
 	tagsCmd.PersistentFlags().StringP("token", "k", "", "login token")
+	bindProfile(tagsCmd.PersistentFlags(), "devspace", "devspace")
+	bindProfile(tagsCmd.PersistentFlags(), "token", "token")
 	_ = tagsCmd.MarkPersistentFlagRequired("devspace")
 	_ = tagsCmd.MarkPersistentFlagRequired("token")
 }
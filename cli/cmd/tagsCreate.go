@@ -6,13 +6,14 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"encoding/hex"
 	"errors"
-	"net/http"
-	"net/url"
+	"fmt"
 
 	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/bingxueshuang/devspaces/core"
 	"github.com/spf13/cobra"
 )
 
@@ -24,8 +25,20 @@ var tagsCreateCmd = &cobra.Command{
 
 Given a particular devspace and if the user has
 permission to create tags on it (the owner), then
-create a new tag and add it under the devspace.`,
-	Args:      cobra.ExactArgs(1),
+create a new tag and add it under the devspace.
+
+Instead of a precomputed trapdoor, the plain keyword may be given
+with --word, along with the secret key of the devspace. Trapdoors
+are then computed for the senders given by --from, or for every
+collaborator of the devspace with --from-all-members. Devspaces
+of the shared scheme take a single trapdoor matching every sender,
+and no senders are given. With --stem, the english words of the
+keyword are stemmed, and messages have to be sent with --stem to
+match.
+
+With --append, the trapdoors are added to an existing tag of
+the name, e.g. to re-issue a tag for the rotated key of a sender.`,
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
@@ -45,14 +58,55 @@ create a new tag and add it under the devspace.`,
 		if err != nil {
 			return err
 		}
-		server := args[0]
-
-		// input
-		tdBytes, err := keyio.ReadFile(tdFlag, true)
+		word, err := cmd.Flags().GetString("word")
+		if err != nil {
+			return err
+		}
+		stem, err := cmd.Flags().GetBool("stem")
+		if err != nil {
+			return err
+		}
+		from, err := cmd.Flags().GetStringSlice("from")
+		if err != nil {
+			return err
+		}
+		allMembers, err := cmd.Flags().GetBool("from-all-members")
+		if err != nil {
+			return err
+		}
+		skFlag, err := cmd.Flags().GetString("skey")
 		if err != nil {
 			return err
 		}
-		trapdoor := string(tdBytes)
+		extend, err := cmd.Flags().GetBool("append")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
+
+		// input
+		var tags []client.NewTag
+		sk := new(core.SKey)
+		defer sk.Destroy()
+		if word == "" {
+			if len(from) != 0 || allMembers {
+				return errors.New("senders are only given along with --word")
+			}
+			tdBytes, err := keyio.ReadFile(tdFlag, true)
+			if err != nil {
+				return err
+			}
+			trapdoor, err := hex.DecodeString(string(tdBytes))
+			if err != nil {
+				return fmt.Errorf("invalid trapdoor: %w", err)
+			}
+			tags = append(tags, client.NewTag{Trapdoor: trapdoor})
+		} else {
+			err = keyio.ReadKey(sk, skFlag, "", false)
+			if err != nil {
+				return err
+			}
+		}
 		if server == "" {
 			return errors.New("no server url supplied")
 		}
@@ -65,38 +119,114 @@ create a new tag and add it under the devspace.`,
 		}
 
 		// core
-		client := new(http.Client)
-		serverURL, err := url.JoinPath(server, "/space/", devspace)
-		buf := new(bytes.Buffer)
-		err = json.NewEncoder(buf).Encode(map[string]any{
-			"from":     name,
-			"trapdoor": trapdoor,
-		})
-		if err != nil {
-			return err
+		c := newClient(server, token)
+		if word != "" {
+			tags, err = wordTrapdoors(cmd.Context(), c, server, devspace, word, canon(stem), from, allMembers, sk)
+			if err != nil {
+				return err
+			}
 		}
-		req, err := http.NewRequest("POST", serverURL, buf)
+		// one trapdoor per sender, all of them under the same tag
+		for i, t := range tags {
+			t.Name = name
+			t.Append = extend || i > 0
+			err = c.CreateTag(cmd.Context(), devspace, t)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// wordTrapdoors computes the trapdoors of word, canonicalized by canon,
+// matching the messages of the senders, under the scheme of the devspace
+// whose secret key is sk. The returned tags carry the trapdoors and their
+// senders, but no name.
+func wordTrapdoors(ctx context.Context, c *client.Client, server, devspace, word string, canon *core.Canon, from []string, allMembers bool, sk *core.SKey) ([]client.NewTag, error) {
+	scheme := core.Scheme{Canon: canon}
+	cache := loadKeyCache(server)
+	defer cache.save()
+	_, spaceScheme, err := cache.spacePubkey(ctx, c, devspace)
+	if err != nil {
+		return nil, err
+	}
+	if spaceScheme == client.SchemeShared {
+		if len(from) != 0 || allMembers {
+			return nil, errors.New("tags of the shared scheme match every sender, senders are not supplied")
+		}
+		blind, err := blindPubkey(ctx, c)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+string(token))
-		res, err := client.Do(req)
+		td, err := scheme.TrapdoorShared(devspace, []byte(word), blind, sk)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
+		return []client.NewTag{{Trapdoor: td}}, nil
+	}
+	if len(from) == 0 && !allMembers {
+		return nil, errors.New("no sender supplied, use --from or --from-all-members")
+	}
+	names, senders, err := senderKeys(ctx, c, devspace, from, allMembers)
+	if err != nil {
+		return nil, err
+	}
+	srv, err := cache.serverPubkey(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]client.NewTag, 0, len(senders))
+	for i, pk := range senders {
+		td, err := scheme.Trapdoor(devspace, []byte(word), srv, pk, sk)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		tags = append(tags, client.NewTag{
+			Trapdoor: td,
+			Sender:   names[i],
+		})
+	}
+	return tags, nil
+}
 
-		// output
-		if res.StatusCode != http.StatusOK {
-			return errors.New(res.Status)
+// senderKeys fetches the usernames and public keys of the senders,
+// or of every collaborator of the devspace when all is set.
+func senderKeys(ctx context.Context, c *client.Client, devspace string, senders []string, all bool) ([]string, []*core.PKey, error) {
+	if all {
+		members, err := c.Members(ctx, devspace)
+		if err != nil {
+			return nil, nil, err
 		}
-		return nil
-	},
+		if len(members) == 0 {
+			return nil, nil, fmt.Errorf("devspace %q has no members", devspace)
+		}
+		names := make([]string, 0, len(members))
+		keys := make([]*core.PKey, 0, len(members))
+		for _, m := range members {
+			err = c.VerifyKey(ctx, client.Binding{Kind: core.BindingUser, Name: m.Username, Pubkey: m.Pubkey, Epoch: m.Version})
+			if err != nil {
+				return nil, nil, err
+			}
+			pk := new(core.PKey)
+			err = pk.FromBytes(m.Pubkey)
+			if err != nil {
+				return nil, nil, fmt.Errorf("public key of %s: %w", m.Username, err)
+			}
+			names = append(names, m.Username)
+			keys = append(keys, pk)
+		}
+		return names, keys, nil
+	}
+	keys := make([]*core.PKey, 0, len(senders))
+	for _, name := range senders {
+		pk, err := c.UserPubkey(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, pk)
+	}
+	return senders, keys, nil
 }
 
 func init() {
@@ -104,5 +234,14 @@ func init() {
 
 	tagsCreateCmd.Flags().StringP("name", "n", "", "name of the tag")
 	tagsCreateCmd.Flags().StringP("trapdoor", "t", "", "trapdoor for the tag")
+	tagsCreateCmd.Flags().StringP("word", "w", "", "plain keyword matched by the tag")
+	tagsCreateCmd.Flags().Bool("stem", false, "stem the english words of the plain keyword")
+	tagsCreateCmd.Flags().StringSliceP("from", "f", nil, "senders of the messages matched by the tag")
+	tagsCreateCmd.Flags().Bool("from-all-members", false, "match the messages of every collaborator")
+	tagsCreateCmd.Flags().StringP("skey", "s", "", "secret key file of the devspace")
+	tagsCreateCmd.Flags().Bool("append", false, "add the trapdoors to an existing tag")
+	_ = tagsCreateCmd.MarkFlagFilename("skey")
+	tagsCreateCmd.MarkFlagsMutuallyExclusive("trapdoor", "word")
+	tagsCreateCmd.MarkFlagsMutuallyExclusive("from", "from-all-members")
 	_ = tagsCreateCmd.MarkFlagRequired("name")
 }
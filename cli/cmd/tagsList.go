@@ -8,10 +8,9 @@ package cmd
 import (
 	"encoding/json"
 	"errors"
+
 	"github.com/bingxueshuang/devspaces/cli/keyio"
 	"github.com/spf13/cobra"
-	"net/http"
-	"net/url"
 )
 
 // tagsListCmd represents the tagsList command
@@ -21,7 +20,7 @@ var tagsListCmd = &cobra.Command{
 	Long: `List tags under devspace.
 
 Given a devspace, fetch and list the tags under it.`,
-	Args:      cobra.ExactArgs(1),
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
@@ -33,7 +32,7 @@ Given a devspace, fetch and list the tags under it.`,
 		if err != nil {
 			return err
 		}
-		server := args[0]
+		server := serverArg(args)
 
 		// input
 		if server == "" {
@@ -45,32 +44,12 @@ Given a devspace, fetch and list the tags under it.`,
 		}
 
 		// core
-		client := new(http.Client)
-		serverURL, err := url.JoinPath(server, "/space/", devspace)
-		if err != nil {
-			return err
-		}
-		req, err := http.NewRequest("GET", serverURL, nil)
-		if err != nil {
-			return err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+string(token))
-		res, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
+		tags, err := newClient(server, token).ListTags(cmd.Context(), devspace)
 		if err != nil {
 			return err
 		}
 
 		// output
-		tags, ok := data.Data.([]any)
-		if !ok {
-			return errors.New("invalid json response")
-		}
 		err = json.NewEncoder(cmd.OutOrStdout()).Encode(tags)
 		return err
 	},
@@ -6,23 +6,89 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
 	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
 	"github.com/spf13/cobra"
-	"net/http"
-	"net/url"
 )
 
+// parseTime parses either an RFC 3339 timestamp or
+// a duration relative to the current time (e.g. 24h).
+func parseTime(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// downloadAttachments saves the attachments of the messages
+// into dir, as files named <message id>-<attachment name>.
+func downloadAttachments(ctx context.Context, c *client.Client, devspace string, msgs []client.Message, dir string) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+	for _, m := range msgs {
+		for _, a := range m.Attachments {
+			filename := filepath.Join(dir, fmt.Sprintf("%d-%s", m.ID, filepath.Base(a.Name)))
+			err := downloadBlob(ctx, c, devspace, a.Digest, filename)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// downloadBlob streams the blob into filename,
+// checking that the content matches its digest.
+func downloadBlob(ctx context.Context, c *client.Client, devspace, digest, filename string) error {
+	body, err := c.DownloadBlob(ctx, devspace, digest)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", digest, err)
+	}
+	defer body.Close()
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, h), body)
+	if err != nil {
+		return err
+	}
+	if "sha256:"+hex.EncodeToString(h.Sum(nil)) != digest {
+		return fmt.Errorf("download %s: content does not match digest", digest)
+	}
+	return file.Close()
+}
+
 // tagsShowCmd represents the tagsShow command
 var tagsShowCmd = &cobra.Command{
-	Use:   "trapdoor",
-	Short: "Show messages under particular tag in the devspace",
+	Use:     "show",
+	Aliases: []string{"trapdoor"},
+	Short:   "Show messages under particular tag in the devspace",
 	Long: `Show messages under particular tag in the devspace.
 
-Given the devspace and access permission, fetch all the messages belonging
-to a particular tag.`,
-	Args:      cobra.ExactArgs(1),
+Given the devspace and access permission, fetch the messages belonging
+to a particular tag. Messages are listed a page at a time; when more
+messages are available, the cursor of the next page is printed on
+standard error and can be passed back using --cursor.
+
+Timestamps for --since and --until are either RFC 3339 or a duration
+relative to now, such as 24h.`,
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
@@ -38,46 +104,87 @@ to a particular tag.`,
 		if err != nil {
 			return err
 		}
-		server := args[0]
-
-		// input
-		if server == "" {
-			return errors.New("server url not supplied")
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return err
 		}
-		token, err := keyio.ReadFile(tokenFlag, false)
+		cursor, err := cmd.Flags().GetString("cursor")
 		if err != nil {
 			return err
 		}
-
-		// core
-		client := new(http.Client)
-		serverURL, err := url.JoinPath(server, "/space/", devspace, tag)
+		since, err := cmd.Flags().GetString("since")
+		if err != nil {
+			return err
+		}
+		until, err := cmd.Flags().GetString("until")
 		if err != nil {
 			return err
 		}
-		req, err := http.NewRequest("GET", serverURL, nil)
+		from, err := cmd.Flags().GetString("from")
 		if err != nil {
 			return err
 		}
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+string(token))
-		res, err := client.Do(req)
+		order, err := cmd.Flags().GetString("order")
 		if err != nil {
 			return err
 		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
+		unread, err := cmd.Flags().GetBool("unread")
+		if err != nil {
+			return err
+		}
+		download, err := cmd.Flags().GetString("download")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
+
+		// input
+		if server == "" {
+			return errors.New("server url not supplied")
+		}
+		token, err := keyio.ReadFile(tokenFlag, false)
+		if err != nil {
+			return err
+		}
+		query := &client.MessageQuery{
+			Limit:  limit,
+			Cursor: cursor,
+			From:   from,
+			Order:  order,
+			Unread: unread,
+		}
+		if since != "" {
+			query.Since, err = parseTime(since)
+			if err != nil {
+				return fmt.Errorf("invalid since: %w", err)
+			}
+		}
+		if until != "" {
+			query.Until, err = parseTime(until)
+			if err != nil {
+				return fmt.Errorf("invalid until: %w", err)
+			}
+		}
+
+		// core
+		c := newClient(server, token)
+		page, err := c.ListMessages(cmd.Context(), devspace, tag, query)
 		if err != nil {
 			return err
 		}
 
 		// output
-		tags, ok := data.Data.([]any)
-		if !ok {
-			return errors.New("invalid json response")
+		err = json.NewEncoder(cmd.OutOrStdout()).Encode(page.Messages)
+		if err != nil {
+			return err
 		}
-		err = json.NewEncoder(cmd.OutOrStdout()).Encode(tags)
-		return err
+		if page.Next != nil {
+			cmd.PrintErrln("next cursor:", *page.Next)
+		}
+		if download != "" {
+			return downloadAttachments(cmd.Context(), c, devspace, page.Messages, download)
+		}
+		return nil
 	},
 }
 
@@ -85,5 +192,14 @@ func init() {
 	tagsCmd.AddCommand(tagsShowCmd)
 
 	tagsShowCmd.Flags().StringP("tag", "t", "", "name of the tag")
+	tagsShowCmd.Flags().IntP("limit", "l", 0, "maximum number of messages to show")
+	tagsShowCmd.Flags().StringP("cursor", "c", "", "cursor of the page to show")
+	tagsShowCmd.Flags().String("since", "", "show messages received at or after this time")
+	tagsShowCmd.Flags().String("until", "", "show messages received before this time")
+	tagsShowCmd.Flags().StringP("from", "f", "", "show messages from this sender only")
+	tagsShowCmd.Flags().StringP("order", "o", "", "order of messages: asc or desc")
+	tagsShowCmd.Flags().BoolP("unread", "u", false, "show unread messages only")
+	tagsShowCmd.Flags().String("download", "", "directory to save attachments of the messages")
+	_ = tagsShowCmd.MarkFlagDirname("download")
 	_ = tagsShowCmd.MarkFlagRequired("tag")
 }
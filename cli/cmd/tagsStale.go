@@ -0,0 +1,64 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/spf13/cobra"
+)
+
+// tagsStaleCmd represents the tagsStale command
+var tagsStaleCmd = &cobra.Command{
+	Use:   "stale",
+	Short: "List tags made for replaced sender keys",
+	Long: `List tags made for replaced sender keys.
+
+Given a devspace, list the tags whose trapdoors were made for
+a version of the key of their sender which the sender has since
+rotated. Such tags no longer match the messages of the sender.
+Re-issue them for the current key with:
+  tags create --name NAME --word WORD --from SENDER --append`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// flags
+		devspace, err := cmd.Flags().GetString("devspace")
+		if err != nil {
+			return err
+		}
+		tokenFlag, err := tagsCmd.PersistentFlags().GetString("token")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
+
+		// input
+		if server == "" {
+			return errors.New("server url not supplied")
+		}
+		token, err := keyio.ReadFile(tokenFlag, false)
+		if err != nil {
+			return err
+		}
+
+		// core
+		tags, err := newClient(server, token).StaleTags(cmd.Context(), devspace)
+		if err != nil {
+			return err
+		}
+
+		// output
+		err = json.NewEncoder(cmd.OutOrStdout()).Encode(tags)
+		return err
+	},
+}
+
+func init() {
+	tagsCmd.AddCommand(tagsStaleCmd)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/spf13/cobra"
+)
+
+// errStreamClosed is returned when the server ends the event stream.
+var errStreamClosed = errors.New("stream closed by server")
+
+// watchStream writes each message of the stream to out as json. It
+// returns the id of the last message received, which is also used to
+// resume the stream.
+func watchStream(ctx context.Context, c *client.Client, devspace string, tags []string, lastID string, out io.Writer) (string, error) {
+	stream, err := c.Stream(ctx, devspace, tags, lastID)
+	if err != nil {
+		return lastID, err
+	}
+	defer stream.Close()
+	enc := json.NewEncoder(out)
+	for {
+		m, err := stream.Next()
+		if err == io.EOF {
+			return stream.LastID, errStreamClosed
+		}
+		if err != nil {
+			return stream.LastID, err
+		}
+		err = enc.Encode(m)
+		if err != nil {
+			return stream.LastID, err
+		}
+	}
+}
+
+// tagsWatchCmd represents the tagsWatch command
+var tagsWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch messages on tags of the devspace",
+	Long: `Watch messages on tags of the devspace.
+
+Tail the messages routed to the given tags of the devspace as
+they arrive, printing one json message per line. Without any
+tag, messages on all tags are shown. Dropped connections are
+resumed from the last message received.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// flags
+		devspace, err := cmd.Flags().GetString("devspace")
+		if err != nil {
+			return err
+		}
+		tokenFlag, err := tagsCmd.PersistentFlags().GetString("token")
+		if err != nil {
+			return err
+		}
+		tags, err := cmd.Flags().GetStringSlice("tag")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
+
+		// input
+		if server == "" {
+			return errors.New("server url not supplied")
+		}
+		token, err := keyio.ReadFile(tokenFlag, false)
+		if err != nil {
+			return err
+		}
+
+		// core
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		c := newClient(server, token)
+		lastID := ""
+		for {
+			lastID, err = watchStream(ctx, c, devspace, tags, lastID, cmd.OutOrStdout())
+			// interrupted watches end quietly
+			if ctx.Err() != nil {
+				return nil
+			}
+			// an error response is not worth retrying unlike a dropped connection
+			var apiErr *client.Error
+			if errors.As(err, &apiErr) {
+				return err
+			}
+			cmd.PrintErrln("reconnecting:", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+			}
+		}
+	},
+}
+
+func init() {
+	tagsCmd.AddCommand(tagsWatchCmd)
+
+	tagsWatchCmd.Flags().StringSliceP("tag", "t", nil, "names of the tags to watch")
+}
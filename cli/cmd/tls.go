@@ -0,0 +1,54 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// httpClient returns the client of the api server trusting the
+// ca-cert and presenting the client-cert of the selected profile,
+// or nil if none are set.
+func httpClient() (*http.Client, error) {
+	if profile.CACert == "" && profile.ClientCert == "" && profile.ClientKey == "" {
+		return nil, nil
+	}
+	conf := new(tls.Config)
+	if profile.CACert != "" {
+		data, err := os.ReadFile(profile.CACert)
+		if err != nil {
+			return nil, err
+		}
+		conf.RootCAs, err = x509.SystemCertPool()
+		if err != nil {
+			conf.RootCAs = x509.NewCertPool()
+		}
+		if !conf.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates in %s", profile.CACert)
+		}
+	}
+	if profile.ClientCert != "" || profile.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(profile.ClientCert, profile.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = conf
+	return &http.Client{Transport: transport}, nil
+}
+
+// errTransport fails every request with the error of the settings.
+type errTransport struct{ err error }
+
+func (t errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
@@ -21,7 +21,18 @@ var trapdoorCmd = &cobra.Command{
 
 Take the keyword, server public key, receiver secret key and
 sender public key from the command line and output trapdoor
-for the given keyword`,
+for the given keyword.
+
+With --shared, the trapdoor is computed for devspaces of the
+shared scheme, which matches every sender and takes no sender
+public key. It is blinded with the blinding key of the server
+given by --blind, as output by dev pubkey --blind, which is
+checked against the server public key.
+
+The keyword is normalized, case folded and trimmed before the
+trapdoor is computed. With --stem, english words are stemmed as
+well, and the ciphertexts have to be computed with --stem to match.
+Keywords are hashed apart on every devspace, given by --devspace.`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error { // flags
 		oFlag, err := cmd.Flags().GetString("output")
@@ -44,6 +55,14 @@ for the given keyword`,
 		if err != nil {
 			return err
 		}
+		blindFlag, err := cmd.Flags().GetString("blind")
+		if err != nil {
+			return err
+		}
+		blindHex, err := cmd.Flags().GetString("blind-hex")
+		if err != nil {
+			return err
+		}
 		skHex, err := cmd.Flags().GetString("skey-hex")
 		if err != nil {
 			return err
@@ -60,15 +79,33 @@ for the given keyword`,
 		if err != nil {
 			return err
 		}
+		shared, err := cmd.Flags().GetBool("shared")
+		if err != nil {
+			return err
+		}
+		stem, err := cmd.Flags().GetBool("stem")
+		if err != nil {
+			return err
+		}
+		devspace, err := cmd.Flags().GetString("devspace")
+		if err != nil {
+			return err
+		}
 
 		// input
 		sk := new(core.SKey)
+		defer sk.Destroy()
 		err = keyio.ReadKey(sk, skFlag, skHex, false)
 		if err != nil {
 			return err
 		}
 		pk := new(core.PKey)
-		err = keyio.ReadKey(pk, pkFlag, pkHex, false)
+		blind := new(core.PKeyBlind)
+		if shared {
+			err = keyio.ReadKey(blind, blindFlag, blindHex, false)
+		} else {
+			err = keyio.ReadKey(pk, pkFlag, pkHex, false)
+		}
 		if err != nil {
 			return err
 		}
@@ -77,6 +114,16 @@ for the given keyword`,
 		if err != nil {
 			return err
 		}
+		err = checkServerKey("", server)
+		if err != nil {
+			return err
+		}
+		if shared {
+			err = blind.Check(server)
+			if err != nil {
+				return err
+			}
+		}
 		if keyword == "" {
 			kwBytes, err := keyio.ReadFile(kwFlag, true)
 			if err != nil {
@@ -86,7 +133,13 @@ for the given keyword`,
 		}
 
 		// core logic
-		peks, err := core.Trapdoor([]byte(keyword), server, pk, sk)
+		scheme := core.Scheme{Canon: canon(stem)}
+		var peks []byte
+		if shared {
+			peks, err = scheme.TrapdoorShared(devspace, []byte(keyword), blind, sk)
+		} else {
+			peks, err = scheme.Trapdoor(devspace, []byte(keyword), server, pk, sk)
+		}
 		if err != nil {
 			return err
 		}
@@ -112,12 +165,21 @@ func init() {
 	trapdoorCmd.Flags().StringP("server", "r", "", "server public key file")
 	_ = trapdoorCmd.MarkFlagFilename("server")
 	trapdoorCmd.Flags().String("server-hex", "", "hexadecimal server public key")
+	trapdoorCmd.Flags().String("blind", "", "server blinding key file, with --shared")
+	_ = trapdoorCmd.MarkFlagFilename("blind")
+	trapdoorCmd.Flags().String("blind-hex", "", "hexadecimal server blinding key, with --shared")
 	trapdoorCmd.Flags().String("skey-hex", "", "hexadecimal private key")
 	trapdoorCmd.Flags().String("pkey-hex", "", "hexadecimal public key")
 	trapdoorCmd.Flags().StringP("file", "f", "", "keyword file")
 	_ = trapdoorCmd.MarkFlagFilename("file")
 	trapdoorCmd.Flags().StringP("keyword", "k", "", "keyword text")
+	trapdoorCmd.Flags().Bool("shared", false, "compute the trapdoor for the shared scheme")
+	trapdoorCmd.Flags().Bool("stem", false, "stem the english words of the keyword")
+	trapdoorCmd.Flags().StringP("devspace", "d", "", "devspace of the trapdoor")
 	trapdoorCmd.MarkFlagsMutuallyExclusive("skey", "skey-hex")
 	trapdoorCmd.MarkFlagsMutuallyExclusive("pkey", "pkey-hex")
+	trapdoorCmd.MarkFlagsMutuallyExclusive("blind", "blind-hex")
 	trapdoorCmd.MarkFlagsMutuallyExclusive("file", "keyword")
+	bindProfile(trapdoorCmd.Flags(), "devspace", "devspace")
+	_ = trapdoorCmd.MarkFlagRequired("devspace")
 }
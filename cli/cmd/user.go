@@ -6,12 +6,13 @@ This file is a part of CLI application for Devspace.
 package cmd
 
 import (
-	"encoding/json"
+	"encoding/hex"
 	"errors"
+
 	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/bingxueshuang/devspaces/core"
 	"github.com/spf13/cobra"
-	"net/http"
-	"net/url"
 )
 
 // userCmd represents the user command
@@ -21,8 +22,9 @@ var userCmd = &cobra.Command{
 	Long: `Fetch user public key.
 
 Request the devspace api server for public key of
-the user`,
-	Args:      cobra.ExactArgs(1),
+the user, which is verified against the key log of
+the server and pinned on first use.`,
+	Args:      cobra.MaximumNArgs(1),
 	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// flags
@@ -34,7 +36,7 @@ the user`,
 		if err != nil {
 			return err
 		}
-		server := args[0]
+		server := serverArg(args)
 
 		// input
 		if username == "" {
@@ -45,38 +47,18 @@ the user`,
 		}
 
 		// core
-		client := new(http.Client)
-		serverURL, err := url.JoinPath(server, "/user/", username)
-		if err != nil {
-			return err
-		}
-		req, err := http.NewRequest("GET", serverURL, nil)
-		if err != nil {
-			return err
-		}
-		res, err := client.Do(req)
+		c := newClient(server, nil)
+		key, err := c.User(cmd.Context(), username)
 		if err != nil {
 			return err
 		}
-		data := new(Response)
-		err = json.NewDecoder(res.Body).Decode(data)
+		err = c.VerifyKey(cmd.Context(), client.Binding{Kind: core.BindingUser, Name: key.Username, Pubkey: key.Pubkey, Epoch: key.Version})
 		if err != nil {
 			return err
 		}
 
 		// output
-		if res.StatusCode != http.StatusOK {
-			return errors.New(res.Status)
-		}
-		datamap, ok := data.Data.(map[string]any)
-		if !ok {
-			return errors.New("invalid json response")
-		}
-		pubkey, ok := datamap["pubkey"].(string)
-		if !ok {
-			return errors.New("invalid json response")
-		}
-		err = keyio.WriteString(pubkey, oFlag, true)
+		err = keyio.WriteString(hex.EncodeToString(key.Pubkey), oFlag, true)
 		return err
 	},
 }
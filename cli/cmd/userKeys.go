@@ -0,0 +1,60 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// userKeysCmd represents the userKeys command
+var userKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "List the key history of a user",
+	Long: `List the key history of a user.
+
+Request the devspace api server for every version of the
+public key of the user, along with its validity period.
+Tags made for an earlier version of the key of a sender no
+longer match its messages, see "tags stale".`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// flags
+		username, err := cmd.Flags().GetString("username")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
+
+		// input
+		if username == "" {
+			return errors.New("no username provided")
+		}
+		if server == "" {
+			return errors.New("no server provided")
+		}
+
+		// core
+		keys, err := newClient(server, nil).UserKeys(cmd.Context(), username)
+		if err != nil {
+			return err
+		}
+
+		// output
+		err = json.NewEncoder(cmd.OutOrStdout()).Encode(keys)
+		return err
+	},
+}
+
+func init() {
+	userCmd.AddCommand(userKeysCmd)
+
+	userKeysCmd.Flags().StringP("username", "u", "", "Requested username")
+	_ = userKeysCmd.MarkFlagRequired("username")
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/core"
+	"github.com/spf13/cobra"
+)
+
+// userRotateCmd represents the userRotate command
+var userRotateCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Rotate the key pair of the logged in user",
+	Long: `Rotate the key pair of the logged in user.
+
+Generate a new key pair for the user, prove to the server
+that the user holds the new secret key and replace the public
+key of the user. The previous key stays in the key history of
+the user, see "user keys".
+
+Tags made for the previous key no longer match the messages
+of the user, and have to be re-issued by the devspace owners.
+Point the profile to the new key pair once it is replaced,
+for example with: config set skey FILE`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// flags
+		tokenFlag, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return err
+		}
+		username, err := cmd.Flags().GetString("username")
+		if err != nil {
+			return err
+		}
+		skFlag, err := cmd.Flags().GetString("skey")
+		if err != nil {
+			return err
+		}
+		pkFlag, err := cmd.Flags().GetString("pkey")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
+
+		// input
+		if server == "" {
+			return errors.New("server url not provided")
+		}
+		if username == "" {
+			return errors.New("no username provided")
+		}
+		token, err := keyio.ReadFile(tokenFlag, false)
+		if err != nil {
+			return err
+		}
+		c := newClient(server, token)
+		srv, err := serverPubkey(cmd.Context(), c)
+		if err != nil {
+			return err
+		}
+
+		// core
+		sk, pk, err := core.KeyGenCurve(srv.Curve)
+		if err != nil {
+			return err
+		}
+		defer sk.Destroy()
+		// save the new key pair before the old one is replaced
+		err = keyio.WriteString(hex.EncodeToString(sk.Bytes()), skFlag, true)
+		if err != nil {
+			return err
+		}
+		if pkFlag != "" {
+			err = keyio.WriteString(hex.EncodeToString(pk.Bytes()), pkFlag, false)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = c.RotateUserKey(cmd.Context(), username, sk)
+		return err
+	},
+}
+
+func init() {
+	userCmd.AddCommand(userRotateCmd)
+
+	userRotateCmd.Flags().StringP("token", "k", "", "login token")
+	userRotateCmd.Flags().StringP("username", "u", "", "username of the logged in user")
+	userRotateCmd.Flags().StringP("skey", "s", "", "file to output new secret key")
+	userRotateCmd.Flags().StringP("pkey", "p", "", "file to output new public key")
+	bindProfile(userRotateCmd.Flags(), "token", "token")
+	bindProfile(userRotateCmd.Flags(), "username", "username")
+	_ = userRotateCmd.MarkFlagRequired("token")
+}
@@ -0,0 +1,32 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// webhooksCmd represents the webhooks command
+var webhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Manage webhooks of a devspace",
+	Long: `Manage webhooks of a devspace.
+
+Webhooks are notified with a signed json payload whenever
+a message is routed to their tag in the devspace. Register
+or remove webhooks and inspect their delivery log.`,
+}
+
+func init() {
+	rootCmd.AddCommand(webhooksCmd)
+
+	webhooksCmd.PersistentFlags().StringP("devspace", "d", "", "devspace of the webhooks")
+	webhooksCmd.PersistentFlags().StringP("token", "k", "", "login token")
+	bindProfile(webhooksCmd.PersistentFlags(), "devspace", "devspace")
+	bindProfile(webhooksCmd.PersistentFlags(), "token", "token")
+	_ = webhooksCmd.MarkPersistentFlagRequired("devspace")
+	_ = webhooksCmd.MarkPersistentFlagRequired("token")
+}
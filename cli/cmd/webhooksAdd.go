@@ -0,0 +1,85 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/spf13/cobra"
+)
+
+// webhooksAddCmd represents the webhooksAdd command
+var webhooksAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Register a webhook on a devspace",
+	Long: `Register a webhook on a devspace.
+
+The webhook is notified of messages routed to the given tag,
+or to any tag if none is given. Unless a secret is supplied,
+the server generates one and shows it only once. Payloads are
+signed with HMAC-SHA256 keyed with the secret exactly as shown.
+The url must not be on a loopback, link-local or private address.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// flags
+		devspace, err := cmd.Flags().GetString("devspace")
+		if err != nil {
+			return err
+		}
+		tokenFlag, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return err
+		}
+		hookURL, err := cmd.Flags().GetString("url")
+		if err != nil {
+			return err
+		}
+		tag, err := cmd.Flags().GetString("tag")
+		if err != nil {
+			return err
+		}
+		secret, err := cmd.Flags().GetString("secret")
+		if err != nil {
+			return err
+		}
+		server := serverArg(args)
+
+		// input
+		if server == "" {
+			return errors.New("server url not supplied")
+		}
+		token, err := keyio.ReadFile(tokenFlag, false)
+		if err != nil {
+			return err
+		}
+
+		// core
+		hook, err := newClient(server, token).CreateWebhook(cmd.Context(), devspace, client.NewWebhook{
+			URL:    hookURL,
+			Tag:    tag,
+			Secret: secret,
+		})
+		if err != nil {
+			return err
+		}
+
+		// output
+		return json.NewEncoder(cmd.OutOrStdout()).Encode(hook)
+	},
+}
+
+func init() {
+	webhooksCmd.AddCommand(webhooksAddCmd)
+
+	webhooksAddCmd.Flags().StringP("url", "u", "", "url of the webhook")
+	webhooksAddCmd.Flags().StringP("tag", "t", "", "tag whose messages are notified")
+	webhooksAddCmd.Flags().StringP("secret", "s", "", "secret for signing the payloads")
+	_ = webhooksAddCmd.MarkFlagRequired("url")
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2023 The Devspace Authors
+This file is a part of CLI application for Devspace.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bingxueshuang/devspaces/cli/keyio"
+	"github.com/bingxueshuang/devspaces/client"
+	"github.com/spf13/cobra"
+)
+
+// webhookRequest calls the webhooks api of the devspace
+// with request and prints the result, if any.
+func webhookRequest(cmd *cobra.Command, server string, request func(c *client.Client, devspace string) (any, error)) error {
+	// flags
+	devspace, err := cmd.Flags().GetString("devspace")
+	if err != nil {
+		return err
+	}
+	tokenFlag, err := cmd.Flags().GetString("token")
+	if err != nil {
+		return err
+	}
+
+	// input
+	if server == "" {
+		return errors.New("server url not supplied")
+	}
+	token, err := keyio.ReadFile(tokenFlag, false)
+	if err != nil {
+		return err
+	}
+
+	// core
+	data, err := request(newClient(server, token), devspace)
+	if err != nil {
+		return err
+	}
+
+	// output
+	if data == nil {
+		return nil
+	}
+	return json.NewEncoder(cmd.OutOrStdout()).Encode(data)
+}
+
+// webhooksListCmd represents the webhooksList command
+var webhooksListCmd = &cobra.Command{
+	Use:       "list",
+	Short:     "List webhooks of a devspace",
+	Long:      `List webhooks of a devspace.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return webhookRequest(cmd, serverArg(args), func(c *client.Client, devspace string) (any, error) {
+			return c.ListWebhooks(cmd.Context(), devspace)
+		})
+	},
+}
+
+// webhooksDeleteCmd represents the webhooksDelete command
+var webhooksDeleteCmd = &cobra.Command{
+	Use:       "delete",
+	Short:     "Remove a webhook from a devspace",
+	Long:      `Remove a webhook from a devspace.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := cmd.Flags().GetInt64("id")
+		if err != nil {
+			return err
+		}
+		return webhookRequest(cmd, serverArg(args), func(c *client.Client, devspace string) (any, error) {
+			return nil, c.DeleteWebhook(cmd.Context(), devspace, id)
+		})
+	},
+}
+
+// webhooksLogCmd represents the webhooksLog command
+var webhooksLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show delivery log of a webhook",
+	Long: `Show delivery log of a webhook.
+
+List the recent delivery attempts of the webhook, or with
+--dead the messages whose delivery failed after all retries.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"http://localhost:5005", "http://localhost:8080", "https://api.devspace.com"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := cmd.Flags().GetInt64("id")
+		if err != nil {
+			return err
+		}
+		dead, err := cmd.Flags().GetBool("dead")
+		if err != nil {
+			return err
+		}
+		return webhookRequest(cmd, serverArg(args), func(c *client.Client, devspace string) (any, error) {
+			if dead {
+				return c.DeadLetters(cmd.Context(), devspace, id)
+			}
+			return c.Deliveries(cmd.Context(), devspace, id)
+		})
+	},
+}
+
+func init() {
+	webhooksCmd.AddCommand(webhooksListCmd)
+	webhooksCmd.AddCommand(webhooksDeleteCmd)
+	webhooksCmd.AddCommand(webhooksLogCmd)
+
+	for _, c := range []*cobra.Command{webhooksDeleteCmd, webhooksLogCmd} {
+		c.Flags().Int64P("id", "i", 0, "id of the webhook")
+		_ = c.MarkFlagRequired("id")
+	}
+	webhooksLogCmd.Flags().Bool("dead", false, "show the dead-letter list")
+}
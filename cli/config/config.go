@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is used when no profile has been selected.
+const DefaultProfile = "default"
+
+var ErrUnknownKey = errors.New("unknown configuration key")
+
+// Profile holds the defaults of the commands run against
+// one devspace api server.
+type Profile struct {
+	Server   string `yaml:"server,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Devspace string `yaml:"devspace,omitempty"`
+	// Token is the file holding the login token.
+	Token string `yaml:"token,omitempty"`
+	// SecretKey and PublicKey are the files holding the key pair of the user.
+	SecretKey string `yaml:"skey,omitempty"`
+	PublicKey string `yaml:"pkey,omitempty"`
+	// ServerKey is the fingerprint of the public key of the server,
+	// pinned on first use.
+	ServerKey string `yaml:"server-key,omitempty"`
+	// CACert is the file holding the certificates trusted to serve
+	// the server over TLS, besides those of the system.
+	CACert string `yaml:"ca-cert,omitempty"`
+	// ClientCert and ClientKey are the files holding the certificate
+	// and its key authenticating the user to the server over TLS.
+	ClientCert string `yaml:"client-cert,omitempty"`
+	ClientKey  string `yaml:"client-key,omitempty"`
+}
+
+// Keys are the names of the profile settings, in the order they are listed.
+var Keys = []string{"server", "username", "devspace", "token", "skey", "pkey", "server-key", "ca-cert", "client-cert", "client-key"}
+
+// FileKeys are the settings holding a file location.
+var FileKeys = map[string]bool{
+	"token": true, "skey": true, "pkey": true,
+	"ca-cert": true, "client-cert": true, "client-key": true,
+}
+
+func (p *Profile) field(key string) (*string, error) {
+	switch key {
+	case "server":
+		return &p.Server, nil
+	case "username":
+		return &p.Username, nil
+	case "devspace":
+		return &p.Devspace, nil
+	case "token":
+		return &p.Token, nil
+	case "skey":
+		return &p.SecretKey, nil
+	case "pkey":
+		return &p.PublicKey, nil
+	case "server-key":
+		return &p.ServerKey, nil
+	case "ca-cert":
+		return &p.CACert, nil
+	case "client-cert":
+		return &p.ClientCert, nil
+	case "client-key":
+		return &p.ClientKey, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+}
+
+// Get returns the value of the setting key.
+func (p *Profile) Get(key string) (string, error) {
+	f, err := p.field(key)
+	if err != nil {
+		return "", err
+	}
+	return *f, nil
+}
+
+// Set changes the value of the setting key.
+// An empty value unsets it.
+func (p *Profile) Set(key, value string) error {
+	f, err := p.field(key)
+	if err != nil {
+		return err
+	}
+	*f = value
+	return nil
+}
+
+// Config is the configuration file of the CLI.
+type Config struct {
+	// Current is the profile used when none is selected.
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// Path returns the location of the configuration file,
+// which is devspaces/config.yaml under the user config directory.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "devspaces", "config.yaml"), nil
+}
+
+// Load reads the configuration file at path.
+// A missing file is an empty configuration.
+func Load(path string) (*Config, error) {
+	conf := new(Config)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return conf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(data, conf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return conf, nil
+}
+
+// Save writes the configuration file to path, creating
+// its directory if needed.
+func (c *Config) Save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Name resolves the profile name, falling back to
+// the current profile and then to the default one.
+func (c *Config) Name(name string) string {
+	if name != "" {
+		return name
+	}
+	if c.Current != "" {
+		return c.Current
+	}
+	return DefaultProfile
+}
+
+// Profile returns the profile of the name, or nil if it does not exist.
+func (c *Config) Profile(name string) *Profile {
+	return c.Profiles[c.Name(name)]
+}
+
+// Ensure returns the profile of the name, adding it if it does not exist.
+func (c *Config) Ensure(name string) *Profile {
+	name = c.Name(name)
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]*Profile)
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		p = new(Profile)
+		c.Profiles[name] = p
+	}
+	return p
+}
+
+// Names returns the sorted profile names.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
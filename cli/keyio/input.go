@@ -27,6 +27,12 @@ func ReadKey(key core.EllipticKey, filename string, hexKey string, stdin bool) e
 	if err != nil {
 		return nil
 	}
+	// the decoded bytes of secret keys are not kept around
+	defer func() {
+		for i := range data {
+			data[i] = 0
+		}
+	}()
 	return key.FromBytes(data)
 }
 
@@ -3,6 +3,7 @@ package keyio
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 )
 
 // WriteString writes given string to file.
@@ -14,3 +15,21 @@ func WriteString(data string, filename string, stdout bool) error {
 	}
 	return os.WriteFile(filename, []byte(data), 0644)
 }
+
+// WriteTemp writes given string to a new temporary file next to
+// filename, to be renamed over filename once it is to be replaced.
+func WriteTemp(data string, filename string) (string, error) {
+	file, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.WriteString(data)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import "context"
+
+// ServerKey fetches the public key of the server.
+func (c *Client) ServerKey(ctx context.Context) (*ServerKey, error) {
+	key := new(ServerKey)
+	err := c.do(ctx, "GET", nil, nil, key, "/pubkey")
+	return key, err
+}
+
+// Nonce fetches a nonce of the server for a proof of possession.
+func (c *Client) Nonce(ctx context.Context) (*Nonce, error) {
+	nonce := new(Nonce)
+	err := c.do(ctx, "GET", nil, nil, nonce, "/auth/nonce")
+	return nonce, err
+}
+
+// Register signs up a user. The registration carries a proof of
+// possession of the secret key, see RegisterKey.
+func (c *Client) Register(ctx context.Context, r Registration) error {
+	return c.do(ctx, "POST", nil, r, nil, "/auth/register")
+}
+
+// Login logs in a user and returns the login token. The
+// token is not stored in the client.
+func (c *Client) Login(ctx context.Context, cred Credentials) (*Token, error) {
+	token := new(Token)
+	err := c.do(ctx, "POST", nil, cred, token, "/auth/login")
+	return token, err
+}
+
+// User fetches the public key of a user.
+func (c *Client) User(ctx context.Context, username string) (*UserKey, error) {
+	key := new(UserKey)
+	err := c.do(ctx, "GET", nil, nil, key, "/user/", username)
+	return key, err
+}
+
+// UserKeys lists the versions of the public key of a user, the current
+// key last. Tags made for an earlier version no longer match the
+// messages of the user.
+func (c *Client) UserKeys(ctx context.Context, username string) ([]KeyVersion, error) {
+	var keys []KeyVersion
+	err := c.do(ctx, "GET", nil, nil, &keys, "/user/", username, "keys")
+	return keys, err
+}
+
+// SetUserKey replaces the public key of the logged in user. The key
+// carries a proof of possession of the secret key, see RotateUserKey.
+func (c *Client) SetUserKey(ctx context.Context, k NewUserKey) (*Version, error) {
+	version := new(Version)
+	err := c.do(ctx, "PUT", nil, k, version, "/user/me/pubkey")
+	return version, err
+}
+
+// Invites lists the collaboration invites to the logged in user.
+func (c *Client) Invites(ctx context.Context) ([]Invite, error) {
+	var invites []Invite
+	err := c.do(ctx, "GET", nil, nil, &invites, "/dashboard")
+	return invites, err
+}
@@ -0,0 +1,197 @@
+// Package client is a typed client of the devspace api server,
+// following the OpenAPI specification served at /openapi.json.
+//
+// Keys, trapdoors and encrypted keywords are exchanged as bytes;
+// the client takes care of their hex encoding on the wire and of
+// the PEKS encryption of keywords with the keys of the server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bingxueshuang/devspaces/core"
+)
+
+// Client sends requests to a devspace api server. A Client is
+// safe for concurrent use and must not be copied after first use.
+type Client struct {
+	// Server is the base url of the api server.
+	Server string
+	// Tokens supplies the login token sent as a bearer token.
+	// Requests are not authorized if it is nil.
+	Tokens TokenSource
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Retries is the number of times an idempotent request is
+	// retried after a network error or an unavailable server.
+	Retries int
+	// Backoff is the delay before the first retry, doubled
+	// on every following retry.
+	Backoff time.Duration
+	// KeyLog verifies the public keys of users and devspaces fetched
+	// by the client against the key log of the server, if not nil.
+	KeyLog *KeyLog
+
+	mu        sync.Mutex
+	serverKey *core.PKeyServer
+	blindKey  *core.PKeyBlind
+}
+
+// New returns a client of the api server at server, retrying
+// idempotent requests up to three times.
+func New(server string, tokens TokenSource) *Client {
+	return &Client{
+		Server:  server,
+		Tokens:  tokens,
+		Retries: 3,
+		Backoff: 250 * time.Millisecond,
+	}
+}
+
+// response is the envelope of every json response.
+type response struct {
+	Ok    bool            `json:"ok"`
+	Data  json.RawMessage `json:"data"`
+	Error *Error          `json:"error"`
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// newRequest builds a request to the path joined from elem.
+func (c *Client) newRequest(ctx context.Context, method string, query url.Values, body io.Reader, elem ...string) (*http.Request, error) {
+	if c.Server == "" {
+		return nil, errors.New("client: no server url")
+	}
+	u, err := url.JoinPath(c.Server, elem...)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Tokens != nil {
+		token, err := c.Tokens.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	return req, nil
+}
+
+// idempotent methods are safe to retry. PUT is not, as the
+// public keys put by the client consume single-use nonces.
+var idempotent = map[string]bool{
+	"GET":    true,
+	"HEAD":   true,
+	"DELETE": true,
+}
+
+// retryable reports whether the response, or the error,
+// is worth retrying the request for.
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// send sends the request and returns the response if successful.
+// Idempotent requests whose body can be replayed are retried.
+// The caller must close the body of the response.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	canRetry := idempotent[req.Method] && (req.Body == nil || req.GetBody != nil)
+	delay := c.Backoff
+	for attempt := 0; ; attempt++ {
+		res, err := c.httpClient().Do(req)
+		if attempt >= c.Retries || !canRetry || !retryable(res, err) {
+			if err != nil {
+				return nil, err
+			}
+			if res.StatusCode != http.StatusOK {
+				defer res.Body.Close()
+				return nil, decodeError(res)
+			}
+			return res, nil
+		}
+		if err == nil {
+			res.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		req = req.Clone(req.Context())
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// do sends a json request with body and decodes the data of
+// the response into out, unless either of them is nil.
+func (c *Client) do(ctx context.Context, method string, query url.Values, body, out any, elem ...string) error {
+	var buf io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		buf = bytes.NewReader(b)
+	}
+	req, err := c.newRequest(ctx, method, query, buf, elem...)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data := new(response)
+	err = json.NewDecoder(res.Body).Decode(data)
+	if err != nil {
+		return err
+	}
+	if !data.Ok {
+		return apiError(res, data)
+	}
+	if out == nil || len(data.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data.Data, out)
+}
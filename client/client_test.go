@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flaky is an api server which is unavailable for the first n requests.
+type flaky struct {
+	fail     int32
+	requests int32
+}
+
+func (f *flaky) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if atomic.AddInt32(&f.requests, 1) <= f.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":    true,
+		"data":  map[string]any{"name": "proj", "pubkey": "0a0b"},
+		"error": nil,
+	})
+}
+
+func testClient(t *testing.T, h http.Handler) *Client {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c := New(srv.URL, nil)
+	c.Backoff = time.Millisecond
+	return c
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	t.Run("idempotent", func(t *testing.T) {
+		f := &flaky{fail: 2}
+		c := testClient(t, f)
+		key, err := c.SpaceKey(ctx, "proj")
+		handleFatal(err, t)
+		if string(key.Pubkey) != "\x0a\x0b" {
+			t.Logf("got: %x", key.Pubkey)
+			t.Fatal("incorrect hex decoding of response")
+		}
+		if f.requests != 3 {
+			t.Logf("expected: %v, got: %v", 3, f.requests)
+			t.Fatal("request is expected to be retried until it succeeds")
+		}
+	})
+	t.Run("exhausted", func(t *testing.T) {
+		f := &flaky{fail: 10}
+		c := testClient(t, f)
+		_, err := c.SpaceKey(ctx, "proj")
+		var e *Error
+		if !asError(err, &e) || e.StatusCode != http.StatusServiceUnavailable {
+			t.Logf("got: %v", err)
+			t.Fatal("unavailable server is expected to fail the request")
+		}
+		if int(f.requests) != c.Retries+1 {
+			t.Logf("expected: %v, got: %v", c.Retries+1, f.requests)
+			t.Fatal("incorrect number of attempts")
+		}
+	})
+	t.Run("not idempotent", func(t *testing.T) {
+		f := &flaky{fail: 1}
+		c := testClient(t, f)
+		err := c.Send(ctx, "proj", NewMessage{Data: []byte("hi")})
+		if err == nil || f.requests != 1 {
+			t.Fatal("messages are expected to never be sent twice")
+		}
+	})
+	t.Run("put", func(t *testing.T) {
+		f := &flaky{fail: 1}
+		c := testClient(t, f)
+		_, err := c.RotateKey(ctx, "proj", Rotation{})
+		if err == nil || f.requests != 1 {
+			t.Fatal("rotations are expected to never consume the nonce twice")
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		f := &flaky{fail: 10}
+		c := testClient(t, f)
+		c.Backoff = time.Hour
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := c.SpaceKey(ctx, "proj")
+		if err != context.DeadlineExceeded {
+			t.Logf("got: %v", err)
+			t.Fatal("retries are expected to stop with the context")
+		}
+	})
+}
+
+func asError(err error, e **Error) bool {
+	var ok bool
+	*e, ok = err.(*Error)
+	return ok
+}
+
+// jwt returns an unsigned token expiring at exp.
+func jwt(exp time.Time) string {
+	payload, _ := json.Marshal(map[string]any{"username": "alice", "exp": exp.Unix()})
+	return "e30." + base64.RawURLEncoding.EncodeToString(payload) + ".sig"
+}
+
+func TestPasswordLogin(t *testing.T) {
+	var logins int32
+	expiry := time.Now().Add(time.Hour)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&logins, 1)
+		_, _ = fmt.Fprintf(w, `{"ok":true,"data":{"token":%q},"error":null}`, jwt(expiry))
+		if n == 1 {
+			// the next login is issued a token about to expire
+			expiry = time.Now().Add(time.Second)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	ts := PasswordLogin(srv.URL, Credentials{Username: "alice", Password: "pw"})
+	ctx := context.Background()
+
+	first, err := ts.Token(ctx)
+	handleFatal(err, t)
+	second, err := ts.Token(ctx)
+	handleFatal(err, t)
+	if first != second || logins != 1 {
+		t.Fatal("valid token is expected to be reused")
+	}
+	ts.(*passwordLogin).expiry = time.Now().Add(time.Second)
+	third, err := ts.Token(ctx)
+	handleFatal(err, t)
+	if third == first || logins != 2 {
+		t.Fatal("expiring token is expected to be renewed")
+	}
+}
+
+func TestError(t *testing.T) {
+	c := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"ok":false,"data":null,"error":{"code":"tag_exists","message":"tag already exists"}}`))
+	}))
+	err := c.CreateTag(context.Background(), "proj", NewTag{Name: "deploys"})
+	if !IsCode(err, CodeTagExists) {
+		t.Logf("got: %v", err)
+		t.Fatal("api error is expected to carry its code")
+	}
+	got := err.Error()
+	want := "tag already exists"
+	if got != want {
+		t.Logf("expected: %v, got: %v", want, got)
+		t.Fatal("incorrect error message")
+	}
+}
+
+func handleFatal(e error, i interface{ Fatal(args ...any) }) {
+	if e != nil {
+		i.Fatal(e)
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Error codes of the api server, see the Error schema.
+const (
+	CodeInvalidBody        = "invalid_body"
+	CodeMissingFields      = "missing_fields"
+	CodeValidation         = "validation_failed"
+	CodeInvalidParameter   = "invalid_parameter"
+	CodeInvalidPubkey      = "invalid_pubkey"
+	CodeInvalidTrapdoor    = "invalid_trapdoor"
+	CodeInvalidKeyword     = "invalid_keyword"
+	CodeInvalidData        = "invalid_data"
+	CodeInvalidAttachment  = "invalid_attachment"
+	CodeInvalidSignature   = "invalid_signature"
+	CodeInvalidProof       = "invalid_proof"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeUserNotFound       = "user_not_found"
+	CodeSpaceNotFound      = "space_not_found"
+	CodeMessageNotFound    = "message_not_found"
+	CodeWebhookNotFound    = "webhook_not_found"
+	CodeBlobNotFound       = "blob_not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeUserExists         = "user_exists"
+	CodeSpaceExists        = "space_exists"
+	CodeTagExists          = "tag_exists"
+	CodeTooLarge           = "payload_too_large"
+	CodeRateLimited        = "rate_limited"
+	CodeInternal           = "internal_error"
+)
+
+// Error is the error of an unsuccessful response.
+type Error struct {
+	// StatusCode and Status are those of the http response.
+	StatusCode int    `json:"-"`
+	Status     string `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Status
+	}
+	if e.Detail != "" {
+		msg += " (" + e.Detail + ")"
+	}
+	return msg
+}
+
+// IsCode reports whether err is an api error with the given code.
+func IsCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// apiError returns the error of an unsuccessful response,
+// falling back to the response status if it has no error body.
+func apiError(res *http.Response, data *response) error {
+	e := new(Error)
+	if data != nil && data.Error != nil {
+		*e = *data.Error
+	}
+	e.StatusCode = res.StatusCode
+	e.Status = res.Status
+	return e
+}
+
+// decodeError decodes the error body of an unsuccessful response.
+func decodeError(res *http.Response) error {
+	data := new(response)
+	if err := json.NewDecoder(res.Body).Decode(data); err != nil {
+		return apiError(res, nil)
+	}
+	return apiError(res, data)
+}
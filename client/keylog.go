@@ -0,0 +1,216 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/bingxueshuang/devspaces/core"
+)
+
+// ErrKeyLog is returned when a public key served by the server
+// is not proven to be in its key transparency log.
+var ErrKeyLog = errors.New("key log verification failed")
+
+// ErrLogKey is returned when the log key of the server does not match
+// the pinned log key. The pins are kept, and are only to be dropped on
+// purpose by the user once the replacement of the log key is confirmed.
+var ErrLogKey = fmt.Errorf("%w: log key of the server does not match the pinned key", ErrKeyLog)
+
+// LogHead fetches the signed head of the key log.
+func (c *Client) LogHead(ctx context.Context) (*TreeHead, error) {
+	head := new(TreeHead)
+	err := c.do(ctx, "GET", nil, nil, head, "/log/head")
+	return head, err
+}
+
+// Inclusion fetches the proof that the binding is in the tree of
+// the key log of size.
+func (c *Client) Inclusion(ctx context.Context, b Binding, size int64) (*InclusionProof, error) {
+	q := url.Values{}
+	q.Set("kind", b.Kind)
+	q.Set("name", b.Name)
+	q.Set("epoch", strconv.FormatInt(b.Epoch, 10))
+	q.Set("pubkey", hex.EncodeToString(b.Pubkey))
+	q.Set("size", strconv.FormatInt(size, 10))
+	proof := new(InclusionProof)
+	err := c.do(ctx, "GET", q, nil, proof, "/log/inclusion")
+	return proof, err
+}
+
+// Consistency fetches the proof that the tree of the key log
+// of size first is a prefix of that of size second.
+func (c *Client) Consistency(ctx context.Context, first, second int64) (*ConsistencyProof, error) {
+	q := url.Values{}
+	q.Set("first", strconv.FormatInt(first, 10))
+	q.Set("second", strconv.FormatInt(second, 10))
+	proof := new(ConsistencyProof)
+	err := c.do(ctx, "GET", q, nil, proof, "/log/consistency")
+	return proof, err
+}
+
+// LogEntries lists up to limit entries of the key log from start,
+// the server default if limit is zero.
+func (c *Client) LogEntries(ctx context.Context, start, limit int) ([]LogEntry, error) {
+	q := url.Values{}
+	q.Set("start", strconv.Itoa(start))
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var entries []LogEntry
+	err := c.do(ctx, "GET", q, nil, &entries, "/log/entries")
+	return entries, err
+}
+
+// VerifyKey verifies the binding served by the server with the key
+// log of the client, and does nothing if the client has none.
+func (c *Client) VerifyKey(ctx context.Context, b Binding) error {
+	if c.KeyLog == nil {
+		return nil
+	}
+	return c.KeyLog.Verify(ctx, c, b)
+}
+
+// Binding is a public key bound to a user or a devspace, see
+// core.BindingUser and core.BindingSpace, at an epoch: the key
+// version of users or the key epoch of devspaces.
+type Binding struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Pubkey Hex    `json:"pubkey"`
+	Epoch  int64  `json:"epoch"`
+}
+
+func (b Binding) String() string {
+	return b.Kind + " " + b.Name
+}
+
+// Pins are what a client trusts on first use: the log key of the
+// server, the last verified tree head of the key log, and the last
+// verified key of every user and devspace, by kind and name.
+type Pins struct {
+	LogKey Hex                `json:"log_key,omitempty"`
+	Head   *TreeHead          `json:"head,omitempty"`
+	Keys   map[string]Binding `json:"keys,omitempty"`
+}
+
+// KeyLog verifies that the public keys served by the server are in its
+// key transparency log, and that the log only grows between the tree
+// heads seen by the client. Keys are pinned on first use, and changes
+// of pinned keys in later epochs are reported as warnings, while a
+// different key at the pinned epoch fails with ErrKeyLog. A change of
+// the log key fails with ErrLogKey.
+type KeyLog struct {
+	Pins Pins
+	// Warn reports the changes of pinned keys, which are dropped if nil.
+	Warn func(msg string)
+	// Save persists the pins whenever they change, if not nil.
+	Save func(p *Pins) error
+
+	mu sync.Mutex
+}
+
+func (l *KeyLog) warn(format string, args ...any) {
+	if l.Warn != nil {
+		l.Warn(fmt.Sprintf(format, args...))
+	}
+}
+
+// Verify checks that the binding is in the key log of the server
+// and pins it, warning when it replaces a pinned key.
+func (l *KeyLog) Verify(ctx context.Context, c *Client, b Binding) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	pins := l.Pins
+	key, err := c.ServerKey(ctx)
+	if err != nil {
+		return err
+	}
+	if pins.LogKey != nil && !bytes.Equal(pins.LogKey, key.LogPubkey) {
+		return ErrLogKey
+	}
+	pins.LogKey = key.LogPubkey
+	logKey := new(core.PKey)
+	if err = logKey.FromBytes(key.LogPubkey); err != nil {
+		return fmt.Errorf("%w: %w", ErrKeyLog, err)
+	}
+
+	head, err := c.LogHead(ctx)
+	if err != nil {
+		return err
+	}
+	ok, err := core.VerifyTreeHead(head.Size, head.Root, head.Timestamp, head.Signature, logKey)
+	if err != nil || !ok {
+		return fmt.Errorf("%w: invalid signature of the tree head", ErrKeyLog)
+	}
+	if old := pins.Head; old != nil {
+		if head.Size < old.Size {
+			return fmt.Errorf("%w: log shrank from %d to %d entries", ErrKeyLog, old.Size, head.Size)
+		}
+		var hashes []Hex
+		if head.Size != old.Size {
+			proof, err := c.Consistency(ctx, old.Size, head.Size)
+			if err != nil {
+				return err
+			}
+			hashes = proof.Hashes
+		}
+		err = core.VerifyConsistency(old.Size, head.Size, bytesOf(hashes), old.Root, head.Root)
+		if err != nil {
+			return fmt.Errorf("%w: log of %d entries is not an extension of the pinned one: %w", ErrKeyLog, head.Size, err)
+		}
+	}
+	pins.Head = head
+
+	proof, err := c.Inclusion(ctx, b, head.Size)
+	if IsCode(err, CodeNotFound) {
+		return fmt.Errorf("%w: key of %s: %w", ErrKeyLog, b, err)
+	}
+	if err != nil {
+		return err
+	}
+	leaf := core.LeafHash(core.BindingLeaf(b.Kind, b.Name, b.Pubkey, b.Epoch))
+	err = core.VerifyInclusion(leaf, proof.Index, head.Size, bytesOf(proof.Hashes), head.Root)
+	if err != nil {
+		return fmt.Errorf("%w: key of %s: %w", ErrKeyLog, b, err)
+	}
+
+	keys := make(map[string]Binding, len(pins.Keys)+1)
+	for k, v := range pins.Keys {
+		keys[k] = v
+	}
+	id := b.Kind + "/" + b.Name
+	if pinned, ok := keys[id]; ok {
+		if b.Epoch < pinned.Epoch {
+			return fmt.Errorf("%w: key of %s at epoch %d is older than the pinned epoch %d", ErrKeyLog, b, b.Epoch, pinned.Epoch)
+		}
+		if !bytes.Equal(pinned.Pubkey, b.Pubkey) {
+			// two keys logged for the same epoch are an equivocation
+			// of the server, not a rotation
+			if b.Epoch == pinned.Epoch {
+				return fmt.Errorf("%w: key of %s at epoch %d differs from the pinned key", ErrKeyLog, b, b.Epoch)
+			}
+			l.warn("public key of %s changed from epoch %d to %d", b, pinned.Epoch, b.Epoch)
+		}
+	}
+	keys[id] = b
+	pins.Keys = keys
+	l.Pins = pins
+	if l.Save != nil {
+		return l.Save(&l.Pins)
+	}
+	return nil
+}
+
+func bytesOf(hashes []Hex) [][]byte {
+	b := make([][]byte, 0, len(hashes))
+	for _, h := range hashes {
+		b = append(b, h)
+	}
+	return b
+}
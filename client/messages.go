@@ -0,0 +1,144 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Send sends a message on a devspace.
+func (c *Client) Send(ctx context.Context, space string, m NewMessage) error {
+	return c.do(ctx, "POST", nil, m, nil, "/space/", space, "send")
+}
+
+// ListMessages lists a page of the messages of a tag.
+func (c *Client) ListMessages(ctx context.Context, space, tag string, q *MessageQuery) (*MessagePage, error) {
+	page := new(MessagePage)
+	err := c.do(ctx, "GET", q.values(), nil, page, "/space/", space, tag)
+	return page, err
+}
+
+// DeleteMessage deletes a message of a devspace.
+func (c *Client) DeleteMessage(ctx context.Context, space string, id int64) error {
+	return c.do(ctx, "DELETE", nil, nil, nil, "/space/", space, "messages", strconv.FormatInt(id, 10))
+}
+
+// MarkMessage marks a message of a devspace as read or unread.
+func (c *Client) MarkMessage(ctx context.Context, space string, id int64, read bool) error {
+	return c.do(ctx, "PATCH", nil, Mark{Read: read}, nil, "/space/", space, "messages", strconv.FormatInt(id, 10))
+}
+
+// UploadBlob streams size bytes of r to the blob store of a
+// devspace, so that the blob can be attached to messages.
+func (c *Client) UploadBlob(ctx context.Context, space string, r io.Reader, size int64) (*Blob, error) {
+	req, err := c.newRequest(ctx, "POST", nil, r, "/space/", space, "blobs")
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data := new(response)
+	err = json.NewDecoder(res.Body).Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	blob := new(Blob)
+	err = json.Unmarshal(data.Data, blob)
+	return blob, err
+}
+
+// DownloadBlob opens an attachment of a devspace. The caller
+// must close the returned reader.
+func (c *Client) DownloadBlob(ctx context.Context, space, digest string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, "GET", nil, nil, "/space/", space, "blobs", digest)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Stream reads the messages pushed on the stream of a devspace.
+type Stream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	// LastID is the id of the last message read, which
+	// resumes the stream when passed to Client.Stream.
+	LastID string
+}
+
+// Stream opens the stream of messages routed on the given tags
+// of a devspace, or on all tags if none is given. Messages after
+// lastID, if not empty, are replayed first.
+func (c *Client) Stream(ctx context.Context, space string, tags []string, lastID string) (*Stream, error) {
+	var query url.Values
+	if len(tags) != 0 {
+		query = url.Values{"tag": tags}
+	}
+	req, err := c.newRequest(ctx, "GET", query, nil, "/space/", space, "stream")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	res, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(nil, 1<<24)
+	return &Stream{
+		body:    res.Body,
+		scanner: scanner,
+		LastID:  lastID,
+	}, nil
+}
+
+// Next blocks until the next message arrives. It returns
+// io.EOF when the server ends the stream.
+func (s *Stream) Next() (*Message, error) {
+	var id, data string
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		switch {
+		case line == "":
+			// blank line dispatches the event
+			if data == "" {
+				continue
+			}
+			m := new(Message)
+			if err := json.Unmarshal([]byte(data), m); err != nil {
+				return nil, err
+			}
+			s.LastID = id
+			return m, nil
+		case strings.HasPrefix(line, "id:"):
+			id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Close closes the connection of the stream.
+func (s *Stream) Close() error {
+	return s.body.Close()
+}
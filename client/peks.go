@@ -0,0 +1,319 @@
+package client
+
+import (
+	"context"
+
+	"github.com/bingxueshuang/devspaces/core"
+)
+
+// ServerPubkey fetches the public key of the server, which is
+// cached for the lifetime of the client.
+func (c *Client) ServerPubkey(ctx context.Context) (*core.PKeyServer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.serverKey != nil {
+		return c.serverKey, nil
+	}
+	key, err := c.ServerKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pk := new(core.PKeyServer)
+	err = pk.FromBytes(key.Pubkey)
+	if err != nil {
+		return nil, err
+	}
+	c.serverKey = pk
+	return pk, nil
+}
+
+// BlindPubkey fetches the blinding key of the server, checked against
+// the public key of the server, which is cached for the lifetime of the
+// client.
+func (c *Client) BlindPubkey(ctx context.Context) (*core.PKeyBlind, error) {
+	srv, err := c.ServerPubkey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.blindKey != nil {
+		return c.blindKey, nil
+	}
+	key, err := c.ServerKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pk := new(core.PKeyBlind)
+	if err = pk.FromBytes(key.BlindPubkey); err != nil {
+		return nil, err
+	}
+	if err = pk.Check(srv); err != nil {
+		return nil, err
+	}
+	c.blindKey = pk
+	return pk, nil
+}
+
+// UserPubkey fetches the public key of a user.
+func (c *Client) UserPubkey(ctx context.Context, username string) (*core.PKey, error) {
+	key, err := c.User(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	err = c.VerifyKey(ctx, Binding{Kind: core.BindingUser, Name: username, Pubkey: key.Pubkey, Epoch: key.Version})
+	if err != nil {
+		return nil, err
+	}
+	pk := new(core.PKey)
+	err = pk.FromBytes(key.Pubkey)
+	return pk, err
+}
+
+// SpacePubkey fetches the current public key of a devspace.
+func (c *Client) SpacePubkey(ctx context.Context, space string) (*core.PKey, error) {
+	pk, _, err := c.spaceScheme(ctx, space)
+	return pk, err
+}
+
+// spaceScheme fetches the current public key and the scheme of a devspace.
+func (c *Client) spaceScheme(ctx context.Context, space string) (*core.PKey, string, error) {
+	key, err := c.SpaceKey(ctx, space)
+	if err != nil {
+		return nil, "", err
+	}
+	err = c.VerifyKey(ctx, Binding{Kind: core.BindingSpace, Name: space, Pubkey: key.Pubkey, Epoch: key.Epoch})
+	if err != nil {
+		return nil, "", err
+	}
+	pk := new(core.PKey)
+	err = pk.FromBytes(key.Pubkey)
+	return pk, key.Scheme, err
+}
+
+// Encrypt computes the ciphertext of keyword, canonicalized by canon,
+// for messages sent with the sender key on a devspace, under the scheme
+// of the devspace. A nil canon is core.CanonV1.
+func (c *Client) Encrypt(ctx context.Context, space, keyword string, canon *core.Canon, sender *core.SKey) ([]byte, error) {
+	srv, err := c.ServerPubkey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pk, scheme, err := c.spaceScheme(ctx, space)
+	if err != nil {
+		return nil, err
+	}
+	return EncryptScheme(scheme, space, []byte(keyword), canon, srv, pk, sender)
+}
+
+// EncryptScheme computes the ciphertext of keyword, canonicalized by
+// canon, on a devspace under the scheme. The sender key is only used
+// by the sender scheme.
+func EncryptScheme(scheme, space string, keyword []byte, canon *core.Canon, srv *core.PKeyServer, pk *core.PKey, sender *core.SKey) ([]byte, error) {
+	s := core.Scheme{Canon: canon}
+	if scheme == SchemeShared {
+		return s.PEKSShared(space, keyword, srv, pk)
+	}
+	return s.PEKS(space, keyword, srv, pk, sender)
+}
+
+// Trapdoor computes the trapdoor matching keyword, canonicalized by
+// canon, on messages sent by the user to the devspace whose secret
+// key is sk.
+func (c *Client) Trapdoor(ctx context.Context, space, keyword string, canon *core.Canon, sender string, sk *core.SKey) ([]byte, error) {
+	srv, err := c.ServerPubkey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := c.UserPubkey(ctx, sender)
+	if err != nil {
+		return nil, err
+	}
+	return core.Scheme{Canon: canon}.Trapdoor(space, []byte(keyword), srv, pk, sk)
+}
+
+// TrapdoorShared computes the trapdoor matching keyword, canonicalized
+// by canon, on messages sent by any user to the devspace of the shared
+// scheme whose secret key is sk, blinded for the server.
+func (c *Client) TrapdoorShared(ctx context.Context, space, keyword string, canon *core.Canon, sk *core.SKey) ([]byte, error) {
+	blind, err := c.BlindPubkey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return core.Scheme{Canon: canon}.TrapdoorShared(space, []byte(keyword), blind, sk)
+}
+
+// SendKeyword encrypts keyword, canonicalized by canon, with the
+// sender key and sends data on the devspace, where it is routed by
+// the keyword. The message is signed with the sender key.
+func (c *Client) SendKeyword(ctx context.Context, space, keyword string, canon *core.Canon, data []byte, sender *core.SKey, attachments ...AttachmentRef) error {
+	ct, err := c.Encrypt(ctx, space, keyword, canon, sender)
+	if err != nil {
+		return err
+	}
+	m := NewMessage{
+		Data:        data,
+		Keyword:     ct,
+		Attachments: attachments,
+	}
+	m.Sign(space, sender)
+	return c.Send(ctx, space, m)
+}
+
+// CreateKeywordTag creates a tag on the devspace whose secret key
+// is sk, collecting the messages sent by sender with keyword,
+// canonicalized by canon. Tags of devspaces of the shared scheme
+// collect the messages of any sender, and sender is then ignored.
+// With extend set, the trapdoor is added to an existing tag of the name.
+func (c *Client) CreateKeywordTag(ctx context.Context, space, name, keyword string, canon *core.Canon, sender string, sk *core.SKey, extend bool) error {
+	_, scheme, err := c.spaceScheme(ctx, space)
+	if err != nil {
+		return err
+	}
+	var td []byte
+	if scheme == SchemeShared {
+		td, err = c.TrapdoorShared(ctx, space, keyword, canon, sk)
+	} else {
+		td, err = c.Trapdoor(ctx, space, keyword, canon, sender, sk)
+	}
+	if err != nil {
+		return err
+	}
+	t := NewTag{
+		Name:     name,
+		Trapdoor: td,
+		Append:   extend,
+	}
+	if scheme == SchemeSender {
+		t.Sender = sender
+	}
+	return c.CreateTag(ctx, space, t)
+}
+
+// Sign signs the message sent on the devspace with the sender key.
+func (m *NewMessage) Sign(space string, sender *core.SKey) {
+	digests := make([]string, 0, len(m.Attachments))
+	for _, a := range m.Attachments {
+		digests = append(digests, a.Digest)
+	}
+	m.Signature = core.Sign(core.MessageDigest(space, m.Keyword, m.Data, digests), sender)
+}
+
+// Verify checks the signature of the message received on the
+// devspace against the public key of its sender.
+func (m *Message) Verify(space string, sender *core.PKey) (bool, error) {
+	if len(m.Signature) == 0 {
+		return false, nil
+	}
+	digests := make([]string, 0, len(m.Attachments))
+	for _, a := range m.Attachments {
+		digests = append(digests, a.Digest)
+	}
+	return core.Verify(core.MessageDigest(space, m.Keyword, m.Data, digests), m.Signature, sender)
+}
+
+// Prove proves that the subject holds the secret key sk, for a nonce
+// fetched from the server. The subject is the username of a user or
+// the name of a devspace whose key is registered.
+func (c *Client) Prove(ctx context.Context, subject string, sk *core.SKey) (nonce, proof []byte, err error) {
+	n, err := c.Nonce(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n.Nonce, core.ProvePossession(subject, n.Nonce, sk), nil
+}
+
+// RegisterKey signs up a user with the public key of sk,
+// proving to hold sk.
+func (c *Client) RegisterKey(ctx context.Context, username, password string, sk *core.SKey) error {
+	nonce, proof, err := c.Prove(ctx, username, sk)
+	if err != nil {
+		return err
+	}
+	pk := new(core.PKey)
+	if err = pk.FromSKey(sk); err != nil {
+		return err
+	}
+	return c.Register(ctx, Registration{
+		Username: username,
+		Password: password,
+		Pubkey:   pk.Bytes(),
+		Nonce:    nonce,
+		Proof:    proof,
+	})
+}
+
+// RotateUserKey replaces the public key of the logged in user
+// with that of sk, proving to hold sk as the user username.
+func (c *Client) RotateUserKey(ctx context.Context, username string, sk *core.SKey) (*Version, error) {
+	nonce, proof, err := c.Prove(ctx, username, sk)
+	if err != nil {
+		return nil, err
+	}
+	pk := new(core.PKey)
+	if err = pk.FromSKey(sk); err != nil {
+		return nil, err
+	}
+	return c.SetUserKey(ctx, NewUserKey{
+		Pubkey: pk.Bytes(),
+		Nonce:  nonce,
+		Proof:  proof,
+	})
+}
+
+// StaleTags lists the tags of the current key epoch of the devspace
+// made only for replaced versions of the key of their sender. Their
+// trapdoors no longer match the messages of the sender, and are to be
+// re-issued by appending a trapdoor for the current key to the tag.
+// The current keys of the senders are verified against the key log.
+func (c *Client) StaleTags(ctx context.Context, space string) ([]Tag, error) {
+	key, err := c.SpaceKey(ctx, space)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := c.ListTags(ctx, space)
+	if err != nil {
+		return nil, err
+	}
+	type pair struct{ name, sender string }
+	current := make(map[string]int64)
+	reissued := make(map[pair]bool)
+	var candidates []Tag
+	for _, t := range tags {
+		if t.Epoch != key.Epoch || t.Sender == "" {
+			continue
+		}
+		version, ok := current[t.Sender]
+		if !ok {
+			u, err := c.User(ctx, t.Sender)
+			if err != nil {
+				return nil, err
+			}
+			err = c.VerifyKey(ctx, Binding{Kind: core.BindingUser, Name: t.Sender, Pubkey: u.Pubkey, Epoch: u.Version})
+			if err != nil {
+				return nil, err
+			}
+			version = u.Version
+			current[t.Sender] = version
+		}
+		if t.SenderKey == version {
+			reissued[pair{t.Name, t.Sender}] = true
+		} else {
+			candidates = append(candidates, t)
+		}
+	}
+	var stale []Tag
+	for _, t := range candidates {
+		if !reissued[pair{t.Name, t.Sender}] {
+			stale = append(stale, t)
+		}
+	}
+	return stale, nil
+}
+
+// Key returns the secret key of the devspace shared by the invite.
+func (i *Invite) Key() (*core.SKey, error) {
+	sk := new(core.SKey)
+	err := sk.FromBytes(i.Secret)
+	return sk, err
+}
@@ -0,0 +1,69 @@
+package client
+
+import "context"
+
+// ListSpaces lists the devspaces owned by the logged in user.
+func (c *Client) ListSpaces(ctx context.Context) ([]Space, error) {
+	var spaces []Space
+	err := c.do(ctx, "GET", nil, nil, &spaces, "/space/")
+	return spaces, err
+}
+
+// CreateSpace creates a devspace owned by the logged in user.
+func (c *Client) CreateSpace(ctx context.Context, s NewSpace) error {
+	return c.do(ctx, "POST", nil, s, nil, "/space/")
+}
+
+// ListTags lists the tags of a devspace.
+func (c *Client) ListTags(ctx context.Context, space string) ([]Tag, error) {
+	var tags []Tag
+	err := c.do(ctx, "GET", nil, nil, &tags, "/space/", space)
+	return tags, err
+}
+
+// CreateTag creates a tag on a devspace.
+func (c *Client) CreateTag(ctx context.Context, space string, t NewTag) error {
+	return c.do(ctx, "POST", nil, t, nil, "/space/", space)
+}
+
+// Invite invites a user to collaborate on a devspace.
+func (c *Client) Invite(ctx context.Context, space string, i Invitation) error {
+	return c.do(ctx, "POST", nil, i, nil, "/space/", space, "request")
+}
+
+// Members lists the collaborators invited to a devspace.
+func (c *Client) Members(ctx context.Context, space string) ([]UserKey, error) {
+	var members []UserKey
+	err := c.do(ctx, "GET", nil, nil, &members, "/space/", space, "members")
+	return members, err
+}
+
+// SpaceKey fetches the current public key of a devspace.
+func (c *Client) SpaceKey(ctx context.Context, space string) (*SpaceKey, error) {
+	key := new(SpaceKey)
+	err := c.do(ctx, "GET", nil, nil, key, "/space/", space, "pubkey")
+	return key, err
+}
+
+// RotateKey replaces the key pair of a devspace and returns
+// the new key epoch.
+func (c *Client) RotateKey(ctx context.Context, space string, r Rotation) (*Epoch, error) {
+	epoch := new(Epoch)
+	err := c.do(ctx, "PUT", nil, r, epoch, "/space/", space, "pubkey")
+	return epoch, err
+}
+
+// Retention fetches the retention policy of a devspace.
+func (c *Client) Retention(ctx context.Context, space string) (*Retention, error) {
+	r := new(Retention)
+	err := c.do(ctx, "GET", nil, nil, r, "/space/", space, "retention")
+	return r, err
+}
+
+// SetRetention updates the retention policy of a devspace
+// and returns the resulting policy.
+func (c *Client) SetRetention(ctx context.Context, space string, u RetentionUpdate) (*Retention, error) {
+	r := new(Retention)
+	err := c.do(ctx, "PUT", nil, u, r, "/space/", space, "retention")
+	return r, err
+}
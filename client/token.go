@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource supplies the login token of the requests.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a login token obtained beforehand.
+type StaticToken string
+
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+// refreshBefore is how long before its expiry a token is renewed.
+const refreshBefore = time.Minute
+
+// passwordLogin logs in with the credentials of the user
+// whenever the current login token is about to expire.
+type passwordLogin struct {
+	client *Client
+	cred   Credentials
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// PasswordLogin returns a token source which logs in to the
+// server at server with the credentials, and logs in again
+// once the login token is about to expire.
+func PasswordLogin(server string, cred Credentials) TokenSource {
+	return &passwordLogin{
+		client: New(server, nil),
+		cred:   cred,
+	}
+}
+
+func (p *passwordLogin) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && (p.expiry.IsZero() || time.Until(p.expiry) > refreshBefore) {
+		return p.token, nil
+	}
+	token, err := p.client.Login(ctx, p.cred)
+	if err != nil {
+		return "", err
+	}
+	p.token = token.Token
+	p.expiry = tokenExpiry(token.Token)
+	return p.token, nil
+}
+
+// tokenExpiry reads the expiry of the login token without verifying
+// it. The zero time is returned for tokens without a readable expiry,
+// which are taken to never expire.
+func tokenExpiry(token string) (never time.Time) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return never
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return never
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return never
+	}
+	return time.Unix(claims.Exp, 0)
+}
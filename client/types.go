@@ -0,0 +1,324 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Hex is binary data, such as a key or a trapdoor, which is
+// hex encoded in json.
+type Hex []byte
+
+func (h Hex) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+func (h *Hex) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*h = b
+	return nil
+}
+
+// Keyword encryption schemes of a devspace. Tags of the sender scheme
+// match the keyword from a single sender, those of the shared scheme
+// match it from any sender, whose messages are then signed.
+const (
+	SchemeSender = "sender"
+	SchemeShared = "shared"
+)
+
+// The types below mirror the schemas of the OpenAPI specification.
+
+type Registration struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Pubkey   Hex    `json:"pubkey"`
+	Nonce    Hex    `json:"nonce"`
+	Proof    Hex    `json:"proof"`
+}
+
+type Nonce struct {
+	Nonce   Hex       `json:"nonce"`
+	Expires time.Time `json:"expires"`
+}
+
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type Token struct {
+	Token string `json:"token"`
+}
+
+type UserKey struct {
+	Username string `json:"username"`
+	Pubkey   Hex    `json:"pubkey"`
+	Version  int64  `json:"version"`
+}
+
+// KeyVersion is a version of the public key of a user
+// with its validity period.
+type KeyVersion struct {
+	Version int64     `json:"version"`
+	Pubkey  Hex       `json:"pubkey"`
+	From    time.Time `json:"from"`
+	// Until is nil for the current key.
+	Until *time.Time `json:"until"`
+}
+
+type NewUserKey struct {
+	Pubkey Hex `json:"pubkey"`
+	Nonce  Hex `json:"nonce"`
+	Proof  Hex `json:"proof"`
+}
+
+type Version struct {
+	Version int64 `json:"version"`
+}
+
+type ServerKey struct {
+	Pubkey Hex `json:"pubkey"`
+	// LogPubkey verifies the signed tree heads of the key log.
+	LogPubkey Hex `json:"log_pubkey"`
+	// BlindPubkey blinds the trapdoors of the shared scheme.
+	BlindPubkey Hex `json:"blind_pubkey"`
+}
+
+// TreeHead is the signed head of the key log, whose timestamp is
+// in milliseconds since the epoch.
+type TreeHead struct {
+	Size      int64 `json:"size"`
+	Root      Hex   `json:"root"`
+	Timestamp int64 `json:"timestamp"`
+	Signature Hex   `json:"signature"`
+}
+
+type InclusionProof struct {
+	Index  int64 `json:"index"`
+	Size   int64 `json:"size"`
+	Hashes []Hex `json:"hashes"`
+}
+
+type ConsistencyProof struct {
+	First  int64 `json:"first"`
+	Second int64 `json:"second"`
+	Hashes []Hex `json:"hashes"`
+}
+
+type LogEntry struct {
+	Index  int64     `json:"index"`
+	Kind   string    `json:"kind"`
+	Name   string    `json:"name"`
+	Pubkey Hex       `json:"pubkey"`
+	Epoch  int64     `json:"epoch"`
+	Time   time.Time `json:"time"`
+}
+
+type NewSpace struct {
+	Name   string `json:"name"`
+	Pubkey Hex    `json:"pubkey"`
+	// Scheme defaults to the sender scheme if empty.
+	Scheme string `json:"scheme,omitempty"`
+}
+
+type Space struct {
+	Name   string `json:"name"`
+	Pubkey Hex    `json:"pubkey"`
+	Scheme string `json:"scheme"`
+}
+
+type SpaceKey struct {
+	Pubkey Hex    `json:"pubkey"`
+	Epoch  int64  `json:"epoch"`
+	Scheme string `json:"scheme"`
+}
+
+type NewTag struct {
+	Name     string `json:"name"`
+	Trapdoor Hex    `json:"trapdoor"`
+	// Append adds another trapdoor to an existing tag.
+	Append bool `json:"append,omitempty"`
+	// Sender is the user whose messages the trapdoor matches, which
+	// records the version of the key of the sender with the tag.
+	Sender string `json:"sender,omitempty"`
+}
+
+type Tag struct {
+	Name     string `json:"name"`
+	Trapdoor Hex    `json:"trapdoor"`
+	Epoch    int64  `json:"epoch"`
+	// Sender is empty and SenderKey zero when the
+	// tag was created without naming the sender.
+	Sender    string `json:"sender"`
+	SenderKey int64  `json:"sender_key"`
+}
+
+type Rotation struct {
+	Pubkey Hex      `json:"pubkey"`
+	Nonce  Hex      `json:"nonce"`
+	Proof  Hex      `json:"proof"`
+	Tags   []NewTag `json:"tags"`
+	// Window is the Go duration for which the previous key keeps
+	// routing messages, "0s" to retire it at once. The server default
+	// applies if empty.
+	Window string `json:"window,omitempty"`
+}
+
+type Epoch struct {
+	Epoch int64 `json:"epoch"`
+}
+
+type Invitation struct {
+	To     string `json:"to"`
+	Secret Hex    `json:"secret"`
+}
+
+type Invite struct {
+	From   string `json:"from"`
+	On     string `json:"on"`
+	To     string `json:"to"`
+	Secret Hex    `json:"secret"`
+}
+
+type AttachmentRef struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+}
+
+type NewMessage struct {
+	Data        Hex             `json:"data"`
+	Keyword     Hex             `json:"keyword"`
+	Attachments []AttachmentRef `json:"attachments,omitempty"`
+	Signature   Hex             `json:"signature,omitempty"`
+}
+
+type Attachment struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+type Message struct {
+	ID          int64        `json:"id"`
+	Received    time.Time    `json:"received"`
+	From        string       `json:"from"`
+	Tag         string       `json:"tag"`
+	Read        bool         `json:"read"`
+	Data        Hex          `json:"data"`
+	Keyword     Hex          `json:"keyword"`
+	Attachments []Attachment `json:"attachments"`
+	// Signature of the sender, empty for unsigned messages.
+	Signature Hex `json:"signature"`
+}
+
+type MessagePage struct {
+	Messages []Message `json:"messages"`
+	// Next is the cursor of the next page, nil on the last page.
+	Next *string `json:"next"`
+}
+
+// MessageQuery filters and pages the messages of a tag.
+// Zero values are left out of the query.
+type MessageQuery struct {
+	Limit  int
+	Cursor string
+	Since  time.Time
+	Until  time.Time
+	From   string
+	// Order is either asc or desc.
+	Order  string
+	Unread bool
+}
+
+func (q *MessageQuery) values() url.Values {
+	v := url.Values{}
+	if q == nil {
+		return v
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Cursor != "" {
+		v.Set("cursor", q.Cursor)
+	}
+	if !q.Since.IsZero() {
+		v.Set("since", q.Since.UTC().Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		v.Set("until", q.Until.UTC().Format(time.RFC3339))
+	}
+	if q.From != "" {
+		v.Set("from", q.From)
+	}
+	if q.Order != "" {
+		v.Set("order", q.Order)
+	}
+	if q.Unread {
+		v.Set("unread", "true")
+	}
+	return v
+}
+
+type Mark struct {
+	Read bool `json:"read"`
+}
+
+type Retention struct {
+	MaxAge   string `json:"max_age"`
+	MaxCount int    `json:"max_count"`
+}
+
+// RetentionUpdate changes the limits which are not nil.
+type RetentionUpdate struct {
+	MaxAge   *string `json:"max_age,omitempty"`
+	MaxCount *int    `json:"max_count,omitempty"`
+}
+
+type NewWebhook struct {
+	URL string `json:"url"`
+	Tag string `json:"tag,omitempty"`
+	// Secret signs the payloads, generated by the server if empty.
+	Secret string `json:"secret,omitempty"`
+}
+
+type Webhook struct {
+	ID      int64     `json:"id"`
+	URL     string    `json:"url"`
+	Tag     string    `json:"tag"`
+	Created time.Time `json:"created"`
+	// Secret is only returned when generated on creation.
+	Secret string `json:"secret,omitempty"`
+}
+
+type Delivery struct {
+	Message  int64     `json:"message"`
+	Attempt  int       `json:"attempt"`
+	Status   int       `json:"status"`
+	Error    string    `json:"error"`
+	Time     time.Time `json:"time"`
+	Duration string    `json:"duration"`
+}
+
+type DeadLetter struct {
+	Message  int64     `json:"message"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	Time     time.Time `json:"time"`
+	Payload  string    `json:"payload"`
+}
+
+type Blob struct {
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"strconv"
+)
+
+// CreateWebhook registers a webhook on a devspace.
+func (c *Client) CreateWebhook(ctx context.Context, space string, w NewWebhook) (*Webhook, error) {
+	hook := new(Webhook)
+	err := c.do(ctx, "POST", nil, w, hook, "/space/", space, "webhooks")
+	return hook, err
+}
+
+// ListWebhooks lists the webhooks of a devspace.
+func (c *Client) ListWebhooks(ctx context.Context, space string) ([]Webhook, error) {
+	var hooks []Webhook
+	err := c.do(ctx, "GET", nil, nil, &hooks, "/space/", space, "webhooks")
+	return hooks, err
+}
+
+// DeleteWebhook removes a webhook from a devspace.
+func (c *Client) DeleteWebhook(ctx context.Context, space string, id int64) error {
+	return c.do(ctx, "DELETE", nil, nil, nil, "/space/", space, "webhooks", strconv.FormatInt(id, 10))
+}
+
+// Deliveries lists the recent delivery attempts of a webhook.
+func (c *Client) Deliveries(ctx context.Context, space string, id int64) ([]Delivery, error) {
+	var log []Delivery
+	err := c.do(ctx, "GET", nil, nil, &log, "/space/", space, "webhooks", strconv.FormatInt(id, 10), "deliveries")
+	return log, err
+}
+
+// DeadLetters lists the deliveries of a webhook which failed
+// after all the retries.
+func (c *Client) DeadLetters(ctx context.Context, space string, id int64) ([]DeadLetter, error) {
+	var dead []DeadLetter
+	err := c.do(ctx, "GET", nil, nil, &dead, "/space/", space, "webhooks", strconv.FormatInt(id, 10), "dead")
+	return dead, err
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Keywords are canonicalized before they are hashed, so that "Deploy",
+// "deploy\n" and "deploy" produce the same ciphertext and trapdoor.
+// Every ciphertext and trapdoor starts with the version of the pipeline
+// that canonicalized its keyword, and Test matches a ciphertext against
+// a trapdoor of the same version alone.
+
+// Stemmer reduces a single word to its stem.
+type Stemmer func(word string) string
+
+// Canon is a keyword canonicalization pipeline. The steps run in the
+// order of the fields. Keywords that are not valid UTF-8 are hashed as
+// they are given.
+type Canon struct {
+	// Version identifies the pipeline in ciphertexts and trapdoors.
+	// Distinct pipelines must not share a version.
+	Version byte
+	// NFKC applies the Unicode compatibility normalization.
+	NFKC bool
+	// FoldCase applies the Unicode case folding.
+	FoldCase bool
+	// TrimSpace trims the keyword and collapses runs of white space
+	// into a single space.
+	TrimSpace bool
+	// Stem, if set, stems every word of the keyword.
+	Stem Stemmer
+}
+
+var (
+	// CanonRaw hashes the keyword bytes as they are given.
+	CanonRaw = &Canon{Version: 0}
+	// CanonV1 normalizes, case folds and trims the keyword.
+	CanonV1 = &Canon{Version: 1, NFKC: true, FoldCase: true, TrimSpace: true}
+	// CanonV1Stem is CanonV1 which also stems english words.
+	CanonV1Stem = &Canon{Version: 2, NFKC: true, FoldCase: true, TrimSpace: true, Stem: StemEnglish}
+)
+
+// ErrCanon is returned for ciphertexts and trapdoors of a pipeline
+// version that is not known.
+var ErrCanon = errors.New("unknown keyword canonicalization")
+
+// canons are the known pipelines by version.
+var canons = []*Canon{CanonRaw, CanonV1, CanonV1Stem}
+
+// CanonOf returns the pipeline that canonicalized the keyword of the
+// ciphertext or trapdoor m, e.g. to issue another trapdoor of the same
+// keyword.
+func CanonOf(m []byte) (*Canon, error) {
+	if len(m) < 2 {
+		return nil, ErrCanon
+	}
+	for _, c := range canons {
+		if c.Version == m[1] {
+			return c, nil
+		}
+	}
+	return nil, ErrCanon
+}
+
+// Apply returns the canonical form of word.
+func (c *Canon) Apply(word []byte) []byte {
+	if !utf8.Valid(word) {
+		return word
+	}
+	s := string(word)
+	if c.NFKC {
+		s = norm.NFKC.String(s)
+	}
+	if c.FoldCase {
+		s = cases.Fold().String(s)
+	}
+	if c.TrimSpace || c.Stem != nil {
+		fields := strings.Fields(s)
+		if c.Stem != nil {
+			for i, f := range fields {
+				fields[i] = c.Stem(f)
+			}
+		}
+		s = strings.Join(fields, " ")
+	}
+	return []byte(s)
+}
+
+// canonical returns the canonical form of word under the pipeline of
+// the scheme, along with the version of the pipeline.
+func (s Scheme) canonical(word []byte) (byte, []byte) {
+	c := s.Canon
+	if c == nil {
+		c = CanonV1
+	}
+	return c.Version, c.Apply(word)
+}
+
+// StemEnglish strips the common inflectional suffixes of an english
+// word, e.g. "deploys", "deployed" and "deploying" all stem to "deploy".
+// It is a light stemmer, and does not handle irregular forms.
+func StemEnglish(word string) string {
+	n := utf8.RuneCountInString(word)
+	switch {
+	case n > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case n > 4 && hasAnySuffix(word, "sses", "shes", "ches", "xes", "zzes"):
+		return word[:len(word)-2]
+	case n > 3 && strings.HasSuffix(word, "s") && !hasAnySuffix(word, "ss", "us", "is"):
+		return word[:len(word)-1]
+	case n > 5 && strings.HasSuffix(word, "ing") && hasVowel(word[:len(word)-3]):
+		return undouble(word[:len(word)-3])
+	case n > 4 && strings.HasSuffix(word, "ed") && hasVowel(word[:len(word)-2]):
+		return undouble(word[:len(word)-2])
+	}
+	return word
+}
+
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
+
+// undouble drops the doubled final consonant of a stem,
+// e.g. "runn" of "running".
+func undouble(stem string) string {
+	n := len(stem)
+	if n < 2 || stem[n-1] != stem[n-2] {
+		return stem
+	}
+	if strings.IndexByte("bfgmnprt", stem[n-1]) < 0 {
+		return stem
+	}
+	return stem[:n-1]
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCanonApply(t *testing.T) {
+	tests := []struct {
+		canon *Canon
+		word  string
+		want  string
+	}{
+		{CanonRaw, " Deploy\n", " Deploy\n"},
+		{CanonV1, "Deploy", "deploy"},
+		{CanonV1, "deploy\n", "deploy"},
+		{CanonV1, "  Deploy \t Prod ", "deploy prod"},
+		{CanonV1, "ＤＥＰＬＯＹ", "deploy"},
+		{CanonV1, "Straße", "strasse"},
+		{CanonV1, "été", "été"},
+		{CanonV1, "deploying", "deploying"},
+		{CanonV1Stem, "Deploying Fixes", "deploy fix"},
+		{CanonV1Stem, "running tests", "run test"},
+		{CanonV1, "\xff Deploy", "\xff Deploy"},
+	}
+	for _, tt := range tests {
+		got := string(tt.canon.Apply([]byte(tt.word)))
+		if got != tt.want {
+			t.Logf("expected: %q, got: %q", tt.want, got)
+			t.Fatalf("invalid canonical form of %q", tt.word)
+		}
+	}
+}
+
+func TestStemEnglish(t *testing.T) {
+	tests := map[string]string{
+		"deploy":    "deploy",
+		"deploys":   "deploy",
+		"deployed":  "deploy",
+		"deploying": "deploy",
+		"studies":   "study",
+		"passes":    "pass",
+		"status":    "status",
+		"string":    "string",
+		"stopped":   "stop",
+		"added":     "add",
+	}
+	for word, want := range tests {
+		got := StemEnglish(word)
+		if got != want {
+			t.Logf("expected: %v, got: %v", want, got)
+			t.Fatalf("invalid stem of %q", word)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	// setup server key pair
+	skServer, pkServer, err := KeyGenServer()
+	handleFatal(err, t)
+	// setup sender key pair
+	skSender, pkSender, err := KeyGen()
+	handleFatal(err, t)
+	// setup receiver key pair
+	skReceiver, pkReceiver, err := KeyGen()
+	handleFatal(err, t)
+
+	// run tests
+	t.Run("truthy", func(t *testing.T) {
+		ciphertext, err := PEKS(testSpace, []byte("Deploy\n"), pkServer, pkReceiver, skSender)
+		handleFatal(err, t)
+		trapdoor, err := Trapdoor(testSpace, []byte("deploy"), pkServer, pkSender, skReceiver)
+		handleFatal(err, t)
+		ok, err := Test(ciphertext, trapdoor, skServer)
+		handleFatal(err, t)
+		if !ok {
+			t.Logf("expected: %v, got: %v", true, ok)
+			t.Fatal("keywords of the same canonical form are expected to match")
+		}
+	})
+	t.Run("version", func(t *testing.T) {
+		ciphertext, err := PEKS(testSpace, []byte("deploy"), pkServer, pkReceiver, skSender)
+		handleFatal(err, t)
+		got := ciphertext[1]
+		want := CanonV1.Version
+		if got != want {
+			t.Logf("expected: %v, got: %v", want, got)
+			t.Fatal("ciphertext is expected to carry the version of the pipeline")
+		}
+	})
+	t.Run("falsey", func(t *testing.T) {
+		ciphertext, err := PEKS(testSpace, []byte("deploy"), pkServer, pkReceiver, skSender)
+		handleFatal(err, t)
+		// setup another pipeline
+		trapdoor, err := Scheme{Canon: CanonRaw}.Trapdoor(testSpace, []byte("deploy"), pkServer, pkSender, skReceiver)
+		handleFatal(err, t)
+		ok, err := Test(ciphertext, trapdoor, skServer)
+		handleFatal(err, t)
+		if ok {
+			t.Logf("expected: %v, got: %v", false, ok)
+			t.Fatal("keywords of distinct pipelines are expected not to match")
+		}
+	})
+	t.Run("shared", func(t *testing.T) {
+		ciphertext, err := PEKSShared(testSpace, []byte("  DEPLOY "), pkServer, pkReceiver)
+		handleFatal(err, t)
+		trapdoor, err := TrapdoorShared(testSpace, []byte("deploy"), blindKey(skServer, t), skReceiver)
+		handleFatal(err, t)
+		ok, err := TestShared(ciphertext, trapdoor, skServer)
+		handleFatal(err, t)
+		if !ok {
+			t.Logf("expected: %v, got: %v", true, ok)
+			t.Fatal("keywords of the same canonical form are expected to match")
+		}
+		if !bytes.Equal(ciphertext[:1], trapdoor[:1]) {
+			t.Logf("expected: %x, got: %x", ciphertext[:1], trapdoor[:1])
+			t.Fatal("ciphertext and trapdoor are expected to be of the same version")
+		}
+	})
+	t.Run("stem", func(t *testing.T) {
+		stem := Scheme{Canon: CanonV1Stem}
+		ciphertext, err := stem.PEKS(testSpace, []byte("deploying"), pkServer, pkReceiver, skSender)
+		handleFatal(err, t)
+		trapdoor, err := stem.Trapdoor(testSpace, []byte("Deploys"), pkServer, pkSender, skReceiver)
+		handleFatal(err, t)
+		ok, err := Test(ciphertext, trapdoor, skServer)
+		handleFatal(err, t)
+		if !ok {
+			t.Logf("expected: %v, got: %v", true, ok)
+			t.Fatal("keywords of the same stem are expected to match")
+		}
+		c, err := CanonOf(trapdoor)
+		handleFatal(err, t)
+		if c != CanonV1Stem {
+			t.Logf("expected: %v, got: %v", CanonV1Stem.Version, c.Version)
+			t.Fatal("incorrect pipeline of the trapdoor")
+		}
+	})
+}
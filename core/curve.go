@@ -0,0 +1,218 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// The keys, ciphertexts, trapdoors and signatures of every curve start
+// with the id of the curve, so that the elements of distinct curves are
+// never confused with each other.
+
+var ErrCurve = errors.New("unknown or mismatched curve")
+
+// Errors of the validation of decoded scalars and group elements.
+var (
+	ErrScalar   = errors.New("scalar out of range")
+	ErrIdentity = errors.New("identity element")
+	ErrSubgroup = errors.New("element not in the prime order subgroup")
+)
+
+// CurveID identifies a curve in the encoding of keys and ciphertexts.
+type CurveID byte
+
+const (
+	CurveBN254    CurveID = 1
+	CurveBLS12381 CurveID = 2
+)
+
+// Curve is a pairing friendly curve, with the groups G1, G2 and GT and
+// the pairing e: G1 x G2 -> GT. All of the groups are written additively.
+type Curve interface {
+	ID() CurveID
+	// String is the name of the curve.
+	String() string
+	// Order is the order of the groups.
+	Order() *big.Int
+	G1() Group
+	G2() Group
+	GT() Group
+	// Pair computes the pairing of p in G1 and q in G2.
+	Pair(p, q Element) Element
+	// HashToG1 hashes msg to G1 under the domain separation tag dst.
+	// The hash behaves as a random oracle.
+	HashToG1(msg, dst []byte) Element
+	// Suite names the hash to G1 in domain separation tags.
+	Suite() string
+}
+
+// Group is a group of a curve.
+type Group interface {
+	// New returns the identity of the group.
+	New() Element
+	// Size is the size of the encoding of an element.
+	Size() int
+}
+
+// Element is an element of a group. The methods set the receiver
+// to the result and return it, as math/big does.
+type Element interface {
+	Add(a, b Element) Element
+	Neg(a Element) Element
+	ScalarMult(a Element, k *big.Int) Element
+	// ScalarBaseMult multiplies the generator of the group by k.
+	// The generator of GT is the pairing of those of G1 and G2.
+	ScalarBaseMult(k *big.Int) Element
+	// Equal runs in constant time, as it compares secret elements,
+	// e.g. in Test.
+	Equal(b Element) bool
+	Marshal() []byte
+	// Unmarshal accepts the canonical encodings of the elements of the
+	// subgroup of prime order alone, and leaves the receiver unchanged
+	// on any other encoding.
+	Unmarshal(m []byte) error
+}
+
+// group is a group of the elements returned by new.
+type group struct {
+	new  func() Element
+	size int
+}
+
+func (g group) New() Element { return g.new() }
+func (g group) Size() int    { return g.size }
+
+// errSize is returned on encodings of the wrong size.
+var errSize = errors.New("invalid size of encoding")
+
+// errEncoding is returned on encodings which are not canonical.
+var errEncoding = errors.New("non-canonical encoding")
+
+// inSubgroup reports whether e of the group g of the curve is in the
+// subgroup of prime order, i.e. whether e multiplied by the order is
+// the identity. As scalars are reduced modulo the order, e multiplied
+// by the order minus one is added to e instead.
+func inSubgroup(c Curve, g Group, e Element) bool {
+	n := new(big.Int).Sub(c.Order(), big.NewInt(1))
+	t := g.New().ScalarMult(e, n)
+	return t.Add(t, e).Equal(g.New())
+}
+
+// isIdentity reports whether e is the identity of the group g.
+func isIdentity(g Group, e Element) bool {
+	return e.Equal(g.New())
+}
+
+// checkScalar checks that k is a scalar of the curve other than zero.
+func checkScalar(c Curve, k *big.Int) error {
+	if k == nil || k.Sign() <= 0 || k.Cmp(c.Order()) >= 0 {
+		return ErrScalar
+	}
+	return nil
+}
+
+var (
+	BN254    Curve = bn254Curve{}
+	BLS12381 Curve = bls12381Curve{}
+)
+
+// Curves are the curves which keys are computed on.
+var Curves = []Curve{BN254, BLS12381}
+
+// DefaultCurve is the curve of the keys generated by KeyGen and KeyGenServer.
+var DefaultCurve = BLS12381
+
+// CurveByID returns the curve identified by id.
+func CurveByID(id CurveID) (Curve, error) {
+	for _, c := range Curves {
+		if c.ID() == id {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: id %d", ErrCurve, id)
+}
+
+// CurveByName returns the curve of the name.
+func CurveByName(name string) (Curve, error) {
+	for _, c := range Curves {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrCurve, name)
+}
+
+// sameCurve returns the curve shared by all of the keys.
+func sameCurve(curves ...Curve) (Curve, error) {
+	for _, c := range curves {
+		if c == nil || c.ID() != curves[0].ID() {
+			return nil, ErrCurve
+		}
+	}
+	return curves[0], nil
+}
+
+// zeroInt overwrites the words of k with zeroes, including
+// those of the spare capacity, and sets k to zero.
+func zeroInt(k *big.Int) {
+	b := k.Bits()
+	b = b[:cap(b)]
+	for i := range b {
+		b[i] = 0
+	}
+	k.SetInt64(0)
+}
+
+// zeroBytes overwrites b with zeroes.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// randomScalar samples a scalar of the curve other than zero from r. As
+// many bytes as the order takes are read, the bits above the length of
+// the order are cleared, and the big endian integer is rejected unless
+// 0 < k < order, in which case the sampling starts over.
+func randomScalar(r io.Reader, c Curve) (*big.Int, error) {
+	n := c.Order()
+	b := make([]byte, (n.BitLen()+7)/8)
+	defer zeroBytes(b)
+	mask := byte(0xff)
+	if bits := n.BitLen() % 8; bits != 0 {
+		mask = byte(1<<bits - 1)
+	}
+	k := new(big.Int)
+	for {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, errors.Join(ErrRandom, err)
+		}
+		b[0] &= mask
+		k.SetBytes(b)
+		if k.Sign() > 0 && k.Cmp(n) < 0 {
+			return k, nil
+		}
+	}
+}
+
+// Sizes of the encodings of the curve.
+
+// SizeSK is the size of a secret key.
+func SizeSK(c Curve) int { return 1 + (c.Order().BitLen()+7)/8 }
+
+// SizeCiphertext is the size of a ciphertext of PEKS.
+func SizeCiphertext(c Curve) int { return 2 + 2*c.GT().Size() }
+
+// SizeTrapdoor is the size of a trapdoor of PEKS.
+func SizeTrapdoor(c Curve) int { return 2 + 2*c.GT().Size() }
+
+// SizeSharedCT is the size of a ciphertext of the shared scheme.
+func SizeSharedCT(c Curve) int { return 2 + c.G2().Size() + 2*c.GT().Size() }
+
+// SizeSharedTD is the size of a trapdoor of the shared scheme.
+func SizeSharedTD(c Curve) int { return 2 + 2*c.G1().Size() }
+
+// SizeSignature is the size of a signature.
+func SizeSignature(c Curve) int { return 1 + c.G1().Size() }
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"math/big"
+	"sync"
+
+	bls "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// BLS12381 is the curve of github.com/cloudflare/circl, whose security
+// level is about 128 bits. Points of G1 and G2 are compressed, and
+// hashes to G1 follow RFC 9380.
+
+type bls12381Curve struct{}
+
+var blsOrder = new(big.Int).SetBytes(bls.Order())
+
+var (
+	genGTOnce sync.Once
+	genGT     *bls.Gt
+)
+
+// blsGenerator returns the generator of GT, the pairing of those of G1 and G2.
+func blsGenerator() *bls.Gt {
+	genGTOnce.Do(func() {
+		genGT = bls.Pair(bls.G1Generator(), bls.G2Generator())
+	})
+	return genGT
+}
+
+func (bls12381Curve) ID() CurveID     { return CurveBLS12381 }
+func (bls12381Curve) String() string  { return "bls12-381" }
+func (bls12381Curve) Order() *big.Int { return blsOrder }
+func (bls12381Curve) G1() Group       { return blsGroups[0] }
+func (bls12381Curve) G2() Group       { return blsGroups[1] }
+func (bls12381Curve) GT() Group       { return blsGroups[2] }
+func (bls12381Curve) Suite() string   { return "BLS12381G1_XMD:SHA-256_SSWU_RO_" }
+
+func (bls12381Curve) Pair(p, q Element) Element {
+	// Pair normalizes the point of G1 in place
+	P := *p.(*blsG1).p
+	return &blsGT{bls.Pair(&P, q.(*blsG2).p)}
+}
+
+func (bls12381Curve) HashToG1(msg, dst []byte) Element {
+	p := new(bls.G1)
+	p.Hash(msg, dst)
+	return &blsG1{p}
+}
+
+var blsGroups = [...]group{
+	{func() Element { p := new(bls.G1); p.SetIdentity(); return &blsG1{p} }, bls.G1SizeCompressed},
+	{func() Element { p := new(bls.G2); p.SetIdentity(); return &blsG2{p} }, bls.G2SizeCompressed},
+	{func() Element { p := new(bls.Gt); p.SetIdentity(); return &blsGT{p} }, bls.GtSize},
+}
+
+// blsScalar converts k to a scalar, modulo the order of the groups.
+// Scalars in range, as secret keys are, go through an encoding of fixed
+// size, so that the length of k does not leak. The arithmetic of circl
+// on scalars and elements is constant time from there on.
+func blsScalar(k *big.Int) *bls.Scalar {
+	if k.Sign() < 0 || k.Cmp(blsOrder) >= 0 {
+		k = new(big.Int).Mod(k, blsOrder)
+	}
+	var b [bls.ScalarSize]byte
+	k.FillBytes(b[:])
+	s := new(bls.Scalar)
+	s.SetBytes(b[:])
+	zeroBytes(b[:])
+	return s
+}
+
+type blsG1 struct{ p *bls.G1 }
+
+func (e *blsG1) Add(a, b Element) Element {
+	p := new(bls.G1)
+	p.Add(a.(*blsG1).p, b.(*blsG1).p)
+	e.p = p
+	return e
+}
+
+func (e *blsG1) Neg(a Element) Element {
+	p := *a.(*blsG1).p
+	p.Neg()
+	e.p = &p
+	return e
+}
+
+func (e *blsG1) ScalarMult(a Element, k *big.Int) Element {
+	p := new(bls.G1)
+	p.ScalarMult(blsScalar(k), a.(*blsG1).p)
+	e.p = p
+	return e
+}
+
+func (e *blsG1) ScalarBaseMult(k *big.Int) Element {
+	p := new(bls.G1)
+	p.ScalarMult(blsScalar(k), bls.G1Generator())
+	e.p = p
+	return e
+}
+
+func (e *blsG1) Equal(b Element) bool { return e.p.IsEqual(b.(*blsG1).p) }
+func (e *blsG1) Marshal() []byte      { return e.p.BytesCompressed() }
+
+func (e *blsG1) Unmarshal(m []byte) error {
+	if len(m) != bls.G1SizeCompressed {
+		return errSize
+	}
+	// SetBytes checks that the point is in the subgroup
+	p := new(bls.G1)
+	if err := p.SetBytes(m); err != nil {
+		return err
+	}
+	e.p = p
+	return nil
+}
+
+type blsG2 struct{ p *bls.G2 }
+
+func (e *blsG2) Add(a, b Element) Element {
+	p := new(bls.G2)
+	p.Add(a.(*blsG2).p, b.(*blsG2).p)
+	e.p = p
+	return e
+}
+
+func (e *blsG2) Neg(a Element) Element {
+	p := *a.(*blsG2).p
+	p.Neg()
+	e.p = &p
+	return e
+}
+
+func (e *blsG2) ScalarMult(a Element, k *big.Int) Element {
+	p := new(bls.G2)
+	p.ScalarMult(blsScalar(k), a.(*blsG2).p)
+	e.p = p
+	return e
+}
+
+func (e *blsG2) ScalarBaseMult(k *big.Int) Element {
+	p := new(bls.G2)
+	p.ScalarMult(blsScalar(k), bls.G2Generator())
+	e.p = p
+	return e
+}
+
+func (e *blsG2) Equal(b Element) bool { return e.p.IsEqual(b.(*blsG2).p) }
+func (e *blsG2) Marshal() []byte      { return e.p.BytesCompressed() }
+
+func (e *blsG2) Unmarshal(m []byte) error {
+	if len(m) != bls.G2SizeCompressed {
+		return errSize
+	}
+	// SetBytes checks that the point is in the subgroup
+	p := new(bls.G2)
+	if err := p.SetBytes(m); err != nil {
+		return err
+	}
+	e.p = p
+	return nil
+}
+
+type blsGT struct{ p *bls.Gt }
+
+func (e *blsGT) Add(a, b Element) Element {
+	p := new(bls.Gt)
+	p.Mul(a.(*blsGT).p, b.(*blsGT).p)
+	e.p = p
+	return e
+}
+
+func (e *blsGT) Neg(a Element) Element {
+	p := new(bls.Gt)
+	p.Inv(a.(*blsGT).p)
+	e.p = p
+	return e
+}
+
+func (e *blsGT) ScalarMult(a Element, k *big.Int) Element {
+	p := new(bls.Gt)
+	p.Exp(a.(*blsGT).p, blsScalar(k))
+	e.p = p
+	return e
+}
+
+func (e *blsGT) ScalarBaseMult(k *big.Int) Element {
+	p := new(bls.Gt)
+	p.Exp(blsGenerator(), blsScalar(k))
+	e.p = p
+	return e
+}
+
+func (e *blsGT) Equal(b Element) bool { return e.p.IsEqual(b.(*blsGT).p) }
+
+func (e *blsGT) Marshal() []byte {
+	m, _ := e.p.MarshalBinary()
+	return m
+}
+
+func (e *blsGT) Unmarshal(m []byte) error {
+	if len(m) != bls.GtSize {
+		return errSize
+	}
+	p := new(bls.Gt)
+	if err := p.UnmarshalBinary(m); err != nil {
+		return err
+	}
+	// UnmarshalBinary accepts any element of the field, whose
+	// inverse is computed correctly in the subgroup alone
+	if !inSubgroup(BLS12381, BLS12381.GT(), &blsGT{p}) {
+		return ErrSubgroup
+	}
+	e.p = p
+	return nil
+}
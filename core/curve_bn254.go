@@ -0,0 +1,184 @@
+package core
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"math/big"
+
+	"github.com/cloudflare/bn256"
+)
+
+// BN254 is the curve of github.com/cloudflare/bn256, whose security
+// level is about 100 bits. It is kept for the keys generated on it.
+// The scalar multiplications of bn256 are not constant time, which is
+// one more reason to prefer BLS12-381.
+
+type bn254Curve struct{}
+
+func (bn254Curve) ID() CurveID     { return CurveBN254 }
+func (bn254Curve) String() string  { return "bn254" }
+func (bn254Curve) Order() *big.Int { return bn256.Order }
+func (bn254Curve) G1() Group       { return bn254Groups[0] }
+func (bn254Curve) G2() Group       { return bn254Groups[1] }
+func (bn254Curve) GT() Group       { return bn254Groups[2] }
+func (bn254Curve) Suite() string   { return "BN254G1_HKDF-SHA-256_FT_RO_" }
+
+func (bn254Curve) Pair(p, q Element) Element {
+	return &bn254GT{bn256.Pair(p.(*bn254G1).p, q.(*bn254G2).p)}
+}
+
+// HashToG1 adds the encodings of two independent field elements, so
+// that the hash behaves as a random oracle, which a single encoding
+// of bn256.HashG1 does not.
+func (bn254Curve) HashToG1(msg, dst []byte) Element {
+	u0 := bn256.HashG1(msg, append(dst[:len(dst):len(dst)], 0))
+	u1 := bn256.HashG1(msg, append(dst[:len(dst):len(dst)], 1))
+	return &bn254G1{u0.Add(u0, u1)}
+}
+
+var bn254Groups = [...]group{
+	{func() Element { return &bn254G1{new(bn256.G1)} }, 64},
+	{func() Element { return &bn254G2{new(bn256.G2)} }, 129},
+	{func() Element { return &bn254GT{new(bn256.GT)} }, 384},
+}
+
+// G1 of bn254 is of prime order, whose points on the curve are all in
+// the subgroup. The points of G2 and the elements of GT are checked.
+
+type bn254G1 struct{ p *bn256.G1 }
+
+func (e *bn254G1) Add(a, b Element) Element {
+	e.p = new(bn256.G1).Add(a.(*bn254G1).p, b.(*bn254G1).p)
+	return e
+}
+
+func (e *bn254G1) Neg(a Element) Element {
+	e.p = new(bn256.G1).Neg(a.(*bn254G1).p)
+	return e
+}
+
+func (e *bn254G1) ScalarMult(a Element, k *big.Int) Element {
+	e.p = new(bn256.G1).ScalarMult(a.(*bn254G1).p, k)
+	return e
+}
+
+func (e *bn254G1) ScalarBaseMult(k *big.Int) Element {
+	e.p = new(bn256.G1).ScalarBaseMult(k)
+	return e
+}
+
+func (e *bn254G1) Equal(b Element) bool {
+	return subtle.ConstantTimeCompare(e.Marshal(), b.Marshal()) == 1
+}
+
+func (e *bn254G1) Marshal() []byte { return e.p.Marshal() }
+
+func (e *bn254G1) Unmarshal(m []byte) error {
+	if len(m) != BN254.G1().Size() {
+		return errSize
+	}
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(m); err != nil {
+		return err
+	}
+	// coordinates are not reduced on decoding
+	if !bytes.Equal(p.Marshal(), m) {
+		return errEncoding
+	}
+	e.p = p
+	return nil
+}
+
+type bn254G2 struct{ p *bn256.G2 }
+
+func (e *bn254G2) Add(a, b Element) Element {
+	e.p = new(bn256.G2).Add(a.(*bn254G2).p, b.(*bn254G2).p)
+	return e
+}
+
+func (e *bn254G2) Neg(a Element) Element {
+	e.p = new(bn256.G2).Neg(a.(*bn254G2).p)
+	return e
+}
+
+func (e *bn254G2) ScalarMult(a Element, k *big.Int) Element {
+	e.p = new(bn256.G2).ScalarMult(a.(*bn254G2).p, k)
+	return e
+}
+
+func (e *bn254G2) ScalarBaseMult(k *big.Int) Element {
+	e.p = new(bn256.G2).ScalarBaseMult(k)
+	return e
+}
+
+func (e *bn254G2) Equal(b Element) bool {
+	return subtle.ConstantTimeCompare(e.Marshal(), b.Marshal()) == 1
+}
+
+func (e *bn254G2) Marshal() []byte { return e.p.Marshal() }
+
+func (e *bn254G2) Unmarshal(m []byte) error {
+	if len(m) != BN254.G2().Size() {
+		return errSize
+	}
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(m); err != nil {
+		return err
+	}
+	// coordinates are not reduced on decoding
+	if !bytes.Equal(p.Marshal(), m) {
+		return errEncoding
+	}
+	if !inSubgroup(BN254, BN254.G2(), &bn254G2{p}) {
+		return ErrSubgroup
+	}
+	e.p = p
+	return nil
+}
+
+type bn254GT struct{ p *bn256.GT }
+
+func (e *bn254GT) Add(a, b Element) Element {
+	e.p = new(bn256.GT).Add(a.(*bn254GT).p, b.(*bn254GT).p)
+	return e
+}
+
+func (e *bn254GT) Neg(a Element) Element {
+	e.p = new(bn256.GT).Neg(a.(*bn254GT).p)
+	return e
+}
+
+func (e *bn254GT) ScalarMult(a Element, k *big.Int) Element {
+	e.p = new(bn256.GT).ScalarMult(a.(*bn254GT).p, k)
+	return e
+}
+
+func (e *bn254GT) ScalarBaseMult(k *big.Int) Element {
+	e.p = new(bn256.GT).ScalarBaseMult(k)
+	return e
+}
+
+func (e *bn254GT) Equal(b Element) bool {
+	return subtle.ConstantTimeCompare(e.Marshal(), b.Marshal()) == 1
+}
+
+func (e *bn254GT) Marshal() []byte { return e.p.Marshal() }
+
+func (e *bn254GT) Unmarshal(m []byte) error {
+	if len(m) != BN254.GT().Size() {
+		return errSize
+	}
+	p := new(bn256.GT)
+	if _, err := p.Unmarshal(m); err != nil {
+		return err
+	}
+	// coordinates are not reduced on decoding
+	if !bytes.Equal(p.Marshal(), m) {
+		return errEncoding
+	}
+	if !inSubgroup(BN254, BN254.GT(), &bn254GT{p}) {
+		return ErrSubgroup
+	}
+	e.p = p
+	return nil
+}
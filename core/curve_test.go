@@ -0,0 +1,196 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestCurves(t *testing.T) {
+	for _, curve := range Curves {
+		t.Run(curve.String(), func(t *testing.T) {
+			// setup key pairs on the curve
+			skServer, pkServer, err := KeyGenServerCurve(curve)
+			handleFatal(err, t)
+			skSender, pkSender, err := KeyGenCurve(curve)
+			handleFatal(err, t)
+			skReceiver, pkReceiver, err := KeyGenCurve(curve)
+			handleFatal(err, t)
+
+			// run tests
+			t.Run("peks", func(t *testing.T) {
+				ciphertext, err := PEKS(testSpace, []byte("deploy"), pkServer, pkReceiver, skSender)
+				handleFatal(err, t)
+				if ciphertext[0] != byte(curve.ID()) {
+					t.Logf("expected: %v, got: %v", curve.ID(), ciphertext[0])
+					t.Fatal("ciphertext is expected to start with the curve id")
+				}
+				trapdoor, err := Trapdoor(testSpace, []byte("deploy"), pkServer, pkSender, skReceiver)
+				handleFatal(err, t)
+				ok, err := Test(ciphertext, trapdoor, skServer)
+				handleFatal(err, t)
+				if !ok {
+					t.Fatal("Test is expected to pass")
+				}
+				trapdoor, err = Trapdoor(testSpace, []byte("release"), pkServer, pkSender, skReceiver)
+				handleFatal(err, t)
+				ok, err = Test(ciphertext, trapdoor, skServer)
+				handleFatal(err, t)
+				if ok {
+					t.Fatal("Test is expected to fail")
+				}
+			})
+			t.Run("shared", func(t *testing.T) {
+				ciphertext, err := PEKSShared(testSpace, []byte("deploy"), pkServer, pkReceiver)
+				handleFatal(err, t)
+				trapdoor, err := TrapdoorShared(testSpace, []byte("deploy"), blindKey(skServer, t), skReceiver)
+				handleFatal(err, t)
+				ok, err := TestShared(ciphertext, trapdoor, skServer)
+				handleFatal(err, t)
+				if !ok {
+					t.Fatal("TestShared is expected to pass")
+				}
+			})
+			t.Run("sign", func(t *testing.T) {
+				msg := MessageDigest(testSpace, []byte("keyword"), []byte("data"), nil)
+				ok, err := Verify(msg, Sign(msg, skSender), pkSender)
+				handleFatal(err, t)
+				if !ok {
+					t.Fatal("signature is expected to verify")
+				}
+			})
+		})
+	}
+	t.Run("mismatch", func(t *testing.T) {
+		_, pkServer, err := KeyGenServerCurve(BN254)
+		handleFatal(err, t)
+		skSender, _, err := KeyGenCurve(BLS12381)
+		handleFatal(err, t)
+		_, pkReceiver, err := KeyGenCurve(BLS12381)
+		handleFatal(err, t)
+		_, err = PEKS(testSpace, []byte("deploy"), pkServer, pkReceiver, skSender)
+		got := err
+		want := ErrCurve
+		if !errors.Is(got, want) {
+			t.Logf("expected: %v, got: %v", want, got)
+			t.Fatal("mismatched curve error is expected")
+		}
+	})
+	t.Run("ciphertext", func(t *testing.T) {
+		skServer, pkServer, err := KeyGenServerCurve(BLS12381)
+		handleFatal(err, t)
+		skSender, pkSender, err := KeyGenCurve(BLS12381)
+		handleFatal(err, t)
+		skReceiver, pkReceiver, err := KeyGenCurve(BLS12381)
+		handleFatal(err, t)
+		ciphertext, err := PEKS(testSpace, []byte("deploy"), pkServer, pkReceiver, skSender)
+		handleFatal(err, t)
+		trapdoor, err := Trapdoor(testSpace, []byte("deploy"), pkServer, pkSender, skReceiver)
+		handleFatal(err, t)
+		// the server of another curve rejects the ciphertext
+		other := &SKey{Curve: BN254, Key: skServer.Key}
+		_, err = Test(ciphertext, trapdoor, other)
+		got := err
+		want := ErrCiphertext
+		if !errors.Is(got, want) {
+			t.Logf("expected: %v, got: %v", want, got)
+			t.Fatal("invalid ciphertext error is expected")
+		}
+	})
+}
+
+func TestCurveByName(t *testing.T) {
+	for _, curve := range Curves {
+		got, err := CurveByName(curve.String())
+		handleFatal(err, t)
+		if got != curve {
+			t.Logf("expected: %v, got: %v", curve, got)
+			t.Fatal("incorrect curve of the name")
+		}
+	}
+	_, err := CurveByName("p256")
+	got := err
+	want := ErrCurve
+	if !errors.Is(got, want) {
+		t.Logf("expected: %v, got: %v", want, got)
+		t.Fatal("unknown curve error is expected")
+	}
+}
+
+func TestValidation(t *testing.T) {
+	for _, curve := range Curves {
+		t.Run(curve.String(), func(t *testing.T) {
+			skServer, pkServer, err := KeyGenServerCurve(curve)
+			handleFatal(err, t)
+			skSender, pkSender, err := KeyGenCurve(curve)
+			handleFatal(err, t)
+			skReceiver, _, err := KeyGenCurve(curve)
+			handleFatal(err, t)
+			// tamper flips the last bit of the encoding, which is
+			// no longer that of an element of the subgroup
+			tamper := func(m []byte) []byte {
+				m = append([]byte(nil), m...)
+				m[len(m)-1] ^= 1
+				return m
+			}
+
+			// run tests
+			t.Run("scalar", func(t *testing.T) {
+				for _, k := range []*big.Int{big.NewInt(0), curve.Order()} {
+					m := append([]byte{byte(curve.ID())}, k.FillBytes(make([]byte, SizeSK(curve)-1))...)
+					got := new(SKey).FromBytes(m)
+					if !errors.Is(got, ErrKey) || !errors.Is(got, ErrScalar) {
+						t.Logf("expected: %v, got: %v", ErrScalar, got)
+						t.Fatal("scalar out of range error is expected")
+					}
+				}
+			})
+			t.Run("identity", func(t *testing.T) {
+				id := curve.G2().New().ScalarBaseMult(big.NewInt(0))
+				m := append([]byte{byte(curve.ID())}, id.Marshal()...)
+				got := new(PKey).FromBytes(m)
+				if !errors.Is(got, ErrKey) {
+					t.Logf("expected: %v, got: %v", ErrKey, got)
+					t.Fatal("invalid key error is expected")
+				}
+			})
+			t.Run("subgroup", func(t *testing.T) {
+				got := new(PKeyServer).FromBytes(tamper(pkServer.Bytes()))
+				if !errors.Is(got, ErrKey) {
+					t.Logf("expected: %v, got: %v", ErrKey, got)
+					t.Fatal("invalid key error is expected")
+				}
+			})
+			t.Run("trapdoor", func(t *testing.T) {
+				trapdoor, err := Trapdoor(testSpace, []byte("deploy"), pkServer, pkSender, skReceiver)
+				handleFatal(err, t)
+				handleFatal(ValidateTrapdoor(curve, trapdoor), t)
+				got := ValidateTrapdoor(curve, tamper(trapdoor))
+				if !errors.Is(got, ErrTrapdoor) {
+					t.Logf("expected: %v, got: %v", ErrTrapdoor, got)
+					t.Fatal("invalid trapdoor error is expected")
+				}
+				ciphertext, err := PEKS(testSpace, []byte("deploy"), pkServer, pkSender, skReceiver)
+				handleFatal(err, t)
+				_, got = Test(tamper(ciphertext), trapdoor, skServer)
+				if !errors.Is(got, ErrCiphertext) {
+					t.Logf("expected: %v, got: %v", ErrCiphertext, got)
+					t.Fatal("invalid ciphertext error is expected")
+				}
+			})
+			t.Run("shared", func(t *testing.T) {
+				trapdoor, err := TrapdoorShared(testSpace, []byte("deploy"), blindKey(skServer, t), skSender)
+				handleFatal(err, t)
+				handleFatal(ValidateTrapdoorShared(curve, trapdoor), t)
+				// the blinded element is the identity
+				n := len(trapdoor) - curve.G1().Size()
+				id := curve.G1().New().ScalarBaseMult(big.NewInt(0))
+				got := ValidateTrapdoorShared(curve, append(trapdoor[:n:n], id.Marshal()...))
+				if !errors.Is(got, ErrTrapdoor) {
+					t.Logf("expected: %v, got: %v", ErrTrapdoor, got)
+					t.Fatal("invalid trapdoor error is expected")
+				}
+			})
+		})
+	}
+}
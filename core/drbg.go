@@ -0,0 +1,43 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// DRBG is a deterministic random bit generator, whose output is a
+// function of its seed alone. It is meant for known answer tests, as
+// those of testdata/vectors.json, and never for keys in use.
+//
+// The output is the concatenation of the blocks SHA-256(seed || i), of
+// the counter i encoded as 8 bytes big endian, starting from zero.
+type DRBG struct {
+	seed    []byte
+	counter uint64
+	block   []byte
+}
+
+// NewDRBG returns the DRBG of the seed.
+func NewDRBG(seed []byte) *DRBG {
+	return &DRBG{seed: append([]byte(nil), seed...)}
+}
+
+// Read fills p with the next bytes of the output. It never fails.
+func (d *DRBG) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(d.block) == 0 {
+			var i [8]byte
+			binary.BigEndian.PutUint64(i[:], d.counter)
+			d.counter++
+			h := sha256.New()
+			h.Write(d.seed)
+			h.Write(i[:])
+			d.block = h.Sum(nil)
+		}
+		c := copy(p[n:], d.block)
+		d.block = d.block[c:]
+		n += c
+	}
+	return n, nil
+}
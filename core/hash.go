@@ -0,0 +1,39 @@
+package core
+
+import "encoding/binary"
+
+// Keywords are hashed to G1 under a domain separation tag naming the
+// hash suite of the curve, the scheme, the version of the keyword
+// canonicalization and the devspace, so that the hash of a keyword in
+// one devspace cannot be correlated with its hash in another, nor with
+// hashes of any other protocol.
+
+// Schemes named in the domain separation tags of keyword hashes.
+const (
+	hashSchemePEKS   = "peks"
+	hashSchemeShared = "shared"
+)
+
+// dstPrefix is the prefix of the domain separation tags of keywords,
+// which is followed by the suite of the curve.
+const dstPrefix = "DEVSPACES-V01-CS01-with-"
+
+// keywordDST returns the domain separation tag of the hashes of keywords
+// of the scheme, canonicalized by the pipeline of the version, in space.
+// Every field is length prefixed so that no two tags share an encoding.
+func keywordDST(curve Curve, scheme string, version byte, space string) []byte {
+	dst := []byte(dstPrefix + curve.Suite())
+	field := func(b []byte) {
+		dst = binary.BigEndian.AppendUint16(dst, uint16(len(b)))
+		dst = append(dst, b...)
+	}
+	field([]byte(scheme))
+	field([]byte{version})
+	field([]byte(space))
+	return dst
+}
+
+// hashKeyword hashes the canonical keyword w of the version to G1.
+func hashKeyword(curve Curve, scheme string, version byte, space string, w []byte) Element {
+	return curve.HashToG1(w, keywordDST(curve, scheme, version, space))
+}
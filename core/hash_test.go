@@ -0,0 +1,127 @@
+package core
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// hashVectors are the keyword hashes to G1, which ciphertexts and
+// trapdoors computed by distinct implementations have to agree on.
+var hashVectors = []struct {
+	curve   Curve
+	scheme  string
+	version byte
+	space   string
+	word    string
+	dst     string
+	hash    string
+}{
+	{
+		BN254, hashSchemePEKS, 1, "proj", "deploy",
+		"4445565350414345532d5630312d435330312d776974682d424e32353447315f484b44462d5348412d3235365f46545f524f5f000470656b73000101000470726f6a",
+		"27617e1a4af57edabe5bfd63a016cfe5acac9bc9905100db19ca94feb9b9d3b1431cb5d65f45425d5363ab3fb5669790d28e7a13c06cb7a2761766b83da836db",
+	},
+	{
+		BN254, hashSchemePEKS, 1, "other", "deploy",
+		"4445565350414345532d5630312d435330312d776974682d424e32353447315f484b44462d5348412d3235365f46545f524f5f000470656b7300010100056f74686572",
+		"866a0deb38a8f56438ceb0f8b8986f110de2f89c8c4ac2cec77a878241580ff0725f3db370aca800edfa8c50aa09f2618fe90d720511d81040ecdc2babeb01a1",
+	},
+	{
+		BN254, hashSchemeShared, 1, "proj", "deploy",
+		"4445565350414345532d5630312d435330312d776974682d424e32353447315f484b44462d5348412d3235365f46545f524f5f0006736861726564000101000470726f6a",
+		"7d40deb4c0e8a37abc772f66a1599a288f41ae5c763a29d5c4f7d1d2f54260e67423423736c62af98ff00ab6c2a9dfd562b97e94f0d2c36224938855a7b77c03",
+	},
+	{
+		BN254, hashSchemePEKS, 2, "proj", "deploy",
+		"4445565350414345532d5630312d435330312d776974682d424e32353447315f484b44462d5348412d3235365f46545f524f5f000470656b73000102000470726f6a",
+		"7e950d61a24d13dfe4afb7f012e7190a081ee0666608111526c4c278d739a95448d37fd277b5126ee6691ab1b72959f2bbce94b7d4f731750b9eeac208a58636",
+	},
+	{
+		BN254, hashSchemePEKS, 1, "proj", "",
+		"4445565350414345532d5630312d435330312d776974682d424e32353447315f484b44462d5348412d3235365f46545f524f5f000470656b73000101000470726f6a",
+		"1ca6a5058bbe48cc9d3b6ccc4caf7a7e519f31be88f91fd8ab56051a05a93bba624ff0ff58955f47a18d4d45ee19bd69194570b52283874f9babe842c97427a5",
+	},
+	{
+		BLS12381, hashSchemePEKS, 1, "proj", "deploy",
+		"4445565350414345532d5630312d435330312d776974682d424c53313233383147315f584d443a5348412d3235365f535357555f524f5f000470656b73000101000470726f6a",
+		"8d8e300693059016bcf19b1d7da17be7251d2a28cec8bb9e5122a98c9902f95f2a21484f14c1b6e7db216da243242b55",
+	},
+	{
+		BLS12381, hashSchemePEKS, 1, "other", "deploy",
+		"4445565350414345532d5630312d435330312d776974682d424c53313233383147315f584d443a5348412d3235365f535357555f524f5f000470656b7300010100056f74686572",
+		"965f9a693f410c60bd94ce617400981e9e63d50efd98ff95ca4a999f41b25056c16470d997302de7cb239db1bfb3207d",
+	},
+	{
+		BLS12381, hashSchemeShared, 1, "proj", "deploy",
+		"4445565350414345532d5630312d435330312d776974682d424c53313233383147315f584d443a5348412d3235365f535357555f524f5f0006736861726564000101000470726f6a",
+		"b13819eac8ec20cc237e43ba5aef635b6c0579de1414f6b455c2eb9a3d88e54f951bca702ee5fffa7218d673cbee18d3",
+	},
+}
+
+// TestHashToG1 checks the hash of BLS12-381 against RFC 9380, J.9.1.
+func TestHashToG1(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G1_XMD:SHA-256_SSWU_RO_")
+	p := BLS12381.HashToG1([]byte(""), dst).(*blsG1).p
+	got := hex.EncodeToString(p.Bytes())
+	want := "052926add2207b76ca4fa57a8734416c8dc95e24501772c814278700eed6d1e4e8cf62d9c09db0fac349612b759e79a1" +
+		"08ba738453bfed09cb546dbb0783dbb3a5f1f566ed67bb6be0e8c67e2e81a4cc68ee29813bb7994998f3eae0c9c6a265"
+	if got != want {
+		t.Logf("expected: %v, got: %v", want, got)
+		t.Fatal("invalid hash to G1")
+	}
+}
+
+func TestHashVectors(t *testing.T) {
+	for _, v := range hashVectors {
+		t.Run(v.curve.String()+"/"+v.scheme+"/"+v.space+"/"+v.word, func(t *testing.T) {
+			dst := hex.EncodeToString(keywordDST(v.curve, v.scheme, v.version, v.space))
+			if dst != v.dst {
+				t.Logf("expected: %v, got: %v", v.dst, dst)
+				t.Fatal("invalid domain separation tag")
+			}
+			got := hex.EncodeToString(hashKeyword(v.curve, v.scheme, v.version, v.space, []byte(v.word)).Marshal())
+			if got != v.hash {
+				t.Logf("expected: %v, got: %v", v.hash, got)
+				t.Fatal("invalid keyword hash")
+			}
+		})
+	}
+}
+
+func TestDomainSeparation(t *testing.T) {
+	// setup server key pair
+	skServer, pkServer, err := KeyGenServer()
+	handleFatal(err, t)
+	// setup sender key pair
+	skSender, pkSender, err := KeyGen()
+	handleFatal(err, t)
+	// setup receiver key pair
+	skReceiver, pkReceiver, err := KeyGen()
+	handleFatal(err, t)
+
+	// run tests
+	t.Run("peks", func(t *testing.T) {
+		ciphertext, err := PEKS(testSpace, []byte("deploy"), pkServer, pkReceiver, skSender)
+		handleFatal(err, t)
+		trapdoor, err := Trapdoor("other", []byte("deploy"), pkServer, pkSender, skReceiver)
+		handleFatal(err, t)
+		ok, err := Test(ciphertext, trapdoor, skServer)
+		handleFatal(err, t)
+		if ok {
+			t.Logf("expected: %v, got: %v", false, ok)
+			t.Fatal("keywords of distinct devspaces are expected not to match")
+		}
+	})
+	t.Run("shared", func(t *testing.T) {
+		ciphertext, err := PEKSShared(testSpace, []byte("deploy"), pkServer, pkReceiver)
+		handleFatal(err, t)
+		trapdoor, err := TrapdoorShared("other", []byte("deploy"), blindKey(skServer, t), skReceiver)
+		handleFatal(err, t)
+		ok, err := TestShared(ciphertext, trapdoor, skServer)
+		handleFatal(err, t)
+		if ok {
+			t.Logf("expected: %v, got: %v", false, ok)
+			t.Fatal("keywords of distinct devspaces are expected not to match")
+		}
+	})
+}
@@ -1,73 +1,198 @@
 package core
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"math/big"
-
-	"github.com/cloudflare/bn256"
 )
 
+// ErrKey is returned on keys which fail to decode or validate, joined
+// with the cause, e.g. ErrScalar, ErrIdentity or ErrSubgroup.
+var ErrKey = errors.New("invalid key")
+
 type EllipticKey interface {
 	Bytes() []byte
 	FromBytes(m []byte) error
 	FromSKey(sk *SKey) error
+	Fingerprint() string
 }
 
+// fingerprint is the SHA-256 digest of the encoding of a public key,
+// in base64 without padding after the name of the hash, e.g.
+// "SHA256:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU".
+func fingerprint(pub []byte) string {
+	h := sha256.Sum256(pub)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(h[:])
+}
+
+// Keys are encoded as the id of their curve followed by the key. The
+// encodings of bn254 keys of before the curve ids are still accepted.
+// Secret keys are scalars other than zero, and public keys are elements
+// of the subgroup of prime order other than the identity.
+
+// legacySizes are the sizes of the public keys of bn254 without curve id.
+var (
+	legacySizePK       = 129
+	legacySizePKServer = 384
+)
+
 type SKey struct {
-	Key *big.Int
+	Curve Curve
+	Key   *big.Int
 }
 
 func (sk *SKey) Bytes() []byte {
-	return sk.Key.Bytes()
+	b := make([]byte, SizeSK(sk.Curve))
+	b[0] = byte(sk.Curve.ID())
+	sk.Key.FillBytes(b[1:])
+	return b
+}
+
+// Destroy overwrites the memory of the secret key with zeroes. The key
+// is unusable afterwards. Copies of the key, e.g. by Bytes, are not
+// affected, and are to be overwritten by their holders.
+func (sk *SKey) Destroy() {
+	if sk.Key != nil {
+		zeroInt(sk.Key)
+	}
+	sk.Key = nil
+}
+
+// Fingerprint of a secret key is that of its public key of a user,
+// so that no digest of the secret key is ever shown.
+func (sk *SKey) Fingerprint() string {
+	pk := new(PKey)
+	if err := pk.FromSKey(sk); err != nil {
+		return ""
+	}
+	return pk.Fingerprint()
 }
 
 func (sk *SKey) FromBytes(m []byte) error {
-	sk.Key = new(big.Int).SetBytes(m)
+	curve := BN254
+	if len(m) >= SizeSK(BN254) {
+		var err error
+		curve, err = CurveByID(CurveID(m[0]))
+		if err != nil {
+			return errors.Join(ErrKey, err)
+		}
+		if len(m) != SizeSK(curve) {
+			return errors.Join(ErrKey, errSize)
+		}
+		m = m[1:]
+	}
+	// shorter keys are legacy secret keys of bn254
+	key := new(big.Int).SetBytes(m)
+	if err := checkScalar(curve, key); err != nil {
+		return errors.Join(ErrKey, err)
+	}
+	sk.Curve = curve
+	sk.Key = key
 	return nil
 }
 
 func (sk *SKey) FromSKey(skey *SKey) error {
+	if err := checkScalar(skey.Curve, skey.Key); err != nil {
+		return errors.Join(ErrKey, err)
+	}
+	sk.Curve = skey.Curve
 	sk.Key = new(big.Int).Set(skey.Key)
 	return nil
 }
 
 type PKey struct {
-	Key *bn256.G2
+	Curve Curve
+	Key   Element
 }
 
 func (pk *PKey) Bytes() []byte {
-	return pk.Key.Marshal()
+	return append([]byte{byte(pk.Curve.ID())}, pk.Key.Marshal()...)
+}
+
+// Fingerprint is a short human readable digest of the public key,
+// which users compare to check that they hold the same key.
+func (pk *PKey) Fingerprint() string {
+	return fingerprint(pk.Bytes())
 }
 
 func (pk *PKey) FromBytes(m []byte) error {
-	pk.Key = new(bn256.G2)
-	_, err := pk.Key.Unmarshal(m)
-	return err
+	curve, key, err := parseKey(m, legacySizePK, Curve.G2)
+	if err != nil {
+		return err
+	}
+	pk.Curve, pk.Key = curve, key
+	return nil
 }
 
 func (pk *PKey) FromSKey(sk *SKey) error {
-	pk.Key = new(bn256.G2).ScalarBaseMult(sk.Key)
+	if err := checkScalar(sk.Curve, sk.Key); err != nil {
+		return errors.Join(ErrKey, err)
+	}
+	pk.Curve = sk.Curve
+	pk.Key = sk.Curve.G2().New().ScalarBaseMult(sk.Key)
 	return nil
 }
 
 type PKeyServer struct {
-	Key *bn256.GT
+	Curve Curve
+	Key   Element
 }
 
 func (pk *PKeyServer) Bytes() []byte {
-	return pk.Key.Marshal()
+	return append([]byte{byte(pk.Curve.ID())}, pk.Key.Marshal()...)
+}
+
+// Fingerprint is a short human readable digest of the public key,
+// which users compare to check that they hold the same key.
+func (pk *PKeyServer) Fingerprint() string {
+	return fingerprint(pk.Bytes())
 }
 
 func (pk *PKeyServer) FromBytes(m []byte) error {
-	pk.Key = new(bn256.GT)
-	_, err := pk.Key.Unmarshal(m)
-	return err
+	curve, key, err := parseKey(m, legacySizePKServer, Curve.GT)
+	if err != nil {
+		return err
+	}
+	pk.Curve, pk.Key = curve, key
+	return nil
 }
 
 func (pk *PKeyServer) FromSKey(sk *SKey) error {
-	pk.Key = new(bn256.GT).ScalarBaseMult(sk.Key)
+	if err := checkScalar(sk.Curve, sk.Key); err != nil {
+		return errors.Join(ErrKey, err)
+	}
+	pk.Curve = sk.Curve
+	pk.Key = sk.Curve.GT().New().ScalarBaseMult(sk.Key)
 	return nil
 }
 
+// parseKey parses the public key m in the group of its curve. Keys of
+// the legacy size are of bn254, and carry no curve id.
+func parseKey(m []byte, legacy int, group func(Curve) Group) (Curve, Element, error) {
+	curve := BN254
+	if len(m) != legacy {
+		if len(m) == 0 {
+			return nil, nil, errors.Join(ErrKey, errSize)
+		}
+		var err error
+		curve, err = CurveByID(CurveID(m[0]))
+		if err != nil {
+			return nil, nil, errors.Join(ErrKey, err)
+		}
+		m = m[1:]
+	}
+	g := group(curve)
+	key := g.New()
+	if err := key.Unmarshal(m); err != nil {
+		return nil, nil, errors.Join(ErrKey, err)
+	}
+	if isIdentity(g, key) {
+		return nil, nil, errors.Join(ErrKey, ErrIdentity)
+	}
+	return curve, key, nil
+}
+
 var _ EllipticKey = new(SKey)
 var _ EllipticKey = new(PKey)
 var _ EllipticKey = new(PKeyServer)
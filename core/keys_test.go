@@ -3,128 +3,208 @@ package core
 import (
 	"bytes"
 	"crypto/rand"
-	"github.com/cloudflare/bn256"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"testing"
+
+	"github.com/cloudflare/bn256"
 )
 
 func TestSKey(t *testing.T) {
-	// any curve is fine, since all secret keys have same size range
-	validInt, _, err := bn256.RandomG1(rand.Reader)
-	handleFatal(err, t)
-	validBytes := validInt.Bytes()
-	validSKey := &SKey{
-		Key: validInt,
+	for _, curve := range Curves {
+		t.Run(curve.String(), func(t *testing.T) {
+			validInt, err := randomScalar(RandomSource, curve)
+			handleFatal(err, t)
+			validSKey := &SKey{
+				Curve: curve,
+				Key:   validInt,
+			}
+			validBytes := validSKey.Bytes()
+			t.Run("ToBytes", func(t *testing.T) {
+				got := len(validBytes)
+				want := SizeSK(curve)
+				if got != want {
+					t.Logf("expected: %v, got: %v", want, got)
+					t.Fatal("incorrect secret key to bytes")
+				}
+				if validBytes[0] != byte(curve.ID()) {
+					t.Logf("expected: %v, got: %v", curve.ID(), validBytes[0])
+					t.Fatal("secret key is expected to start with the curve id")
+				}
+			})
+			t.Run("FromBytes", func(t *testing.T) {
+				sk := new(SKey)
+				err := sk.FromBytes(validBytes)
+				handleFatal(err, t)
+				got := sk.Key
+				want := validInt
+				if want.Cmp(got) != 0 || sk.Curve != curve {
+					t.Logf("expected: %x, got: %x", want, got)
+					t.Fatal("incorrect secret key from bytes")
+				}
+			})
+			t.Run("FromSKey", func(t *testing.T) {
+				sk := new(SKey)
+				err := sk.FromSKey(validSKey)
+				handleFatal(err, t)
+				got := sk.Key
+				want := validInt
+				if want.Cmp(got) != 0 || sk.Curve != curve {
+					t.Logf("expected: %x, got: %x", want, got)
+					t.Fatal("incorrect secret key from SKey")
+				}
+			})
+		})
 	}
-	t.Run("ToBytes", func(t *testing.T) {
-		got := validSKey.Bytes()
-		want := validBytes
-		if !bytes.Equal(got, want) {
-			t.Logf("expected: %v, got: %v", want, got)
-			t.Fatal("incorrect secret key to bytes")
-		}
-	})
-	t.Run("FromBytes", func(t *testing.T) {
-		sk := new(SKey)
-		err := sk.FromBytes(validBytes)
+	t.Run("Destroy", func(t *testing.T) {
+		sk, _, err := KeyGen()
 		handleFatal(err, t)
-		got := sk.Key
-		want := validInt
-		if want.Cmp(got) != 0 {
-			t.Logf("expected: %x, got: %x", want, got)
-			t.Fatal("incorrect secret key from bytes")
+		key := sk.Key
+		words := key.Bits()
+		sk.Destroy()
+		if sk.Key != nil || key.Sign() != 0 {
+			t.Fatal("secret key is expected to be destroyed")
+		}
+		for _, w := range words[:cap(words)] {
+			if w != 0 {
+				t.Logf("expected: %v, got: %v", 0, w)
+				t.Fatal("memory of the secret key is expected to be zeroed")
+			}
 		}
+		sk.Destroy()
 	})
-	t.Run("FromSKey", func(t *testing.T) {
+	t.Run("legacy", func(t *testing.T) {
+		validInt, _, err := bn256.RandomG1(rand.Reader)
+		handleFatal(err, t)
 		sk := new(SKey)
-		err := sk.FromSKey(validSKey)
+		err = sk.FromBytes(validInt.Bytes())
 		handleFatal(err, t)
-		got := sk.Key
-		want := validInt
-		if want.Cmp(got) != 0 {
-			t.Logf("expected: %x, got: %x", want, got)
-			t.Fatal("incorrect secret key from SKey")
+		if sk.Curve != BN254 || sk.Key.Cmp(validInt) != 0 {
+			t.Logf("expected: %x, got: %x", validInt, sk.Key)
+			t.Fatal("secret key without curve id is expected to be of bn254")
 		}
 	})
 }
 
 func TestPkey(t *testing.T) {
-	zero := []byte{0x0}
-	sk, validKey, err := bn256.RandomG2(rand.Reader)
-	validSKey := &SKey{Key: sk}
-	handleFatal(err, t)
-	validBytes := validKey.Marshal()
-	t.Run("ToBytes", func(t *testing.T) {
-		pk := &PKey{
-			Key: validKey,
-		}
-		// check for panics
-		got := len(pk.Bytes())
-		want := SizeG2
-		if got != want {
-			t.Logf("expected: %v, got: %v", want, got)
-			t.Fatal("incorrect public key to bytes")
-		}
-	})
-	t.Run("FromBytes", func(t *testing.T) {
+	for _, curve := range Curves {
+		t.Run(curve.String(), func(t *testing.T) {
+			validSKey, validKey, err := KeyGenCurve(curve)
+			handleFatal(err, t)
+			validBytes := validKey.Bytes()
+			t.Run("ToBytes", func(t *testing.T) {
+				got := len(validBytes)
+				want := 1 + curve.G2().Size()
+				if got != want {
+					t.Logf("expected: %v, got: %v", want, got)
+					t.Fatal("incorrect public key to bytes")
+				}
+			})
+			t.Run("FromBytes", func(t *testing.T) {
+				pk := new(PKey)
+				err := pk.FromBytes(validBytes)
+				handleFatal(err, t)
+				if pk.Curve != curve || !pk.Key.Equal(validKey.Key) {
+					t.Fatal("incorrect public key from bytes")
+				}
+			})
+			t.Run("FromSKey", func(t *testing.T) {
+				pk := new(PKey)
+				err := pk.FromSKey(validSKey)
+				handleFatal(err, t)
+				if pk.Curve != curve || !pk.Key.Equal(validKey.Key) {
+					t.Fatal("incorrect public key from SKey")
+				}
+			})
+		})
+	}
+	t.Run("legacy", func(t *testing.T) {
+		_, validKey, err := bn256.RandomG2(rand.Reader)
+		handleFatal(err, t)
 		pk := new(PKey)
-		err := pk.FromBytes(validBytes)
+		err = pk.FromBytes(validKey.Marshal())
 		handleFatal(err, t)
-		tmp := new(bn256.G2).Neg(pk.Key)
-		tmp.Add(tmp, validKey)
-		if !bytes.Equal(zero, tmp.Marshal()) {
-			t.Fatal("incorrect public key from bytes")
+		if pk.Curve != BN254 || !bytes.Equal(pk.Key.Marshal(), validKey.Marshal()) {
+			t.Fatal("public key without curve id is expected to be of bn254")
 		}
 	})
-	t.Run("FromSKey", func(t *testing.T) {
-		pk := new(PKey)
-		err := pk.FromSKey(validSKey)
+	t.Run("error", func(t *testing.T) {
+		_, pk, err := KeyGenCurve(BLS12381)
 		handleFatal(err, t)
-		tmp := new(bn256.G2).Neg(pk.Key)
-		tmp.Add(tmp, validKey)
-		if !bytes.Equal(zero, tmp.Marshal()) {
-			t.Fatal("incorrect public key from SKey")
+		m := pk.Bytes()
+		m[0] = 0xff
+		got := new(PKey).FromBytes(m)
+		want := ErrCurve
+		if !errors.Is(got, want) {
+			t.Logf("expected: %v, got: %v", want, got)
+			t.Fatal("unknown curve error is expected")
 		}
 	})
 }
 
 func TestPkeyServer(t *testing.T) {
-	zero := make([]byte, SizeGT)
-	zero[len(zero)-1] = 0x1
-	sk, validKey, err := bn256.RandomGT(rand.Reader)
-	validSKey := &SKey{Key: sk}
-	handleFatal(err, t)
-	validBytes := validKey.Marshal()
-	t.Run("ToBytes", func(t *testing.T) {
-		pk := &PKeyServer{
-			Key: validKey,
-		}
-		// check for panics
-		got := len(pk.Bytes())
-		want := SizeGT
-		if got != want {
-			t.Logf("expected: %v, got: %v", want, got)
-			t.Fatal("incorrect public key to bytes")
-		}
-	})
-	t.Run("FromBytes", func(t *testing.T) {
-		pk := new(PKeyServer)
-		err := pk.FromBytes(validBytes)
+	for _, curve := range Curves {
+		t.Run(curve.String(), func(t *testing.T) {
+			validSKey, validKey, err := KeyGenServerCurve(curve)
+			handleFatal(err, t)
+			validBytes := validKey.Bytes()
+			t.Run("ToBytes", func(t *testing.T) {
+				got := len(validBytes)
+				want := 1 + curve.GT().Size()
+				if got != want {
+					t.Logf("expected: %v, got: %v", want, got)
+					t.Fatal("incorrect public key to bytes")
+				}
+			})
+			t.Run("FromBytes", func(t *testing.T) {
+				pk := new(PKeyServer)
+				err := pk.FromBytes(validBytes)
+				handleFatal(err, t)
+				if pk.Curve != curve || !pk.Key.Equal(validKey.Key) {
+					t.Fatal("incorrect public key from bytes")
+				}
+			})
+			t.Run("FromSKey", func(t *testing.T) {
+				pk := new(PKeyServer)
+				err := pk.FromSKey(validSKey)
+				handleFatal(err, t)
+				if pk.Curve != curve || !pk.Key.Equal(validKey.Key) {
+					t.Fatal("incorrect public key from SKey")
+				}
+			})
+		})
+	}
+	t.Run("legacy", func(t *testing.T) {
+		_, validKey, err := bn256.RandomGT(rand.Reader)
 		handleFatal(err, t)
-		tmp := new(bn256.GT).Neg(pk.Key)
-		tmp.Add(tmp, validKey)
-		if !bytes.Equal(zero, tmp.Marshal()) {
-			t.Logf("expected: %v, got: %d", zero, len(tmp.Marshal()))
-			t.Fatal("incorrect public key from bytes")
-		}
-	})
-	t.Run("FromSKey", func(t *testing.T) {
 		pk := new(PKeyServer)
-		err := pk.FromSKey(validSKey)
+		err = pk.FromBytes(validKey.Marshal())
 		handleFatal(err, t)
-		tmp := new(bn256.GT).Neg(pk.Key)
-		tmp.Add(tmp, validKey)
-		if !bytes.Equal(zero, tmp.Marshal()) {
-			t.Fatal("incorrect public key from SKey")
+		if pk.Curve != BN254 || !bytes.Equal(pk.Key.Marshal(), validKey.Marshal()) {
+			t.Fatal("public key without curve id is expected to be of bn254")
 		}
 	})
 }
+
+func TestFingerprint(t *testing.T) {
+	sk, pk, err := KeyGen()
+	handleFatal(err, t)
+	_, srv, err := KeyGenServer()
+	handleFatal(err, t)
+	sum := sha256.Sum256(pk.Bytes())
+	want := "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+	if got := pk.Fingerprint(); got != want {
+		t.Logf("expected: %v, got: %v", want, got)
+		t.Fatal("incorrect fingerprint of the public key")
+	}
+	if got := sk.Fingerprint(); got != want {
+		t.Logf("expected: %v, got: %v", want, got)
+		t.Fatal("fingerprint of the secret key is expected to be that of its public key")
+	}
+	other := new(PKeyServer)
+	handleFatal(other.FromBytes(srv.Bytes()), t)
+	if srv.Fingerprint() != other.Fingerprint() || srv.Fingerprint() == want {
+		t.Fatal("fingerprints are expected to identify the key")
+	}
+}
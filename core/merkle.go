@@ -0,0 +1,227 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+// The key transparency log is a Merkle tree as in RFC 6962, whose
+// leaves are the bindings of public keys to users and devspaces.
+
+var ErrProof = errors.New("invalid merkle proof")
+
+// SizeHash is the size of the hashes of the Merkle tree.
+const SizeHash = sha256.Size
+
+// treeHeadDST separates the hashes of signed tree heads from those of
+// signed messages and proofs of possession.
+var treeHeadDST = []byte("devspaces-tree-head")
+
+// Kinds of the names bound to public keys in the log.
+const (
+	BindingUser  = "user"
+	BindingSpace = "space"
+)
+
+// BindingLeaf encodes the binding of pubkey to the user or devspace
+// name of the kind at the epoch, the key version of users or the key
+// epoch of devspaces. Every field is length prefixed.
+func BindingLeaf(kind, name string, pubkey []byte, epoch int64) []byte {
+	var e [8]byte
+	binary.BigEndian.PutUint64(e[:], uint64(epoch))
+	var b []byte
+	for _, f := range [][]byte{[]byte(kind), []byte(name), pubkey, e[:]} {
+		var n [8]byte
+		binary.BigEndian.PutUint64(n[:], uint64(len(f)))
+		b = append(b, n[:]...)
+		b = append(b, f...)
+	}
+	return b
+}
+
+// LeafHash is the hash of the leaf data in the Merkle tree.
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// split is the largest power of two smaller than n > 1.
+func split(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// MerkleRoot is the root of the tree of the leaf hashes.
+func MerkleRoot(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		h := sha256.Sum256(nil)
+		return h[:]
+	case 1:
+		return leaves[0]
+	}
+	k := split(len(leaves))
+	return nodeHash(MerkleRoot(leaves[:k]), MerkleRoot(leaves[k:]))
+}
+
+// InclusionProof is the audit path of the leaf at index
+// in the tree of the leaf hashes.
+func InclusionProof(leaves [][]byte, index int) ([][]byte, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, ErrProof
+	}
+	return path(index, leaves), nil
+}
+
+func path(m int, leaves [][]byte) [][]byte {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := split(len(leaves))
+	if m < k {
+		return append(path(m, leaves[:k]), MerkleRoot(leaves[k:]))
+	}
+	return append(path(m-k, leaves[k:]), MerkleRoot(leaves[:k]))
+}
+
+// ConsistencyProof proves that the tree of the first size leaf hashes
+// is a prefix of the tree of all of them.
+func ConsistencyProof(leaves [][]byte, size int) ([][]byte, error) {
+	if size < 0 || size > len(leaves) {
+		return nil, ErrProof
+	}
+	if size == 0 || size == len(leaves) {
+		return nil, nil
+	}
+	return subproof(size, leaves, true), nil
+}
+
+func subproof(m int, leaves [][]byte, complete bool) [][]byte {
+	if m == len(leaves) {
+		if complete {
+			return nil
+		}
+		return [][]byte{MerkleRoot(leaves)}
+	}
+	k := split(len(leaves))
+	if m <= k {
+		return append(subproof(m, leaves[:k], complete), MerkleRoot(leaves[k:]))
+	}
+	return append(subproof(m-k, leaves[k:], false), MerkleRoot(leaves[:k]))
+}
+
+// VerifyInclusion checks the proof that the leaf hash is at index
+// in the tree of size with the root.
+func VerifyInclusion(leaf []byte, index, size int64, proof [][]byte, root []byte) error {
+	if index < 0 || index >= size {
+		return ErrProof
+	}
+	fn, sn := index, size-1
+	r := leaf
+	for _, p := range proof {
+		if sn == 0 {
+			return ErrProof
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(r, root) {
+		return ErrProof
+	}
+	return nil
+}
+
+// VerifyConsistency checks the proof that the tree of size1 with root1
+// is a prefix of the tree of size2 with root2.
+func VerifyConsistency(size1, size2 int64, proof [][]byte, root1, root2 []byte) error {
+	switch {
+	case size1 < 0 || size1 > size2:
+		return ErrProof
+	case size1 == size2:
+		if len(proof) != 0 || !bytes.Equal(root1, root2) {
+			return ErrProof
+		}
+		return nil
+	case size1 == 0:
+		if len(proof) != 0 {
+			return ErrProof
+		}
+		return nil
+	}
+	// the root of a complete subtree is left out of the proof
+	if size1&(size1-1) == 0 {
+		proof = append([][]byte{root1}, proof...)
+	}
+	if len(proof) == 0 {
+		return ErrProof
+	}
+	fn, sn := size1-1, size2-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return ErrProof
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = nodeHash(c, fr)
+			sr = nodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = nodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(fr, root1) || !bytes.Equal(sr, root2) {
+		return ErrProof
+	}
+	return nil
+}
+
+// SignTreeHead signs the head of the log of size with the root at
+// the timestamp, in milliseconds since the epoch, with the log key.
+func SignTreeHead(size int64, root []byte, timestamp int64, sk *SKey) []byte {
+	return sign(treeHeadDigest(size, root, timestamp), treeHeadDST, sk)
+}
+
+// VerifyTreeHead checks the signature of the head of the log.
+func VerifyTreeHead(size int64, root []byte, timestamp int64, sig []byte, pk *PKey) (ok bool, err error) {
+	return verify(treeHeadDigest(size, root, timestamp), sig, treeHeadDST, pk)
+}
+
+func treeHeadDigest(size int64, root []byte, timestamp int64) []byte {
+	var s, t [8]byte
+	binary.BigEndian.PutUint64(s[:], uint64(size))
+	binary.BigEndian.PutUint64(t[:], uint64(timestamp))
+	return digest(s[:], root, t[:])
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// rfcLeaves are the leaves of the reference tree of RFC 6962.
+var rfcLeaves = []string{
+	"", "00", "10", "2021", "3031", "40414243",
+	"5051525354555657", "606162636465666768696a6b6c6d6e6f",
+}
+
+func testLeaves(n int) [][]byte {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		leaves[i] = LeafHash([]byte{byte(i), byte(i >> 8)})
+	}
+	return leaves
+}
+
+func TestMerkle(t *testing.T) {
+	t.Run("root", func(t *testing.T) {
+		leaves := make([][]byte, 0, len(rfcLeaves))
+		for _, l := range rfcLeaves {
+			leaves = append(leaves, LeafHash(decodeHex(l, t)))
+		}
+		want := map[int]string{
+			1: "6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+			8: "5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+		}
+		for n, root := range want {
+			got := hex.EncodeToString(MerkleRoot(leaves[:n]))
+			if got != root {
+				t.Logf("expected: %v, got: %v", root, got)
+				t.Fatal("incorrect merkle root")
+			}
+		}
+	})
+	t.Run("inclusion", func(t *testing.T) {
+		for n := 1; n <= 33; n++ {
+			leaves := testLeaves(n)
+			root := MerkleRoot(leaves)
+			for i := 0; i < n; i++ {
+				proof, err := InclusionProof(leaves, i)
+				handleFatal(err, t)
+				err = VerifyInclusion(leaves[i], int64(i), int64(n), proof, root)
+				if err != nil {
+					t.Logf("size: %v, index: %v", n, i)
+					t.Fatal("inclusion proof is expected to verify")
+				}
+				if VerifyInclusion(leaves[(i+1)%n], int64(i), int64(n), proof, root) == nil && n > 1 {
+					t.Fatal("inclusion proof is expected to only verify its leaf")
+				}
+				if len(proof) != 0 {
+					proof[0] = leaves[i]
+					if VerifyInclusion(leaves[i], int64(i), int64(n), proof, root) == nil {
+						t.Fatal("tampered inclusion proof is not expected to verify")
+					}
+				}
+			}
+		}
+		_, err := InclusionProof(testLeaves(4), 4)
+		if !errors.Is(err, ErrProof) {
+			t.Logf("expected: %v, got: %v", ErrProof, err)
+			t.Fatal("incorrect error for an index out of range")
+		}
+	})
+	t.Run("consistency", func(t *testing.T) {
+		for n := 1; n <= 33; n++ {
+			leaves := testLeaves(n)
+			root := MerkleRoot(leaves)
+			for m := 0; m <= n; m++ {
+				proof, err := ConsistencyProof(leaves, m)
+				handleFatal(err, t)
+				old := MerkleRoot(leaves[:m])
+				err = VerifyConsistency(int64(m), int64(n), proof, old, root)
+				if err != nil {
+					t.Logf("sizes: %v, %v", m, n)
+					t.Fatal("consistency proof is expected to verify")
+				}
+				if m == 0 {
+					continue
+				}
+				forged := MerkleRoot(testLeaves(m + 40)[40:])
+				if VerifyConsistency(int64(m), int64(n), proof, forged, root) == nil {
+					t.Logf("sizes: %v, %v", m, n)
+					t.Fatal("consistency proof is expected to only verify the prefix")
+				}
+			}
+		}
+	})
+}
+
+func TestTreeHead(t *testing.T) {
+	sk, pk, err := KeyGen()
+	handleFatal(err, t)
+	root := MerkleRoot(testLeaves(5))
+	sig := SignTreeHead(5, root, 1700000000000, sk)
+	ok, err := VerifyTreeHead(5, root, 1700000000000, sig, pk)
+	handleFatal(err, t)
+	if !ok {
+		t.Fatal("tree head is expected to verify")
+	}
+	ok, err = VerifyTreeHead(6, root, 1700000000000, sig, pk)
+	handleFatal(err, t)
+	if ok {
+		t.Fatal("tree head is expected to only verify the signed size")
+	}
+	// tree heads are not taken for signed messages
+	ok, err = Verify(treeHeadDigest(5, root, 1700000000000), sig, pk)
+	handleFatal(err, t)
+	if ok {
+		t.Fatal("tree head signature is not expected to verify as a message signature")
+	}
+}
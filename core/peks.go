@@ -4,20 +4,28 @@ import (
 	"bytes"
 	"crypto/rand"
 	"errors"
+	"io"
 	"math/big"
-
-	"github.com/cloudflare/bn256"
-)
-
-var (
-	SizeG1 = 64
-	SizeG2 = 129
-	SizeGT = 384
-	SizeSK = 32
 )
 
 var RandomSource = rand.Reader
 
+// Scheme runs the randomized algorithms of the package with the
+// randomness of Rand, e.g. a DRBG for known answer tests, and hashes
+// keywords canonicalized by Canon, CanonV1 if nil. The functions of the
+// package run those of the zero Scheme, which reads RandomSource.
+type Scheme struct {
+	Rand  io.Reader
+	Canon *Canon
+}
+
+func (s Scheme) rand() io.Reader {
+	if s.Rand != nil {
+		return s.Rand
+	}
+	return RandomSource
+}
+
 var (
 	ErrCiphertext = errors.New("invalid ciphertext")
 	ErrTrapdoor   = errors.New("invalid trapdoor")
@@ -27,97 +35,165 @@ var (
 func SharedKey(pk *PKey, sk *SKey) *PKey {
 	a := sk.Key
 	bP := pk.Key
-	abP := new(bn256.G2).ScalarMult(bP, a)
+	abP := pk.Curve.G2().New().ScalarMult(bP, a)
 	return &PKey{
-		Key: abP,
+		Curve: pk.Curve,
+		Key:   abP,
 	}
 }
 
 func KeyGen() (sk *SKey, pk *PKey, err error) {
-	a, aP, err := bn256.RandomG2(RandomSource)
+	return KeyGenCurve(DefaultCurve)
+}
+
+// KeyGenCurve generates a key pair of a user on the curve.
+func KeyGenCurve(curve Curve) (sk *SKey, pk *PKey, err error) {
+	return Scheme{}.KeyGen(curve)
+}
+
+// KeyGen generates a key pair of a user on the curve.
+func (s Scheme) KeyGen(curve Curve) (sk *SKey, pk *PKey, err error) {
+	a, err := randomScalar(s.rand(), curve)
 	if err != nil {
-		err = errors.Join(ErrRandom, err)
 		return
 	}
-	return &SKey{a}, &PKey{aP}, nil
+	sk = &SKey{curve, a}
+	pk = new(PKey)
+	_ = pk.FromSKey(sk)
+	return sk, pk, nil
 }
 
 func KeyGenServer() (sk *SKey, pk *PKeyServer, err error) {
-	b, bQ, err := bn256.RandomGT(RandomSource)
+	return KeyGenServerCurve(DefaultCurve)
+}
+
+// KeyGenServerCurve generates a key pair of the server on the curve.
+func KeyGenServerCurve(curve Curve) (sk *SKey, pk *PKeyServer, err error) {
+	return Scheme{}.KeyGenServer(curve)
+}
+
+// KeyGenServer generates a key pair of the server on the curve.
+func (s Scheme) KeyGenServer(curve Curve) (sk *SKey, pk *PKeyServer, err error) {
+	b, err := randomScalar(s.rand(), curve)
 	if err != nil {
-		err = errors.Join(ErrRandom, err)
 		return
 	}
-	return &SKey{b}, &PKeyServer{bQ}, nil
+	sk = &SKey{curve, b}
+	pk = new(PKeyServer)
+	_ = pk.FromSKey(sk)
+	return sk, pk, nil
 }
 
-func PEKS(word []byte, server *PKeyServer, receiver *PKey, sender *SKey) ([]byte, error) {
-	ct1, pr, e, err := encryptHelper(word, server.Key, receiver.Key, sender.Key)
+func PEKS(space string, word []byte, server *PKeyServer, receiver *PKey, sender *SKey) ([]byte, error) {
+	return Scheme{}.PEKS(space, word, server, receiver, sender)
+}
+
+// PEKS computes the ciphertext of word in space from the sender to the receiver.
+func (s Scheme) PEKS(space string, word []byte, server *PKeyServer, receiver *PKey, sender *SKey) ([]byte, error) {
+	curve, err := sameCurve(server.Curve, receiver.Curve, sender.Curve)
+	if err != nil {
+		return nil, err
+	}
+	version, w := s.canonical(word)
+	h := hashKeyword(curve, hashSchemePEKS, version, space, w)
+	ct1, pr, e, err := encryptHelper(s.rand(), curve, h, server.Key, receiver.Key, sender.Key)
 	if err != nil {
 		return nil, err
 	}
 	c1 := ct1.Marshal()
-	c2 := new(bn256.GT).Add(pr, e).Marshal()
-	return bytes.Join([][]byte{c1, c2}, nil), nil
+	c2 := pr.Add(pr, e).Marshal()
+	return bytes.Join([][]byte{header(curve, version), c1, c2}, nil), nil
 }
 
-func Trapdoor(word []byte, server *PKeyServer, sender *PKey, receiver *SKey) ([]byte, error) {
-	ct1, pr, e, err := encryptHelper(word, server.Key, sender.Key, receiver.Key)
+func Trapdoor(space string, word []byte, server *PKeyServer, sender *PKey, receiver *SKey) ([]byte, error) {
+	return Scheme{}.Trapdoor(space, word, server, sender, receiver)
+}
+
+// Trapdoor computes the trapdoor of word in space of the receiver for
+// the ciphertexts from the sender.
+func (s Scheme) Trapdoor(space string, word []byte, server *PKeyServer, sender *PKey, receiver *SKey) ([]byte, error) {
+	curve, err := sameCurve(server.Curve, sender.Curve, receiver.Curve)
+	if err != nil {
+		return nil, err
+	}
+	version, w := s.canonical(word)
+	h := hashKeyword(curve, hashSchemePEKS, version, space, w)
+	ct1, pr, e, err := encryptHelper(s.rand(), curve, h, server.Key, sender.Key, receiver.Key)
 	if err != nil {
 		return nil, err
 	}
 	e.Neg(e)
 	t1 := ct1.Marshal()
-	t2 := new(bn256.GT).Add(pr, e).Marshal()
-	return bytes.Join([][]byte{t1, t2}, nil), nil
+	t2 := pr.Add(pr, e).Marshal()
+	return bytes.Join([][]byte{header(curve, version), t1, t2}, nil), nil
 }
 
 func Test(ciphertext, trapdoor []byte, server *SKey) (ok bool, err error) {
-	s1, s2, err := testHelper(ciphertext, trapdoor)
+	s1, s2, err := testHelper(server.Curve, ciphertext, trapdoor)
 	if err != nil {
 		return
 	}
-	A := s1.ScalarMult(s1, server.Key).Marshal()
-	B := s2.Marshal()
-	return bytes.Equal(A, B), nil
+	// keywords canonicalized by distinct pipelines never match
+	if ciphertext[1] != trapdoor[1] {
+		return false, nil
+	}
+	A := s1.ScalarMult(s1, server.Key)
+	return A.Equal(s2), nil
 }
 
-func testHelper(c, t []byte) (s1, s2 *bn256.GT, err error) {
-	n := SizeGT
-	// prevent index out of bounds
-	if len(c) != 2*n {
-		err = ErrCiphertext
+// header is the header of the ciphertexts and trapdoors of the curve,
+// of keywords canonicalized by the pipeline of the version.
+func header(curve Curve, version byte) []byte {
+	return []byte{byte(curve.ID()), version}
+}
+
+func testHelper(curve Curve, c, t []byte) (s1, s2 Element, err error) {
+	c1, c2, err := parsePEKS(curve, c, SizeCiphertext(curve), ErrCiphertext)
+	if err != nil {
 		return
 	}
-	if len(t) != 2*n {
-		err = ErrTrapdoor
+	t1, t2, err := parsePEKS(curve, t, SizeTrapdoor(curve), ErrTrapdoor)
+	if err != nil {
 		return
 	}
-	c1 := new(bn256.GT)
-	c2 := new(bn256.GT)
-	t1 := new(bn256.GT)
-	t2 := new(bn256.GT)
-	// only possible error is bad number of bytes
-	// which is already handled above
-	_, _ = c1.Unmarshal(c[:n])
-	_, _ = c2.Unmarshal(c[n:])
-	_, _ = t1.Unmarshal(t[:n])
-	_, _ = t2.Unmarshal(t[n:])
-	s1 = new(bn256.GT).Add(c1, t1)
-	s2 = new(bn256.GT).Add(c2, t2)
+	s1 = c1.Add(c1, t1)
+	s2 = c2.Add(c2, t2)
 	return
 }
 
-func encryptHelper(w []byte, pubkey *bn256.GT, pk *bn256.G2, sk *big.Int) (ct1, pr, e *bn256.GT, err error) {
-	r, err := rand.Int(RandomSource, bn256.Order)
+// parsePEKS parses the elements of GT of a ciphertext or a trapdoor of
+// PEKS of the size, which is invalid with the error invalid otherwise.
+func parsePEKS(curve Curve, m []byte, size int, invalid error) (a, b Element, err error) {
+	// prevent index out of bounds
+	if len(m) != size || m[0] != byte(curve.ID()) {
+		return nil, nil, invalid
+	}
+	// skip the header
+	m = m[2:]
+	n := curve.GT().Size()
+	a, b = curve.GT().New(), curve.GT().New()
+	if err = errors.Join(a.Unmarshal(m[:n]), b.Unmarshal(m[n:])); err != nil {
+		return nil, nil, errors.Join(invalid, err)
+	}
+	return a, b, nil
+}
+
+// ValidateTrapdoor checks that the trapdoor of PEKS is of the curve,
+// and that its elements are valid, e.g. before it is stored.
+func ValidateTrapdoor(curve Curve, trapdoor []byte) error {
+	_, _, err := parsePEKS(curve, trapdoor, SizeTrapdoor(curve), ErrTrapdoor)
+	return err
+}
+
+func encryptHelper(rand io.Reader, curve Curve, h, pubkey, pk Element, sk *big.Int) (ct1, pr, e Element, err error) {
+	r, err := randomScalar(rand, curve)
 	if err != nil {
-		err = errors.Join(ErrRandom, err)
 		return
 	}
-	ct1 = new(bn256.GT).ScalarBaseMult(r)
-	h := bn256.HashG1(w, nil)
-	k := new(bn256.G2).ScalarMult(pk, sk)
-	e = bn256.Pair(h, k)
-	pr = new(bn256.GT).ScalarMult(pubkey, r)
+	defer zeroInt(r)
+	ct1 = curve.GT().New().ScalarBaseMult(r)
+	k := curve.G2().New().ScalarMult(pk, sk)
+	e = curve.Pair(h, k)
+	pr = curve.GT().New().ScalarMult(pubkey, r)
 	return
 }
@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"crypto/rand"
 	"errors"
-	"github.com/cloudflare/bn256"
 	"math/big"
 	"testing"
 	"testing/iotest"
 )
 
+// testSpace is the devspace of the keywords under test.
+const testSpace = "devspace"
+
 var (
 	randReader = RandomSource
 	errReader  = iotest.ErrReader(ErrRandom)
@@ -22,7 +24,7 @@ func TestKeyGen(t *testing.T) {
 		sk, pk, err := KeyGen()
 		handleFatal(err, t)
 		got := pk.Key.Marshal()
-		want := new(bn256.G2).ScalarBaseMult(sk.Key).Marshal()
+		want := sk.Curve.G2().New().ScalarBaseMult(sk.Key).Marshal()
 		if !bytes.Equal(want, got) {
 			t.Logf("expected: %x, got: %x", want, got)
 			t.Fatal("secret key is expected to correctly map to the public key")
@@ -48,7 +50,7 @@ func TestKeyGenServer(t *testing.T) {
 		sk, pk, err := KeyGenServer()
 		handleFatal(err, t)
 		got := pk.Key.Marshal()
-		want := new(bn256.GT).ScalarBaseMult(sk.Key).Marshal()
+		want := sk.Curve.GT().New().ScalarBaseMult(sk.Key).Marshal()
 		if !bytes.Equal(want, got) {
 			t.Logf("expected: %x, got: %x", want, got)
 			t.Fatal("secret key is expected to correctly map to the public key")
@@ -88,7 +90,7 @@ func TestSharedKey(t *testing.T) {
 	t.Run("length", func(t *testing.T) {
 		shared := SharedKey(pk1, sk2)
 		got := len(shared.Bytes())
-		want := SizeG2
+		want := 1 + DefaultCurve.G2().Size()
 		if got != want {
 			t.Logf("expected: %v, got: %v", want, got)
 			t.Fatal("invalid length of shared key")
@@ -113,10 +115,10 @@ func TestPEKS(t *testing.T) {
 
 	// run tests
 	t.Run("correctness", func(t *testing.T) {
-		ct, err := PEKS(word, server, receiver, sender)
+		ct, err := PEKS(testSpace, word, server, receiver, sender)
 		handleFatal(err, t)
 		got := len(ct)
-		want := 2 * SizeGT
+		want := SizeCiphertext(DefaultCurve)
 		if got != want {
 			t.Logf("expected: %v, got: %v", want, got)
 			t.Fatal("invalid ciphertext length")
@@ -126,7 +128,7 @@ func TestPEKS(t *testing.T) {
 	// setup erroneous random source
 	RandomSource = errReader
 	t.Run("error", func(t *testing.T) {
-		_, err := PEKS(word, server, receiver, sender)
+		_, err := PEKS(testSpace, word, server, receiver, sender)
 		got := err
 		want := ErrRandom
 		if !errors.Is(got, want) {
@@ -154,10 +156,10 @@ func TestTrapdoor(t *testing.T) {
 
 	// run tests
 	t.Run("correctness", func(t *testing.T) {
-		ct, err := Trapdoor(word, server, sender, receiver)
+		ct, err := Trapdoor(testSpace, word, server, sender, receiver)
 		handleFatal(err, t)
 		got := len(ct)
-		want := 2 * SizeGT
+		want := SizeTrapdoor(DefaultCurve)
 		if got != want {
 			t.Logf("expected: %v, got: %v", want, got)
 			t.Fatal("invalid trapdoor length")
@@ -167,7 +169,7 @@ func TestTrapdoor(t *testing.T) {
 	// setup erroneous random source
 	RandomSource = errReader
 	t.Run("error", func(t *testing.T) {
-		_, err := Trapdoor(word, server, sender, receiver)
+		_, err := Trapdoor(testSpace, word, server, sender, receiver)
 		got := err
 		want := ErrRandom
 		if !errors.Is(got, want) {
@@ -197,17 +199,17 @@ func TestTest(t *testing.T) {
 	handleFatal(err, t)
 
 	// correct ciphertext
-	ciphertext, err := PEKS(word, pkServer, pkReceiver, skSender)
+	ciphertext, err := PEKS(testSpace, word, pkServer, pkReceiver, skSender)
 	handleFatal(err, t)
 	// invalid ciphertext
 	invalidCT, err := getRandomBytes()
 	handleFatal(err, t)
 
 	// correct trapdoor
-	tdTruthy, err := Trapdoor(word, pkServer, pkSender, skReceiver)
+	tdTruthy, err := Trapdoor(testSpace, word, pkServer, pkSender, skReceiver)
 	handleFatal(err, t)
 	// incorrect trapdoor
-	tdFalsey, err := Trapdoor(word2, pkServer, pkSender, skReceiver)
+	tdFalsey, err := Trapdoor(testSpace, word2, pkServer, pkSender, skReceiver)
 	handleFatal(err, t)
 	// invalid trapdoor
 	invalidTD, err := getRandomBytes()
@@ -302,7 +304,7 @@ func BenchmarkTrapdoor(b *testing.B) {
 
 	// run benchmark
 	for i := 0; i < b.N; i++ {
-		_, err := Trapdoor(word, server, sender, receiver)
+		_, err := Trapdoor(testSpace, word, server, sender, receiver)
 		handleFatal(err, b)
 	}
 }
@@ -324,7 +326,7 @@ func BenchmarkPEKS(b *testing.B) {
 
 	// run benchmark
 	for i := 0; i < b.N; i++ {
-		_, err := PEKS(word, server, receiver, sender)
+		_, err := PEKS(testSpace, word, server, receiver, sender)
 		handleFatal(err, b)
 	}
 }
@@ -348,13 +350,13 @@ func BenchmarkTest(b *testing.B) {
 	handleFatal(err, b)
 
 	// ciphertext
-	ciphertext, err := PEKS(word, pkServer, pkReceiver, skSender)
+	ciphertext, err := PEKS(testSpace, word, pkServer, pkReceiver, skSender)
 	handleFatal(err, b)
 	// correct trapdoor
-	tdTruthy, err := Trapdoor(word, pkServer, pkSender, skReceiver)
+	tdTruthy, err := Trapdoor(testSpace, word, pkServer, pkSender, skReceiver)
 	handleFatal(err, b)
 	// incorrect trapdoor
-	tdFalsey, err := Trapdoor(word2, pkServer, pkSender, skReceiver)
+	tdFalsey, err := Trapdoor(testSpace, word2, pkServer, pkSender, skReceiver)
 	handleFatal(err, b)
 
 	// run benchmarks
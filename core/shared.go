@@ -0,0 +1,205 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+)
+
+// The shared scheme designates the keyword ciphertext to the receiver
+// alone, so that a single trapdoor matches the keyword from any sender.
+// The sender contributes a fresh ephemeral key to every ciphertext in
+// place of its own key, and has to be authenticated otherwise, e.g. by
+// signing its messages. As in PEKS, testing takes the server secret key.
+//
+// The trapdoor a*H(w) of the receiver of secret key a is blinded with
+// the blinding key of the server, as PEKS trapdoors are, so that those
+// who get hold of the trapdoor, e.g. from the list of tags, cannot test
+// guessed keywords w' with e(a*H(w), P) == e(H(w'), aP). Since anybody
+// can compute ciphertexts of the scheme, the server, which unblinds the
+// trapdoor, is still able to test guessed keywords against it, which
+// PEKS prevents.
+
+// ErrBlindKey is returned when the blinding key is not of the secret key
+// of the public key of the server.
+var ErrBlindKey = errors.New("blinding key does not match the server key")
+
+// PKeyBlind is the blinding key of the server, bP in G1 for the server
+// secret key b, whose public key is e(P, Q)^b.
+type PKeyBlind struct {
+	Curve Curve
+	Key   Element
+}
+
+func (pk *PKeyBlind) Bytes() []byte {
+	return append([]byte{byte(pk.Curve.ID())}, pk.Key.Marshal()...)
+}
+
+// Fingerprint is a short human readable digest of the public key,
+// which users compare to check that they hold the same key.
+func (pk *PKeyBlind) Fingerprint() string {
+	return fingerprint(pk.Bytes())
+}
+
+func (pk *PKeyBlind) FromBytes(m []byte) error {
+	curve, key, err := parseKey(m, -1, Curve.G1)
+	if err != nil {
+		return err
+	}
+	pk.Curve, pk.Key = curve, key
+	return nil
+}
+
+func (pk *PKeyBlind) FromSKey(sk *SKey) error {
+	if err := checkScalar(sk.Curve, sk.Key); err != nil {
+		return errors.Join(ErrKey, err)
+	}
+	pk.Curve = sk.Curve
+	pk.Key = sk.Curve.G1().New().ScalarBaseMult(sk.Key)
+	return nil
+}
+
+// Check checks that the blinding key is of the same secret key as the
+// public key of the server, i.e. that e(bP, Q) is the server key.
+func (pk *PKeyBlind) Check(server *PKeyServer) error {
+	curve, err := sameCurve(pk.Curve, server.Curve)
+	if err != nil {
+		return err
+	}
+	q := curve.G2().New().ScalarBaseMult(big.NewInt(1))
+	if !curve.Pair(pk.Key, q).Equal(server.Key) {
+		return ErrBlindKey
+	}
+	return nil
+}
+
+var _ EllipticKey = new(PKeyBlind)
+
+// PEKSShared computes the ciphertext of word in space for the receiver.
+func PEKSShared(space string, word []byte, server *PKeyServer, receiver *PKey) ([]byte, error) {
+	return Scheme{}.PEKSShared(space, word, server, receiver)
+}
+
+// PEKSShared computes the ciphertext of word in space for the receiver.
+func (scheme Scheme) PEKSShared(space string, word []byte, server *PKeyServer, receiver *PKey) ([]byte, error) {
+	curve, err := sameCurve(server.Curve, receiver.Curve)
+	if err != nil {
+		return nil, err
+	}
+	s, err := randomScalar(scheme.rand(), curve)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroInt(s)
+	sP := curve.G2().New().ScalarBaseMult(s)
+	version, w := scheme.canonical(word)
+	h := hashKeyword(curve, hashSchemeShared, version, space, w)
+	ct1, pr, e, err := encryptHelper(scheme.rand(), curve, h, server.Key, receiver.Key, s)
+	if err != nil {
+		return nil, err
+	}
+	c0 := sP.Marshal()
+	c1 := ct1.Marshal()
+	c2 := pr.Add(pr, e).Marshal()
+	return bytes.Join([][]byte{header(curve, version), c0, c1, c2}, nil), nil
+}
+
+// TrapdoorShared computes the trapdoor of word in space for the receiver,
+// blinded for the server, which matches the ciphertexts of word from
+// any sender.
+func TrapdoorShared(space string, word []byte, server *PKeyBlind, receiver *SKey) ([]byte, error) {
+	return Scheme{}.TrapdoorShared(space, word, server, receiver)
+}
+
+// TrapdoorShared computes the trapdoor of word in space for the receiver,
+// blinded for the server, which matches the ciphertexts of word from
+// any sender.
+func (scheme Scheme) TrapdoorShared(space string, word []byte, server *PKeyBlind, receiver *SKey) ([]byte, error) {
+	curve, err := sameCurve(server.Curve, receiver.Curve)
+	if err != nil {
+		return nil, err
+	}
+	r, err := randomScalar(scheme.rand(), curve)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroInt(r)
+	version, w := scheme.canonical(word)
+	h := hashKeyword(curve, hashSchemeShared, version, space, w)
+	t1 := curve.G1().New().ScalarBaseMult(r)
+	t2 := curve.G1().New().ScalarMult(h, receiver.Key)
+	t2.Add(t2, curve.G1().New().ScalarMult(server.Key, r))
+	return bytes.Join([][]byte{header(curve, version), t1.Marshal(), t2.Marshal()}, nil), nil
+}
+
+// TestShared checks whether the ciphertext and the trapdoor
+// of the shared scheme are of the same keyword.
+func TestShared(ciphertext, trapdoor []byte, server *SKey) (ok bool, err error) {
+	curve := server.Curve
+	c0, c1, c2, err := parseSharedCT(curve, ciphertext)
+	if err != nil {
+		return false, err
+	}
+	t1, t2, err := parseSharedTD(curve, trapdoor)
+	if err != nil {
+		return false, err
+	}
+	// keywords canonicalized by distinct pipelines never match
+	if ciphertext[1] != trapdoor[1] {
+		return false, nil
+	}
+	// unblind the trapdoor, t = t2 - b*t1
+	t := t1.ScalarMult(t1, server.Key)
+	t.Neg(t).Add(t, t2)
+	A := c1.ScalarMult(c1, server.Key)
+	A.Add(A, curve.Pair(t, c0))
+	return A.Equal(c2), nil
+}
+
+// ValidateTrapdoorShared checks that the trapdoor of the shared scheme
+// is of the curve, and that its elements are valid.
+func ValidateTrapdoorShared(curve Curve, trapdoor []byte) error {
+	_, _, err := parseSharedTD(curve, trapdoor)
+	return err
+}
+
+func parseSharedCT(curve Curve, m []byte) (c0, c1, c2 Element, err error) {
+	if len(m) != SizeSharedCT(curve) || m[0] != byte(curve.ID()) {
+		return nil, nil, nil, ErrCiphertext
+	}
+	m = m[2:]
+	n0, n := curve.G2().Size(), curve.GT().Size()
+	c0 = curve.G2().New()
+	c1 = curve.GT().New()
+	c2 = curve.GT().New()
+	err = errors.Join(
+		c0.Unmarshal(m[:n0]),
+		c1.Unmarshal(m[n0:n0+n]),
+		c2.Unmarshal(m[n0+n:]),
+	)
+	if err != nil {
+		return nil, nil, nil, errors.Join(ErrCiphertext, err)
+	}
+	return c0, c1, c2, nil
+}
+
+func parseSharedTD(curve Curve, m []byte) (t1, t2 Element, err error) {
+	if len(m) != SizeSharedTD(curve) || m[0] != byte(curve.ID()) {
+		return nil, nil, ErrTrapdoor
+	}
+	m = m[2:]
+	n := curve.G1().Size()
+	t1 = curve.G1().New()
+	t2 = curve.G1().New()
+	err = errors.Join(
+		t1.Unmarshal(m[:n]),
+		t2.Unmarshal(m[n:]),
+	)
+	if err != nil {
+		return nil, nil, errors.Join(ErrTrapdoor, err)
+	}
+	if isIdentity(curve.G1(), t1) || isIdentity(curve.G1(), t2) {
+		return nil, nil, errors.Join(ErrTrapdoor, ErrIdentity)
+	}
+	return t1, t2, nil
+}
@@ -0,0 +1,209 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestPEKSShared(t *testing.T) {
+	// setup random word
+	word, err := getRandomBytes()
+	handleFatal(err, t)
+
+	// setup server public key
+	_, server, err := KeyGenServer()
+	handleFatal(err, t)
+	// setup receiver public key
+	_, receiver, err := KeyGen()
+	handleFatal(err, t)
+
+	// run tests
+	t.Run("correctness", func(t *testing.T) {
+		ct, err := PEKSShared(testSpace, word, server, receiver)
+		handleFatal(err, t)
+		got := len(ct)
+		want := SizeSharedCT(DefaultCurve)
+		if got != want {
+			t.Logf("expected: %v, got: %v", want, got)
+			t.Fatal("invalid ciphertext length")
+		}
+	})
+
+	// setup erroneous random source
+	RandomSource = errReader
+	t.Run("error", func(t *testing.T) {
+		_, err := PEKSShared(testSpace, word, server, receiver)
+		got := err
+		want := ErrRandom
+		if !errors.Is(got, want) {
+			t.Logf("expected: %v, got %v", want, got)
+			t.Fatal("PEKSShared is expected to throw randomness error")
+		}
+	})
+	RandomSource = randReader
+}
+
+func TestTrapdoorShared(t *testing.T) {
+	// setup random word
+	word, err := getRandomBytes()
+	handleFatal(err, t)
+	// setup server blinding key
+	skServer, _, err := KeyGenServer()
+	handleFatal(err, t)
+	// setup receiver secret key
+	receiver, _, err := KeyGen()
+	handleFatal(err, t)
+
+	td, err := TrapdoorShared(testSpace, word, blindKey(skServer, t), receiver)
+	handleFatal(err, t)
+	got := len(td)
+	want := SizeSharedTD(DefaultCurve)
+	if got != want {
+		t.Logf("expected: %v, got: %v", want, got)
+		t.Fatal("invalid trapdoor length")
+	}
+}
+
+func TestBlindKey(t *testing.T) {
+	skServer, pkServer, err := KeyGenServer()
+	handleFatal(err, t)
+	blind := new(PKeyBlind)
+	handleFatal(blind.FromBytes(blindKey(skServer, t).Bytes()), t)
+	handleFatal(blind.Check(pkServer), t)
+
+	_, pkOther, err := KeyGenServer()
+	handleFatal(err, t)
+	got := blind.Check(pkOther)
+	if !errors.Is(got, ErrBlindKey) {
+		t.Logf("expected: %v, got: %v", ErrBlindKey, got)
+		t.Fatal("blinding key of another server is expected to be rejected")
+	}
+}
+
+func TestTestShared(t *testing.T) {
+	// setup server key pair
+	skServer, pkServer, err := KeyGenServer()
+	handleFatal(err, t)
+	// setup receiver key pair
+	skReceiver, pkReceiver, err := KeyGen()
+	handleFatal(err, t)
+
+	// setup random word
+	word, err := getRandomBytes()
+	handleFatal(err, t)
+	// setup another random word
+	word2, err := getRandomBytes()
+	handleFatal(err, t)
+
+	// ciphertexts of the word from two senders
+	ciphertext, err := PEKSShared(testSpace, word, pkServer, pkReceiver)
+	handleFatal(err, t)
+	ciphertext2, err := PEKSShared(testSpace, word, pkServer, pkReceiver)
+	handleFatal(err, t)
+	// invalid ciphertext
+	invalidCT, err := getRandomBytes()
+	handleFatal(err, t)
+
+	blind := blindKey(skServer, t)
+	// correct trapdoor
+	tdTruthy, err := TrapdoorShared(testSpace, word, blind, skReceiver)
+	handleFatal(err, t)
+	// incorrect trapdoor
+	tdFalsey, err := TrapdoorShared(testSpace, word2, blind, skReceiver)
+	handleFatal(err, t)
+	// trapdoor of another receiver
+	skOther, _, err := KeyGen()
+	handleFatal(err, t)
+	tdOther, err := TrapdoorShared(testSpace, word, blind, skOther)
+	handleFatal(err, t)
+	// invalid trapdoor
+	invalidTD, err := getRandomBytes()
+	handleFatal(err, t)
+
+	// run tests
+	t.Run("truthy", func(t *testing.T) {
+		for _, ct := range [][]byte{ciphertext, ciphertext2} {
+			ok, err := TestShared(ct, tdTruthy, skServer)
+			handleFatal(err, t)
+			if !ok {
+				t.Logf("expected: %v, got: %v", true, ok)
+				t.Fatal("TestShared is expected to pass for every sender")
+			}
+		}
+	})
+	t.Run("falsey", func(t *testing.T) {
+		for _, td := range [][]byte{tdFalsey, tdOther} {
+			ok, err := TestShared(ciphertext, td, skServer)
+			handleFatal(err, t)
+			if ok {
+				t.Logf("expected: %v, got: %v", false, ok)
+				t.Fatal("TestShared is expected to fail")
+			}
+		}
+	})
+	t.Run("blinded", func(t *testing.T) {
+		// the trapdoor does not test guessed keywords without the server
+		_, t2, err := parseSharedTD(DefaultCurve, tdTruthy)
+		handleFatal(err, t)
+		h := hashKeyword(DefaultCurve, hashSchemeShared, CanonV1.Version, testSpace, CanonV1.Apply(word))
+		q := DefaultCurve.G2().New().ScalarBaseMult(big.NewInt(1))
+		if DefaultCurve.Pair(t2, q).Equal(DefaultCurve.Pair(h, pkReceiver.Key)) {
+			t.Fatal("trapdoor is expected to be blinded for the server")
+		}
+	})
+	t.Run("server", func(t *testing.T) {
+		skWrong, _, err := KeyGenServer()
+		handleFatal(err, t)
+		ok, err := TestShared(ciphertext, tdTruthy, skWrong)
+		handleFatal(err, t)
+		if ok {
+			t.Fatal("TestShared is expected to take the server secret key")
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		t.Run("ciphertext", func(t *testing.T) {
+			_, err := TestShared(invalidCT, tdTruthy, skServer)
+			got := err
+			want := ErrCiphertext
+			if !errors.Is(got, want) {
+				t.Logf("expected: %v, got: %v", want, got)
+				t.Fatal("invalid ciphertext error is expected")
+			}
+		})
+		t.Run("trapdoor", func(t *testing.T) {
+			_, err := TestShared(ciphertext, invalidTD, skServer)
+			got := err
+			want := ErrTrapdoor
+			if !errors.Is(got, want) {
+				t.Logf("expected: %v, got: %v", want, got)
+				t.Fatal("invalid trapdoor error is expected")
+			}
+		})
+	})
+}
+
+func BenchmarkTestShared(b *testing.B) {
+	skServer, pkServer, err := KeyGenServer()
+	handleFatal(err, b)
+	skReceiver, pkReceiver, err := KeyGen()
+	handleFatal(err, b)
+	word, err := getRandomBytes()
+	handleFatal(err, b)
+	ct, err := PEKSShared(testSpace, word, pkServer, pkReceiver)
+	handleFatal(err, b)
+	td, err := TrapdoorShared(testSpace, word, blindKey(skServer, b), skReceiver)
+	handleFatal(err, b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := TestShared(ct, td, skServer)
+		handleFatal(err, b)
+	}
+}
+
+// blindKey returns the blinding key of the server secret key.
+func blindKey(sk *SKey, i interface{ Fatal(args ...any) }) *PKeyBlind {
+	pk := new(PKeyBlind)
+	handleFatal(pk.FromSKey(sk), i)
+	return pk
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+var ErrSignature = errors.New("invalid signature")
+
+// signDST separates the hashes of signed messages from keyword hashes.
+var signDST = []byte("devspaces-signature")
+
+// possessionDST separates the hashes of proofs of possession from those
+// of signed messages, so that neither is ever taken for the other.
+var possessionDST = []byte("devspaces-possession")
+
+// Sign computes the BLS signature of msg with the secret key of a user.
+// The signature starts with the id of the curve of the key.
+func Sign(msg []byte, sk *SKey) []byte {
+	return sign(msg, signDST, sk)
+}
+
+// Verify checks the signature of msg against the public key of a user.
+func Verify(msg, sig []byte, pk *PKey) (ok bool, err error) {
+	return verify(msg, sig, signDST, pk)
+}
+
+// ProvePossession proves that the subject, e.g. a user registering its
+// public key, holds the secret key sk. The proof signs the public key
+// of sk along with a nonce of the server, which makes it fresh.
+func ProvePossession(subject string, nonce []byte, sk *SKey) []byte {
+	pk := new(PKey)
+	_ = pk.FromSKey(sk)
+	return sign(possessionDigest(subject, nonce, pk), possessionDST, sk)
+}
+
+// VerifyPossession checks the proof that the subject holds the secret
+// key of pk, for the nonce of the server.
+func VerifyPossession(subject string, nonce, proof []byte, pk *PKey) (ok bool, err error) {
+	return verify(possessionDigest(subject, nonce, pk), proof, possessionDST, pk)
+}
+
+func sign(msg, dst []byte, sk *SKey) []byte {
+	curve := sk.Curve
+	h := curve.HashToG1(msg, dst)
+	s := curve.G1().New().ScalarMult(h, sk.Key).Marshal()
+	return append([]byte{byte(curve.ID())}, s...)
+}
+
+func verify(msg, sig, dst []byte, pk *PKey) (ok bool, err error) {
+	curve := pk.Curve
+	if len(sig) != SizeSignature(curve) || sig[0] != byte(curve.ID()) {
+		return false, ErrSignature
+	}
+	s := curve.G1().New()
+	if err = s.Unmarshal(sig[1:]); err != nil {
+		return false, errors.Join(ErrSignature, err)
+	}
+	g := curve.G2().New().ScalarBaseMult(big.NewInt(1))
+	A := curve.Pair(s, g)
+	B := curve.Pair(curve.HashToG1(msg, dst), pk.Key)
+	return A.Equal(B), nil
+}
+
+// MessageDigest is the digest of a message sent on a devspace, which
+// is signed by the sender. Every field is length prefixed so that no
+// two distinct messages share their encoding.
+func MessageDigest(space string, keyword, data []byte, attachments []string) []byte {
+	fields := [][]byte{[]byte(space), keyword, data}
+	for _, a := range attachments {
+		fields = append(fields, []byte(a))
+	}
+	return digest(fields...)
+}
+
+// possessionDigest is the digest of the proof of possession of pk.
+func possessionDigest(subject string, nonce []byte, pk *PKey) []byte {
+	return digest([]byte(subject), nonce, pk.Bytes())
+}
+
+// digest hashes the fields, each of which is length prefixed.
+func digest(fields ...[]byte) []byte {
+	h := sha256.New()
+	for _, b := range fields {
+		var n [8]byte
+		binary.BigEndian.PutUint64(n[:], uint64(len(b)))
+		h.Write(n[:])
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
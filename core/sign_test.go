@@ -0,0 +1,108 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	// setup signer key pair
+	sk, pk, err := KeyGen()
+	handleFatal(err, t)
+	// setup another key pair
+	_, pkOther, err := KeyGen()
+	handleFatal(err, t)
+
+	msg := MessageDigest("space", []byte("keyword"), []byte("data"), []string{"sha256:00"})
+	sig := Sign(msg, sk)
+
+	t.Run("truthy", func(t *testing.T) {
+		ok, err := Verify(msg, sig, pk)
+		handleFatal(err, t)
+		if !ok {
+			t.Fatal("signature is expected to verify")
+		}
+	})
+	t.Run("falsey", func(t *testing.T) {
+		ok, err := Verify(msg, sig, pkOther)
+		handleFatal(err, t)
+		if ok {
+			t.Fatal("signature is expected to only verify against the signer")
+		}
+		other := MessageDigest("space", []byte("keyword"), []byte("date"), []string{"sha256:00"})
+		ok, err = Verify(other, sig, pk)
+		handleFatal(err, t)
+		if ok {
+			t.Fatal("signature is expected to only verify the signed message")
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		invalid, err := getRandomBytes()
+		handleFatal(err, t)
+		_, err = Verify(msg, invalid, pk)
+		got := err
+		want := ErrSignature
+		if !errors.Is(got, want) {
+			t.Logf("expected: %v, got: %v", want, got)
+			t.Fatal("invalid signature error is expected")
+		}
+	})
+}
+
+func TestMessageDigest(t *testing.T) {
+	// fields are length prefixed, moving bytes across them changes the digest
+	a := MessageDigest("ab", []byte("c"), nil, nil)
+	b := MessageDigest("a", []byte("bc"), nil, nil)
+	if bytes.Equal(a, b) {
+		t.Fatal("distinct messages are expected to have distinct digests")
+	}
+}
+
+func TestPossession(t *testing.T) {
+	// setup key pairs of the user and another user
+	sk, pk, err := KeyGen()
+	handleFatal(err, t)
+	_, pkOther, err := KeyGen()
+	handleFatal(err, t)
+
+	nonce := []byte("nonce")
+	proof := ProvePossession("alice", nonce, sk)
+
+	t.Run("truthy", func(t *testing.T) {
+		ok, err := VerifyPossession("alice", nonce, proof, pk)
+		handleFatal(err, t)
+		if !ok {
+			t.Fatal("proof of possession is expected to verify")
+		}
+	})
+	t.Run("falsey", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			subject string
+			nonce   []byte
+			pk      *PKey
+		}{
+			{"key", "alice", nonce, pkOther},
+			{"subject", "bob", nonce, pk},
+			{"nonce", "alice", []byte("other"), pk},
+		}
+		for _, c := range cases {
+			ok, err := VerifyPossession(c.subject, c.nonce, proof, c.pk)
+			handleFatal(err, t)
+			if ok {
+				t.Logf("expected: %v, got: %v", false, ok)
+				t.Fatalf("proof of possession is expected not to verify with another %s", c.name)
+			}
+		}
+	})
+	t.Run("signature", func(t *testing.T) {
+		// a proof is never a signature of its digest
+		msg := possessionDigest("alice", nonce, pk)
+		ok, err := Verify(msg, proof, pk)
+		handleFatal(err, t)
+		if ok {
+			t.Fatal("proof of possession is expected not to verify as a signature")
+		}
+	})
+}
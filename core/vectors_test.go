@@ -0,0 +1,184 @@
+package core
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the test vectors in testdata")
+
+var vectorsFile = filepath.Join("testdata", "vectors.json")
+
+// vectorSuite is the suite of known answers of the scheme, which other
+// implementations check against. The fields describe how the randomness
+// of every vector is derived from its seed.
+type vectorSuite struct {
+	Description string   `json:"description"`
+	DRBG        string   `json:"drbg"`
+	Scalar      string   `json:"scalar"`
+	Order       string   `json:"order"`
+	Vectors     []vector `json:"vectors"`
+}
+
+type vector struct {
+	Curve           string `json:"curve"`
+	Seed            string `json:"seed"`
+	Space           string `json:"space"`
+	Keyword         string `json:"keyword"`
+	TrapdoorKeyword string `json:"trapdoor_keyword"`
+	ServerSK        string `json:"server_sk"`
+	ServerPK        string `json:"server_pk"`
+	SenderSK        string `json:"sender_sk"`
+	SenderPK        string `json:"sender_pk"`
+	ReceiverSK      string `json:"receiver_sk"`
+	ReceiverPK      string `json:"receiver_pk"`
+	Ciphertext      string `json:"ciphertext"`
+	Trapdoor        string `json:"trapdoor"`
+	Test            bool   `json:"test"`
+}
+
+// vectorCases are the inputs of the vectors of every curve.
+var vectorCases = []struct {
+	space, keyword, trapdoorKeyword string
+}{
+	{"proj", "deploy", "deploy"},
+	{"proj", "deploy", "release"},
+	{"proj", " Deploy ", "deploy"},
+	{"other", "ﬁx", "fix"},
+}
+
+func newVectorSuite() (*vectorSuite, error) {
+	suite := &vectorSuite{
+		Description: "Known answers of keygen, PEKS, trapdoor and test. Keys, ciphertexts and trapdoors are hex encoded.",
+		DRBG:        "The randomness is the concatenation of the blocks SHA-256(seed || i), of the counter i encoded as 8 bytes big endian from zero.",
+		Scalar:      "Scalars are sampled by reading as many bytes as the order of the curve takes, clearing the bits above the length of the order, and rejecting the big endian integer k unless 0 < k < order.",
+		Order:       "The randomness is drawn by the server keygen, the sender keygen, the receiver keygen, PEKS and trapdoor, in order.",
+	}
+	for _, curve := range Curves {
+		for i, c := range vectorCases {
+			seed := []byte(curve.String() + "/" + string(rune('0'+i)))
+			v, err := newVector(curve, seed, c.space, c.keyword, c.trapdoorKeyword)
+			if err != nil {
+				return nil, err
+			}
+			suite.Vectors = append(suite.Vectors, *v)
+		}
+	}
+	return suite, nil
+}
+
+func newVector(curve Curve, seed []byte, space, keyword, trapdoorKeyword string) (*vector, error) {
+	s := Scheme{Rand: NewDRBG(seed)}
+	skServer, pkServer, err := s.KeyGenServer(curve)
+	if err != nil {
+		return nil, err
+	}
+	skSender, pkSender, err := s.KeyGen(curve)
+	if err != nil {
+		return nil, err
+	}
+	skReceiver, pkReceiver, err := s.KeyGen(curve)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := s.PEKS(space, []byte(keyword), pkServer, pkReceiver, skSender)
+	if err != nil {
+		return nil, err
+	}
+	trapdoor, err := s.Trapdoor(space, []byte(trapdoorKeyword), pkServer, pkSender, skReceiver)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := Test(ciphertext, trapdoor, skServer)
+	if err != nil {
+		return nil, err
+	}
+	return &vector{
+		Curve:           curve.String(),
+		Seed:            hex.EncodeToString(seed),
+		Space:           space,
+		Keyword:         keyword,
+		TrapdoorKeyword: trapdoorKeyword,
+		ServerSK:        hex.EncodeToString(skServer.Bytes()),
+		ServerPK:        hex.EncodeToString(pkServer.Bytes()),
+		SenderSK:        hex.EncodeToString(skSender.Bytes()),
+		SenderPK:        hex.EncodeToString(pkSender.Bytes()),
+		ReceiverSK:      hex.EncodeToString(skReceiver.Bytes()),
+		ReceiverPK:      hex.EncodeToString(pkReceiver.Bytes()),
+		Ciphertext:      hex.EncodeToString(ciphertext),
+		Trapdoor:        hex.EncodeToString(trapdoor),
+		Test:            ok,
+	}, nil
+}
+
+func TestVectors(t *testing.T) {
+	suite, err := newVectorSuite()
+	handleFatal(err, t)
+	if *update {
+		data, err := json.MarshalIndent(suite, "", "  ")
+		handleFatal(err, t)
+		handleFatal(os.WriteFile(vectorsFile, append(data, '\n'), 0644), t)
+	}
+	data, err := os.ReadFile(vectorsFile)
+	handleFatal(err, t)
+	want := new(vectorSuite)
+	handleFatal(json.Unmarshal(data, want), t)
+	if len(want.Vectors) != len(suite.Vectors) {
+		t.Logf("expected: %v, got: %v", len(want.Vectors), len(suite.Vectors))
+		t.Fatal("incorrect number of test vectors")
+	}
+	for i, v := range want.Vectors {
+		t.Run(v.Curve+"/"+v.Keyword+"/"+v.TrapdoorKeyword, func(t *testing.T) {
+			got := suite.Vectors[i]
+			if got != v {
+				t.Logf("expected: %+v, got: %+v", v, got)
+				t.Fatal("test vector is expected to be reproduced")
+			}
+			// test as another implementation does, from the encodings
+			sk := new(SKey)
+			handleFatal(sk.FromBytes(decodeHex(v.ServerSK, t)), t)
+			ok, err := Test(decodeHex(v.Ciphertext, t), decodeHex(v.Trapdoor, t), sk)
+			handleFatal(err, t)
+			if ok != v.Test {
+				t.Logf("expected: %v, got: %v", v.Test, ok)
+				t.Fatal("incorrect result of Test")
+			}
+		})
+	}
+}
+
+func TestDRBG(t *testing.T) {
+	a, b := make([]byte, 100), make([]byte, 100)
+	_, err := NewDRBG([]byte("seed")).Read(a)
+	handleFatal(err, t)
+	// reads of any size yield the same stream
+	d := NewDRBG([]byte("seed"))
+	for i := 0; i < len(b); i += 7 {
+		j := i + 7
+		if j > len(b) {
+			j = len(b)
+		}
+		_, err = d.Read(b[i:j])
+		handleFatal(err, t)
+	}
+	if hex.EncodeToString(a) != hex.EncodeToString(b) {
+		t.Logf("expected: %x, got: %x", a, b)
+		t.Fatal("output of the DRBG is expected to be deterministic")
+	}
+	got := hex.EncodeToString(a[:8])
+	want := "1a30d3c0635d49b5"
+	if got != want {
+		t.Logf("expected: %v, got: %v", want, got)
+		t.Fatal("incorrect output of the DRBG")
+	}
+}
+
+func decodeHex(s string, t *testing.T) []byte {
+	b, err := hex.DecodeString(s)
+	handleFatal(err, t)
+	return b
+}
@@ -0,0 +1,98 @@
+package db
+
+import "time"
+
+// Blob is an attachment uploaded to a devspace. The content
+// itself lives in the blob store, addressed by its digest.
+type Blob struct {
+	Digest   string
+	On       string
+	Size     int64
+	Uploader string
+	Uploaded time.Time
+}
+
+// Attachment refers to a blob attached to a message.
+type Attachment struct {
+	Name   string
+	Digest string
+	Size   int64
+}
+
+var blobs []*Blob
+
+// AddBlob records the blob as uploaded to the devspace.
+// Uploading the same content again is not an error, and
+// renews the time of the upload.
+func AddBlob(b *Blob) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range blobs {
+		if v.Digest == b.Digest && v.On == b.On {
+			v.Uploaded = time.Now().UTC()
+			return true, nil
+		}
+	}
+	b.Uploaded = time.Now().UTC()
+	blobs = append(blobs, b)
+	return true, nil
+}
+
+func FindBlob(on string, digest string) (ok bool, b Blob, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, v := range blobs {
+		if v.Digest == digest && v.On == on {
+			return true, *v, nil
+		}
+	}
+	return
+}
+
+// BlobsSize returns the total size of the blobs uploaded to the devspace.
+func BlobsSize(on string) (size int64, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, v := range blobs {
+		if v.On == on {
+			size += v.Size
+		}
+	}
+	return
+}
+
+// PruneBlobs drops the blobs uploaded before the time which no message
+// of their devspace is attached to, and returns the digests of the
+// dropped blobs which no devspace has left, whose content is unused.
+func PruneBlobs(before time.Time) (unused []string, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	attached := make(map[[2]string]bool)
+	for _, m := range msgs {
+		for _, a := range m.Attachments {
+			attached[[2]string{m.On, a.Digest}] = true
+		}
+	}
+	kept := make([]*Blob, 0, len(blobs))
+	var dropped []string
+	for _, b := range blobs {
+		if attached[[2]string{b.On, b.Digest}] || !b.Uploaded.Before(before) {
+			kept = append(kept, b)
+		} else {
+			dropped = append(dropped, b.Digest)
+		}
+	}
+	blobs = kept
+	// the content of a blob is shared by the devspaces it is uploaded to
+	used := make(map[string]bool, len(kept))
+	for _, b := range kept {
+		used[b.Digest] = true
+	}
+	for _, d := range dropped {
+		if !used[d] {
+			used[d] = true
+			unused = append(unused, d)
+		}
+	}
+	return
+}
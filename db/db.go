@@ -0,0 +1,7 @@
+package db
+
+import "sync"
+
+// mu guards the in-memory tables of the package, which are
+// accessed concurrently by request handlers and background jobs.
+var mu sync.RWMutex
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"time"
+
+	"github.com/bingxueshuang/devspaces/core"
+)
+
+// LogEntry binds a public key to a user or a devspace at an epoch, the
+// key version of users or the key epoch of devspaces. An entry is
+// appended to the key transparency log whenever a key is registered.
+type LogEntry struct {
+	Kind   string
+	Name   string
+	Pubkey []byte
+	Epoch  int
+	Time   time.Time
+	// Leaf is the hash of the entry in the Merkle tree of the log.
+	Leaf []byte
+}
+
+var keyLog []*LogEntry
+
+// appendKey appends the binding to the key log. The caller holds mu,
+// so that keys and their log entries are added together.
+func appendKey(kind, name string, pubkey []byte, epoch int) {
+	keyLog = append(keyLog, &LogEntry{
+		Kind:   kind,
+		Name:   name,
+		Pubkey: pubkey,
+		Epoch:  epoch,
+		Time:   time.Now(),
+		Leaf:   core.LeafHash(core.BindingLeaf(kind, name, pubkey, int64(epoch))),
+	})
+}
+
+// KeyLog returns the entries of the key log, oldest first.
+func KeyLog() []LogEntry {
+	mu.RLock()
+	defer mu.RUnlock()
+	entries := make([]LogEntry, 0, len(keyLog))
+	for _, e := range keyLog {
+		entries = append(entries, *e)
+	}
+	return entries
+}
@@ -1,27 +1,160 @@
 package db
 
+import "time"
+
 type Message struct {
-	From    string
-	To      string
-	On      string
-	Tag     string
-	Data    []byte
-	Keyword []byte
+	ID          int64
+	Received    time.Time
+	From        string
+	To          string
+	On          string
+	Tag         string
+	Data        []byte
+	Keyword     []byte
+	Signature   []byte
+	Attachments []Attachment
+	Read        bool
+}
+
+// MessageQuery filters and orders the messages listed under a tag.
+// Zero values of the fields disable the respective filter.
+type MessageQuery struct {
+	// After is the id of the last message of the previous page.
+	After  int64
+	Since  time.Time
+	Until  time.Time
+	From   string
+	Limit  int
+	Desc   bool
+	Unread bool
 }
 
 var msgs []*Message
 
+var lastMessageID int64
+
 func AddMessage(m *Message) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	lastMessageID++
+	m.ID = lastMessageID
+	m.Received = time.Now().UTC()
 	msgs = append(msgs, m)
 	return true, nil
 }
 
-func ListMessages(tag string, on string) ([]Message, error) {
-	m := make([]Message, 0, len(msgs))
-	for _, msg := range msgs {
-		if msg.Tag == tag && msg.On == on {
-			m = append(m, *msg)
+func (q MessageQuery) match(m *Message) bool {
+	if q.After != 0 {
+		if !q.Desc && m.ID <= q.After {
+			return false
+		}
+		if q.Desc && m.ID >= q.After {
+			return false
+		}
+	}
+	if !q.Since.IsZero() && m.Received.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !m.Received.Before(q.Until) {
+		return false
+	}
+	if q.From != "" && m.From != q.From {
+		return false
+	}
+	if q.Unread && m.Read {
+		return false
+	}
+	return true
+}
+
+// ListMessages lists the messages under the tag of the devspace
+// matching the query, or under all tags if tag is empty.
+// more reports whether further pages exist.
+func ListMessages(tag string, on string, q MessageQuery) (m []Message, more bool, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	m = make([]Message, 0)
+	for i := range msgs {
+		msg := msgs[i]
+		if q.Desc {
+			msg = msgs[len(msgs)-1-i]
+		}
+		if tag != "" && msg.Tag != tag || msg.On != on || !q.match(msg) {
+			continue
+		}
+		if q.Limit > 0 && len(m) == q.Limit {
+			return m, true, nil
+		}
+		m = append(m, *msg)
+	}
+	return m, false, nil
+}
+
+// DeleteMessage removes the message with the given id from the devspace.
+func DeleteMessage(on string, id int64) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for i, m := range msgs {
+		if m.ID == id && m.On == on {
+			msgs = append(msgs[:i:i], msgs[i+1:]...)
+			return true, nil
+		}
+	}
+	return
+}
+
+// MarkMessage sets the read state of the message with the given id.
+func MarkMessage(on string, id int64, read bool) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, m := range msgs {
+		if m.ID == id && m.On == on {
+			m.Read = read
+			return true, nil
+		}
+	}
+	return
+}
+
+// DefaultRetention is the retention policy of the devspaces
+// which have none of their own.
+var DefaultRetention Retention
+
+// PruneMessages enforces the retention policies of all devspaces
+// and returns the number of messages removed.
+func PruneMessages(now time.Time) (n int, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	policies := make(map[string]Retention, len(spaces))
+	for _, s := range spaces {
+		p := s.Retention
+		if p == (Retention{}) {
+			p = DefaultRetention
+		}
+		if p != (Retention{}) {
+			policies[s.Name] = p
+		}
+	}
+	// walk newest first so that per tag counts keep the latest messages
+	counts := make(map[[2]string]int)
+	kept := make([]*Message, len(msgs))
+	k := len(kept)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		m := msgs[i]
+		if p, ok := policies[m.On]; ok {
+			if p.MaxAge > 0 && now.Sub(m.Received) > p.MaxAge {
+				continue
+			}
+			key := [2]string{m.On, m.Tag}
+			if p.MaxCount > 0 && counts[key] >= p.MaxCount {
+				continue
+			}
+			counts[key]++
 		}
+		k--
+		kept[k] = m
 	}
-	return m, nil
+	n = k
+	msgs = kept[k:]
+	return
 }
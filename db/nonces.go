@@ -0,0 +1,34 @@
+package db
+
+import (
+	"time"
+)
+
+// Nonce is a challenge issued by the server, which makes proofs of
+// possession fresh. A nonce is used once, before it expires. Nonces
+// are authenticated by the server rather than stored when issued, and
+// only the used ones are kept, until they expire.
+type Nonce struct {
+	Value   []byte
+	Expires time.Time
+}
+
+// usedNonces are the expiry times of the used nonces by value.
+var usedNonces = make(map[string]time.Time)
+
+// UseNonce records the use of the nonce at now, and reports whether it
+// had not been used before. Expired nonces are dropped.
+func UseNonce(n *Nonce, now time.Time) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for v, expires := range usedNonces {
+		if !now.Before(expires) {
+			delete(usedNonces, v)
+		}
+	}
+	if _, used := usedNonces[string(n.Value)]; used {
+		return false, nil
+	}
+	usedNonces[string(n.Value)] = n.Expires
+	return true, nil
+}
@@ -10,11 +10,15 @@ type Request struct {
 var requests []*Request
 
 func AddRequest(r *Request) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
 	requests = append(requests, r)
 	return true, nil
 }
 
 func RequestsTo(to string) ([]Request, error) {
+	mu.RLock()
+	defer mu.RUnlock()
 	r := make([]Request, 0, len(requests))
 	for _, v := range requests {
 		if v.To == to {
@@ -23,3 +27,32 @@ func RequestsTo(to string) ([]Request, error) {
 	}
 	return r, nil
 }
+
+// IsMember reports whether the user was invited to collaborate
+// on the devspace.
+func IsMember(space string, user string) (bool, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, v := range requests {
+		if v.On == space && v.To == user {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Members returns the users invited to collaborate on the
+// devspace, in the order they were first invited.
+func Members(space string) ([]string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	seen := make(map[string]bool)
+	var members []string
+	for _, v := range requests {
+		if v.On == space && !seen[v.To] {
+			seen[v.To] = true
+			members = append(members, v.To)
+		}
+	}
+	return members, nil
+}
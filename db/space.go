@@ -1,29 +1,76 @@
 package db
 
-import "github.com/bingxueshuang/devspaces/core"
+import (
+	"time"
+
+	"github.com/bingxueshuang/devspaces/core"
+)
 
 type Tag struct {
 	Name     string
 	Trapdoor []byte
+	Epoch    int
+	// Sender is the user whose messages the trapdoor matches, if known,
+	// and SenderKey the version of the key of the sender it was made for.
+	Sender    string
+	SenderKey int
 }
 
-type Space struct {
-	Name   string
-	Owner  string
+// SpaceKey is a retired devspace public key which is still
+// accepted for routing until the transition window ends.
+type SpaceKey struct {
+	Epoch  int
 	Pubkey []byte
-	Tags   []*Tag
+	Until  time.Time
+}
+
+// Retention limits how long messages of a devspace are kept.
+// Zero values of the fields disable the respective limit.
+type Retention struct {
+	MaxAge   time.Duration
+	MaxCount int // per tag
+}
+
+// Keyword encryption schemes of a devspace. Tags of the sender scheme
+// match the keyword from a single sender, those of the shared scheme
+// match it from any sender, who instead signs its messages.
+const (
+	SchemeSender = "sender"
+	SchemeShared = "shared"
+)
+
+type Space struct {
+	Name      string
+	Owner     string
+	Pubkey    []byte
+	Scheme    string
+	Epoch     int
+	Retired   []SpaceKey
+	Retention Retention
+	Tags      []*Tag
 }
 
 var spaces []*Space
 
 func AddSpace(s *Space) (bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range spaces {
+		if v.Name == s.Name {
+			return false, nil
+		}
+	}
 	spaces = append(spaces, s)
+	appendKey(core.BindingSpace, s.Name, s.Pubkey, s.Epoch)
 	return true, nil
 }
 
 func AddTag(space string, tag *Tag) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
 	for _, s := range spaces {
 		if s.Name == space {
+			tag.Epoch = s.Epoch
 			s.Tags = append(s.Tags, tag)
 			return true, nil
 		}
@@ -31,10 +78,86 @@ func AddTag(space string, tag *Tag) (ok bool, err error) {
 	return
 }
 
+// RotateKey replaces the public key of the devspace with pubkey
+// and adds the re-issued tags under the new key epoch. Tags of the
+// previous epoch keep routing messages until window has elapsed.
+func RotateKey(space string, pubkey []byte, tags []*Tag, window time.Duration) (ok bool, epoch int, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, s := range spaces {
+		if s.Name != space {
+			continue
+		}
+		now := time.Now()
+		s.Retired = append(s.Retired, SpaceKey{
+			Epoch:  s.Epoch,
+			Pubkey: s.Pubkey,
+			Until:  now.Add(window),
+		})
+		s.Epoch++
+		s.Pubkey = pubkey
+		appendKey(core.BindingSpace, s.Name, pubkey, s.Epoch)
+		pruneRetired(s, now)
+		for _, t := range tags {
+			t.Epoch = s.Epoch
+			s.Tags = append(s.Tags, t)
+		}
+		return true, s.Epoch, nil
+	}
+	return
+}
+
+// pruneRetired drops the retired keys whose transition window
+// has ended along with the tags issued under them.
+func pruneRetired(s *Space, now time.Time) {
+	retired := make([]SpaceKey, 0, len(s.Retired))
+	for _, k := range s.Retired {
+		if now.Before(k.Until) {
+			retired = append(retired, k)
+		}
+	}
+	s.Retired = retired
+	tags := make([]*Tag, 0, len(s.Tags))
+	for _, t := range s.Tags {
+		if s.activeEpoch(t.Epoch, now) {
+			tags = append(tags, t)
+		}
+	}
+	s.Tags = tags
+}
+
+// activeEpoch reports whether tags issued under epoch may
+// still be used for routing messages at the given time.
+func (s *Space) activeEpoch(epoch int, now time.Time) bool {
+	if epoch == s.Epoch {
+		return true
+	}
+	for _, k := range s.Retired {
+		if k.Epoch == epoch {
+			return now.Before(k.Until)
+		}
+	}
+	return false
+}
+
+func SetRetention(space string, r Retention) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, s := range spaces {
+		if s.Name == space {
+			s.Retention = r
+			return true, nil
+		}
+	}
+	return
+}
+
 func ListTags(sp string) ([]*Tag, error) {
-	ok, space, err := FindSpace(sp)
-	if !ok || err != nil {
-		return nil, err
+	mu.RLock()
+	defer mu.RUnlock()
+	ok, space := findSpace(sp)
+	if !ok {
+		return nil, nil
 	}
 	tags := make([]*Tag, len(space.Tags))
 	copy(tags, space.Tags)
@@ -42,6 +165,8 @@ func ListTags(sp string) ([]*Tag, error) {
 }
 
 func ListSpaces(owner string) ([]Space, error) {
+	mu.RLock()
+	defer mu.RUnlock()
 	sp := make([]Space, 0, len(spaces))
 	for _, s := range spaces {
 		if s.Owner == owner {
@@ -52,17 +177,32 @@ func ListSpaces(owner string) ([]Space, error) {
 }
 
 func FindSpace(space string) (ok bool, s Space, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	ok, s = findSpace(space)
+	return
+}
+
+func findSpace(space string) (bool, Space) {
 	for _, v := range spaces {
 		if v.Name == space {
-			return true, *v, nil
+			return true, *v
 		}
 	}
-	return
+	return false, Space{}
 }
 
 func MessageTag(ciphertext []byte, server *core.SKey, sp Space) (string, error) {
+	test := core.Test
+	if sp.Scheme == SchemeShared {
+		test = core.TestShared
+	}
+	now := time.Now()
 	for _, tag := range sp.Tags {
-		ok, err := core.Test(ciphertext, tag.Trapdoor, server)
+		if !sp.activeEpoch(tag.Epoch, now) {
+			continue
+		}
+		ok, err := test(ciphertext, tag.Trapdoor, server)
 		if err != nil {
 			return "", err
 		}
@@ -1,24 +1,90 @@
 package db
 
+import (
+	"time"
+
+	"github.com/bingxueshuang/devspaces/core"
+)
+
 type User struct {
 	Username string
 	Password string
 	Pubkey   []byte
+	Keys     []UserKey
+}
+
+// UserKey is a version of the public key of a user, valid from its
+// registration until it is replaced. The current key has a zero Until.
+type UserKey struct {
+	Version int
+	Pubkey  []byte
+	From    time.Time
+	Until   time.Time
 }
 
 var users []*User
 
 func AddUser(user *User) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
 	for _, u := range users {
 		if u.Username == user.Username {
 			return false, nil
 		}
 	}
+	if len(user.Keys) == 0 {
+		user.Keys = []UserKey{{
+			Version: 1,
+			Pubkey:  user.Pubkey,
+			From:    time.Now(),
+		}}
+	}
 	users = append(users, user)
+	k := user.Keys[len(user.Keys)-1]
+	appendKey(core.BindingUser, user.Username, k.Pubkey, k.Version)
 	return true, nil
 }
 
+// RotateUserKey replaces the public key of the user with pubkey, ending
+// the validity of the current key. It returns the new key version.
+func RotateUserKey(uname string, pubkey []byte) (ok bool, version int, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, u := range users {
+		if u.Username != uname {
+			continue
+		}
+		now := time.Now()
+		keys := make([]UserKey, len(u.Keys), len(u.Keys)+1)
+		copy(keys, u.Keys)
+		version = 1
+		if n := len(keys); n != 0 {
+			keys[n-1].Until = now
+			version = keys[n-1].Version + 1
+		}
+		u.Keys = append(keys, UserKey{
+			Version: version,
+			Pubkey:  pubkey,
+			From:    now,
+		})
+		u.Pubkey = pubkey
+		appendKey(core.BindingUser, u.Username, pubkey, version)
+		return true, version, nil
+	}
+	return
+}
+
+// KeyVersion is the version of the current public key of the user.
+func (u User) KeyVersion() int {
+	if len(u.Keys) == 0 {
+		return 1
+	}
+	return u.Keys[len(u.Keys)-1].Version
+}
+
 func MatchUser(user *User) (ok bool, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
 	for _, u := range users {
 		if u.Username == user.Username {
 			return u.Password == user.Password, nil
@@ -28,6 +94,8 @@ func MatchUser(user *User) (ok bool, err error) {
 }
 
 func GetUser(uname string) (ok bool, user User, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
 	for _, u := range users {
 		if u.Username == uname {
 			return true, *u, nil
@@ -37,6 +105,8 @@ func GetUser(uname string) (ok bool, user User, err error) {
 }
 
 func ListUsers() []User {
+	mu.RLock()
+	defer mu.RUnlock()
 	slice := make([]User, 0, len(users))
 	for _, u := range users {
 		slice = append(slice, *u)
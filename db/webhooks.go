@@ -0,0 +1,208 @@
+package db
+
+import "time"
+
+// Webhook is an url notified of the messages routed to a tag
+// of the devspace, or to any tag if Tag is empty.
+type Webhook struct {
+	ID      int64
+	On      string
+	Tag     string
+	URL     string
+	Secret  []byte
+	Created time.Time
+}
+
+// Delivery is a single attempt at delivering a message to a webhook.
+type Delivery struct {
+	Webhook  int64
+	Message  int64
+	Attempt  int
+	Status   int
+	Error    string
+	Time     time.Time
+	Duration time.Duration
+}
+
+// DeadLetter is a message whose delivery to a webhook
+// failed even after all the retries.
+type DeadLetter struct {
+	Webhook  int64
+	Message  int64
+	Payload  []byte
+	Attempts int
+	Error    string
+	Time     time.Time
+}
+
+var (
+	webhooks      []*Webhook
+	deliveries    []*Delivery
+	deadLetters   []*DeadLetter
+	lastWebhookID int64
+)
+
+// MaxDeliveries is the number of delivery attempts logged per webhook,
+// and MaxDeadLetters the number of dead letters kept per webhook.
+var (
+	MaxDeliveries  = 100
+	MaxDeadLetters = 100
+)
+
+func AddWebhook(w *Webhook) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	lastWebhookID++
+	w.ID = lastWebhookID
+	w.Created = time.Now().UTC()
+	webhooks = append(webhooks, w)
+	return true, nil
+}
+
+// DeleteWebhook removes the webhook along with its delivery
+// log and its dead letters.
+func DeleteWebhook(on string, id int64) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for i, w := range webhooks {
+		if w.ID == id && w.On == on {
+			webhooks = append(webhooks[:i:i], webhooks[i+1:]...)
+			kept := make([]*Delivery, 0, len(deliveries))
+			for _, d := range deliveries {
+				if d.Webhook != id {
+					kept = append(kept, d)
+				}
+			}
+			deliveries = kept
+			dead := make([]*DeadLetter, 0, len(deadLetters))
+			for _, d := range deadLetters {
+				if d.Webhook != id {
+					dead = append(dead, d)
+				}
+			}
+			deadLetters = dead
+			return true, nil
+		}
+	}
+	return
+}
+
+// hasWebhook reports whether the webhook of the id exists,
+// with mu held.
+func hasWebhook(id int64) bool {
+	for _, w := range webhooks {
+		if w.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func FindWebhook(on string, id int64) (ok bool, w Webhook, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, v := range webhooks {
+		if v.ID == id && v.On == on {
+			return true, *v, nil
+		}
+	}
+	return
+}
+
+func ListWebhooks(on string) ([]Webhook, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	w := make([]Webhook, 0)
+	for _, v := range webhooks {
+		if v.On == on {
+			w = append(w, *v)
+		}
+	}
+	return w, nil
+}
+
+// WebhooksFor lists the webhooks to be notified of a message
+// routed to the tag of the devspace.
+func WebhooksFor(on string, tag string) ([]Webhook, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	w := make([]Webhook, 0)
+	for _, v := range webhooks {
+		if v.On == on && (v.Tag == "" || v.Tag == tag) {
+			w = append(w, *v)
+		}
+	}
+	return w, nil
+}
+
+// LogDelivery records the delivery attempt, keeping only the
+// latest MaxDeliveries attempts of each webhook. Attempts of
+// deleted webhooks are dropped.
+func LogDelivery(d *Delivery) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !hasWebhook(d.Webhook) {
+		return false, nil
+	}
+	deliveries = append(deliveries, d)
+	count := 0
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		if deliveries[i].Webhook != d.Webhook {
+			continue
+		}
+		count++
+		if count > MaxDeliveries {
+			deliveries = append(deliveries[:i:i], deliveries[i+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
+
+func ListDeliveries(webhook int64) ([]Delivery, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	d := make([]Delivery, 0)
+	for _, v := range deliveries {
+		if v.Webhook == webhook {
+			d = append(d, *v)
+		}
+	}
+	return d, nil
+}
+
+// AddDeadLetter records the failed delivery, keeping only the
+// latest MaxDeadLetters of each webhook. Dead letters of deleted
+// webhooks are dropped.
+func AddDeadLetter(d *DeadLetter) (ok bool, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !hasWebhook(d.Webhook) {
+		return false, nil
+	}
+	deadLetters = append(deadLetters, d)
+	count := 0
+	for i := len(deadLetters) - 1; i >= 0; i-- {
+		if deadLetters[i].Webhook != d.Webhook {
+			continue
+		}
+		count++
+		if count > MaxDeadLetters {
+			deadLetters = append(deadLetters[:i:i], deadLetters[i+1:]...)
+			break
+		}
+	}
+	return true, nil
+}
+
+func ListDeadLetters(webhook int64) ([]DeadLetter, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	d := make([]DeadLetter, 0)
+	for _, v := range deadLetters {
+		if v.Webhook == webhook {
+			d = append(d, *v)
+		}
+	}
+	return d, nil
+}